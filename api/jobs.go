@@ -0,0 +1,414 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"sslscanner/internal/fsutil"
+	"sslscanner/internal/logging"
+	"sslscanner/model"
+	"sslscanner/service"
+)
+
+// Estados de un trabajo
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+const (
+	DefaultQueueSize = 100
+	// maxFinishedJobs acota los trabajos terminados que se conservan
+	maxFinishedJobs = 1000
+)
+
+// ErrQueueFull se devuelve cuando no hay lugar para otro trabajo
+var ErrQueueFull = errors.New("la cola de análisis está llena")
+
+// Options son las opciones de análisis que acepta POST /scans
+type Options struct {
+	New            bool `json:"new,omitempty"`
+	MaxAge         *int `json:"maxAge,omitempty"`
+	IgnoreMismatch bool `json:"ignoreMismatch,omitempty"`
+}
+
+func (o Options) scanOptions() service.ScanOptions {
+	opts := service.DefaultScanOptions()
+	opts.ForceNew = o.New
+	opts.IgnoreMismatch = o.IgnoreMismatch
+	if o.MaxAge != nil {
+		opts.MaxAgeHours = *o.MaxAge
+	}
+	return opts
+}
+
+// Job es un análisis pedido por la API
+type Job struct {
+	ID         string     `json:"id"`
+	Domain     string     `json:"domain"`
+	Options    Options    `json:"options"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Error      string     `json:"error,omitempty"`
+
+	// ScanStatus y Endpoints son el último estado informado por el motor
+	ScanStatus    string             `json:"scanStatus,omitempty"`
+	StatusMessage string             `json:"statusMessage,omitempty"`
+	Endpoints     []EndpointProgress `json:"endpoints,omitempty"`
+}
+
+// EndpointProgress es el avance de un endpoint según el último polling
+type EndpointProgress struct {
+	IPAddress     string `json:"ipAddress"`
+	Progress      int    `json:"progress"`
+	StatusDetails string `json:"statusDetails,omitempty"`
+	Grade         string `json:"grade,omitempty"`
+}
+
+func (j *Job) active() bool {
+	return j.Status == JobQueued || j.Status == JobRunning
+}
+
+// requeue devuelve el trabajo a la cola sin rastros del intento anterior
+func (j *Job) requeue() {
+	j.Status = JobQueued
+	j.StartedAt, j.FinishedAt = nil, nil
+	j.Error = ""
+	j.ScanStatus, j.StatusMessage, j.Endpoints = "", "", nil
+}
+
+// Queue ejecuta los trabajos con un único service.Scanner compartido, que
+// respeta los cupos y el cool-off de SSL Labs. Los trabajos se guardan en
+// statePath; los que quedaron pendientes al cerrar se retoman al arrancar.
+type Queue struct {
+	scanner   *service.Scanner
+	statePath string
+	log       logging.Logger
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	pending chan string
+}
+
+// NewQueue crea la cola y restaura los trabajos guardados. Para seguir el
+// progreso de los trabajos, el llamador registra Queue.Observe en el scanner
+// (ver service.MultiObserver).
+func NewQueue(scanner *service.Scanner, statePath string, size int) (*Queue, error) {
+	if size < 1 {
+		size = DefaultQueueSize
+	}
+
+	jobs, err := loadJobs(statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	restored := []*Job{}
+	for _, job := range jobs {
+		if job.active() {
+			job.requeue()
+			restored = append(restored, job)
+		}
+	}
+	sort.Slice(restored, func(i, j int) bool { return restored[i].CreatedAt.Before(restored[j].CreatedAt) })
+
+	q := &Queue{
+		scanner:   scanner,
+		statePath: statePath,
+		jobs:      jobs,
+		pending:   make(chan string, max(size, len(restored))),
+	}
+	for _, job := range restored {
+		q.pending <- job.ID
+	}
+
+	return q, nil
+}
+
+// SetLogOutput redirige el registro de actividad (por defecto se descarta)
+func (q *Queue) SetLogOutput(out io.Writer) {
+	q.log.SetOutput(out)
+}
+
+// Submit encola un análisis. Si ya hay un trabajo pendiente para el mismo
+// dominio y opciones se devuelve ese, con existing en true.
+func (q *Queue) Submit(domain string, options Options) (job Job, existing bool, err error) {
+	if err := q.scanner.ValidateTarget(domain); err != nil {
+		return Job{}, false, err
+	}
+	if err := options.scanOptions().Validate(); err != nil {
+		return Job{}, false, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, j := range q.jobs {
+		if j.active() && j.Domain == domain && j.Options.scanOptions() == options.scanOptions() {
+			return *j, true, nil
+		}
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, false, err
+	}
+
+	created := &Job{
+		ID:        id,
+		Domain:    domain,
+		Options:   options,
+		Status:    JobQueued,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	select {
+	case q.pending <- id:
+	default:
+		return Job{}, false, ErrQueueFull
+	}
+
+	q.jobs[id] = created
+	q.pruneLocked()
+	q.saveLocked()
+
+	return *created, false, nil
+}
+
+// Get devuelve una copia del trabajo
+func (q *Queue) Get(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	copied := *job
+	copied.Endpoints = append([]EndpointProgress(nil), job.Endpoints...)
+	return copied, true
+}
+
+// Run procesa la cola con workers análisis simultáneos hasta que se cancela
+// ctx. Los análisis interrumpidos vuelven a quedar pendientes.
+func (q *Queue) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-q.pending:
+					q.process(ctx, id)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (q *Queue) process(ctx context.Context, id string) {
+	q.mu.Lock()
+	job, ok := q.jobs[id]
+	if !ok {
+		q.mu.Unlock()
+		return
+	}
+	started := time.Now().UTC()
+	job.Status = JobRunning
+	job.StartedAt = &started
+	job.Error = ""
+	job.ScanStatus, job.StatusMessage, job.Endpoints = "", "", nil
+	domain, opts := job.Domain, job.Options.scanOptions()
+	q.saveLocked()
+	q.mu.Unlock()
+
+	q.log.Printf("%s: análisis iniciado (trabajo %s)", domain, id)
+	host, err := q.scanner.RunAnalysis(ctx, domain, opts)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err != nil && ctx.Err() != nil {
+		// se retoma en el próximo arranque
+		job.requeue()
+		q.saveLocked()
+		return
+	}
+
+	finished := time.Now().UTC()
+	job.FinishedAt = &finished
+
+	switch {
+	case err != nil:
+		job.Status = JobFailed
+		job.Error = err.Error()
+	case host.Status != service.StatusReady:
+		job.Status = JobFailed
+		job.Error = host.StatusMessage
+		job.ScanStatus = host.Status
+	default:
+		job.Status = JobDone
+		job.updateProgress(host)
+	}
+
+	q.log.Printf("%s: trabajo %s %s", domain, id, job.Status)
+	q.saveLocked()
+}
+
+// Observe actualiza el progreso de los trabajos en curso con los eventos del scanner
+func (q *Queue) Observe(event service.Event) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	switch e := event.(type) {
+	case service.StatusChanged:
+		for _, job := range q.runningJobs(e.Domain, e.Options) {
+			job.ScanStatus = e.Status
+			job.StatusMessage = e.Message
+		}
+	case service.EndpointProgress:
+		progress := EndpointProgress{
+			IPAddress:     e.IPAddress,
+			Progress:      e.Progress,
+			StatusDetails: e.Details,
+			Grade:         e.Grade,
+		}
+		for _, job := range q.runningJobs(e.Domain, e.Options) {
+			job.setEndpoint(progress)
+		}
+	}
+}
+
+// runningJobs devuelve los trabajos en curso del dominio con esas opciones; el
+// mismo dominio puede analizarse a la vez con opciones distintas
+func (q *Queue) runningJobs(domain string, opts service.ScanOptions) []*Job {
+	jobs := []*Job{}
+	for _, job := range q.jobs {
+		if job.Status == JobRunning && job.Domain == domain && job.Options.scanOptions() == opts {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// setEndpoint reemplaza el avance del endpoint, o lo agrega si es nuevo
+func (j *Job) setEndpoint(progress EndpointProgress) {
+	for i := range j.Endpoints {
+		if j.Endpoints[i].IPAddress == progress.IPAddress {
+			j.Endpoints[i] = progress
+			return
+		}
+	}
+	j.Endpoints = append(j.Endpoints, progress)
+}
+
+func (j *Job) updateProgress(host *model.Host) {
+	j.ScanStatus = host.Status
+	j.StatusMessage = host.StatusMessage
+	j.Endpoints = make([]EndpointProgress, 0, len(host.Endpoints))
+	for _, endpoint := range host.Endpoints {
+		j.Endpoints = append(j.Endpoints, EndpointProgress{
+			IPAddress:     endpoint.IPAddress,
+			Progress:      endpoint.Progress,
+			StatusDetails: endpoint.StatusDetailsMessage,
+			Grade:         endpoint.Grade,
+		})
+	}
+}
+
+// pruneLocked descarta los trabajos terminados más viejos. Debe llamarse con q.mu tomado.
+func (q *Queue) pruneLocked() {
+	finished := []*Job{}
+	for _, job := range q.jobs {
+		if !job.active() {
+			finished = append(finished, job)
+		}
+	}
+	if len(finished) <= maxFinishedJobs {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool { return finished[i].CreatedAt.Before(finished[j].CreatedAt) })
+	for _, job := range finished[:len(finished)-maxFinishedJobs] {
+		delete(q.jobs, job.ID)
+	}
+}
+
+// saveLocked guarda los trabajos; un fallo no detiene la cola. Debe llamarse con q.mu tomado.
+func (q *Queue) saveLocked() {
+	if q.statePath == "" {
+		return
+	}
+	if err := saveJobs(q.statePath, q.jobs); err != nil {
+		q.log.Printf("Advertencia: %v", err)
+	}
+}
+
+func loadJobs(path string) (map[string]*Job, error) {
+	jobs := make(map[string]*Job)
+	if path == "" {
+		return jobs, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return jobs, nil
+		}
+		return nil, fmt.Errorf("falló al leer los trabajos: %w", err)
+	}
+
+	var list []*Job
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("falló al decodificar los trabajos %s: %w", path, err)
+	}
+	for _, job := range list {
+		jobs[job.ID] = job
+	}
+
+	return jobs, nil
+}
+
+// saveJobs escribe los trabajos ordenados por fecha de forma atómica
+func saveJobs(path string, jobs map[string]*Job) error {
+	list := make([]*Job, 0, len(jobs))
+	for _, job := range jobs {
+		list = append(list, job)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("falló al codificar los trabajos: %w", err)
+	}
+
+	if err := fsutil.WriteFileAtomic(path, data, 0o600); err != nil {
+		return fmt.Errorf("falló al guardar los trabajos: %w", err)
+	}
+	return nil
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("no se pudo generar el id del trabajo: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sslscanner/model"
+	"sslscanner/service"
+	"sslscanner/service/scantest"
+)
+
+func testHost(domain string) *model.Host {
+	return &model.Host{
+		Host:      domain,
+		Endpoints: []model.Endpoint{{IPAddress: "192.0.2.1", StatusMessage: "Ready", Grade: "A"}},
+	}
+}
+
+func newTestQueue(t *testing.T, backend *scantest.Backend, statePath string, size int) *Queue {
+	t.Helper()
+
+	scanner := service.NewScannerWithBackend(backend)
+	scanner.SetPolling(service.Polling{Initial: time.Millisecond, Running: time.Millisecond, MaxWait: time.Minute})
+
+	queue, err := NewQueue(scanner, statePath, size)
+	if err != nil {
+		t.Fatalf("NewQueue: %v", err)
+	}
+	scanner.SetObserver(queue.Observe)
+	return queue
+}
+
+// waitStatus espera a que el trabajo llegue a status
+func waitStatus(t *testing.T, queue *Queue, id, status string) Job {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, ok := queue.Get(id); ok && job.Status == status {
+			return job
+		}
+		time.Sleep(time.Millisecond)
+	}
+	job, _ := queue.Get(id)
+	t.Fatalf("trabajo %s en %s, se esperaba %s", id, job.Status, status)
+	return Job{}
+}
+
+func TestSubmitDedup(t *testing.T) {
+	queue := newTestQueue(t, scantest.New(), "", 2)
+
+	first, existing, err := queue.Submit("example.com", Options{})
+	if err != nil || existing {
+		t.Fatalf("Submit = %+v, %t, %v", first, existing, err)
+	}
+
+	// maxAge explícito con el valor por defecto es el mismo análisis
+	maxAge := service.DefaultMaxAgeHours
+	same, existing, err := queue.Submit("example.com", Options{MaxAge: &maxAge})
+	if err != nil || !existing || same.ID != first.ID {
+		t.Errorf("Submit repetido = %+v, %t, %v; se esperaba el trabajo %s", same, existing, err, first.ID)
+	}
+
+	other, existing, err := queue.Submit("example.com", Options{IgnoreMismatch: true})
+	if err != nil || existing || other.ID == first.ID {
+		t.Errorf("con otras opciones = %+v, %t, %v; se esperaba un trabajo nuevo", other, existing, err)
+	}
+
+	if _, _, err := queue.Submit("otro.example.com", Options{}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("err = %v, se esperaba ErrQueueFull", err)
+	}
+	if _, _, err := queue.Submit("no es un dominio", Options{}); err == nil {
+		t.Error("Submit aceptó un dominio inválido")
+	}
+}
+
+func TestRunPersistsJobs(t *testing.T) {
+	backend := scantest.New()
+	backend.Script("a.example.com", testHost("a.example.com"), scantest.DNS(), scantest.InProgress(50, "Testing protocols"), scantest.Ready())
+	backend.Script("b.example.com", nil, scantest.Failed("Unable to resolve domain name"))
+
+	statePath := filepath.Join(t.TempDir(), "state", "jobs.json")
+	queue := newTestQueue(t, backend, statePath, 0)
+
+	a, _, err := queue.Submit("a.example.com", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _, err := queue.Submit("b.example.com", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		queue.Run(ctx, 2)
+		close(done)
+	}()
+
+	doneJob := waitStatus(t, queue, a.ID, JobDone)
+	failedJob := waitStatus(t, queue, b.ID, JobFailed)
+	cancel()
+	<-done
+
+	if doneJob.ScanStatus != service.StatusReady || len(doneJob.Endpoints) != 1 || doneJob.Endpoints[0].Grade != "A" {
+		t.Errorf("trabajo terminado = %+v", doneJob)
+	}
+	if !strings.Contains(failedJob.Error, "Unable to resolve domain name") || failedJob.FinishedAt == nil {
+		t.Errorf("trabajo fallido = %+v", failedJob)
+	}
+
+	// el archivo guarda el estado final de ambos trabajos
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		t.Fatalf("falta el estado: %v", err)
+	}
+	var saved []Job
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	statuses := map[string]string{}
+	for _, job := range saved {
+		statuses[job.ID] = job.Status
+	}
+	if statuses[a.ID] != JobDone || statuses[b.ID] != JobFailed {
+		t.Errorf("estado guardado = %v", statuses)
+	}
+}
+
+func TestNewQueueRestoresPendingJobs(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "jobs.json")
+	started := time.Now().UTC().Add(-time.Minute)
+	created := started.Add(-time.Minute)
+
+	saved := []Job{
+		{ID: "running", Domain: "a.example.com", Status: JobRunning, CreatedAt: created, StartedAt: &started, Error: "intento anterior", ScanStatus: "IN_PROGRESS"},
+		{ID: "queued", Domain: "b.example.com", Status: JobQueued, CreatedAt: created.Add(time.Second)},
+		{ID: "done", Domain: "c.example.com", Status: JobDone, CreatedAt: created, StartedAt: &started, FinishedAt: &started},
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(statePath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := scantest.New()
+	backend.Script("a.example.com", testHost("a.example.com"))
+	backend.Script("b.example.com", testHost("b.example.com"))
+	queue := newTestQueue(t, backend, statePath, 0)
+
+	restored, ok := queue.Get("running")
+	if !ok || restored.Status != JobQueued || restored.StartedAt != nil || restored.Error != "" || restored.ScanStatus != "" {
+		t.Errorf("trabajo restaurado = %+v", restored)
+	}
+	if job, _ := queue.Get("done"); job.Status != JobDone {
+		t.Errorf("un trabajo terminado no se vuelve a encolar: %+v", job)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		queue.Run(ctx, 1)
+		close(done)
+	}()
+	waitStatus(t, queue, "running", JobDone)
+	waitStatus(t, queue, "queued", JobDone)
+	cancel()
+	<-done
+
+	// se retoman en el orden en que se crearon
+	var domains []string
+	for _, call := range backend.Calls() {
+		if call.Method == "StartAnalysis" {
+			domains = append(domains, call.Domain)
+		}
+	}
+	if len(domains) != 2 || domains[0] != "a.example.com" {
+		t.Errorf("análisis iniciados = %v", domains)
+	}
+}
+
+func TestObserveMatchesOptions(t *testing.T) {
+	queue := newTestQueue(t, scantest.New(), "", 0)
+
+	plain, _, err := queue.Submit("example.com", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mismatch, _, err := queue.Submit("example.com", Options{IgnoreMismatch: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue.mu.Lock()
+	for _, job := range queue.jobs {
+		job.Status = JobRunning
+	}
+	queue.mu.Unlock()
+
+	opts := Options{IgnoreMismatch: true}.scanOptions()
+	queue.Observe(service.StatusChanged{Domain: "example.com", Options: opts, Status: "IN_PROGRESS"})
+	queue.Observe(service.EndpointProgress{Domain: "example.com", Options: opts, IPAddress: "192.0.2.1", Progress: 40})
+
+	if job, _ := queue.Get(mismatch.ID); job.ScanStatus != "IN_PROGRESS" || len(job.Endpoints) != 1 || job.Endpoints[0].Progress != 40 {
+		t.Errorf("trabajo con ignoreMismatch = %+v", job)
+	}
+	if job, _ := queue.Get(plain.ID); job.ScanStatus != "" || len(job.Endpoints) != 0 {
+		t.Errorf("el evento de otras opciones actualizó el trabajo: %+v", job)
+	}
+}
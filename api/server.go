@@ -0,0 +1,161 @@
+// Package api expone el escáner como una API REST: POST /scans encola un
+// análisis, GET /scans/{id} informa su avance y GET /hosts/{domain}/latest
+// devuelve el último reporte guardado en el historial.
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"sslscanner/history"
+	"sslscanner/internal/httpserve"
+	"sslscanner/output"
+)
+
+const (
+	DefaultAddr = "127.0.0.1:8089"
+
+	// maxBodySize limita el cuerpo de POST /scans
+	maxBodySize = 64 << 10
+)
+
+// Server atiende la API sobre una cola de trabajos
+type Server struct {
+	queue   *Queue
+	history *history.Store
+	token   string
+}
+
+// NewServer crea el servidor. Los resultados terminados se leen de store, donde
+// el scanner de la cola los guarda. Si token no está vacío, cada solicitud
+// debe traer "Authorization: Bearer <token>".
+func NewServer(queue *Queue, store *history.Store, token string) *Server {
+	queue.scanner.SetHistory(store)
+
+	return &Server{
+		queue:   queue,
+		history: store,
+		token:   token,
+	}
+}
+
+// Handler devuelve las rutas de la API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /scans", s.createScan)
+	mux.HandleFunc("GET /scans/{id}", s.getScan)
+	mux.HandleFunc("GET /hosts/{domain}/latest", s.latestHost)
+	return s.authenticate(mux)
+}
+
+// ListenAndServe atiende en addr y procesa la cola con workers análisis
+// simultáneos hasta que se cancela ctx. La actividad se registra en la salida
+// de Queue.SetLogOutput.
+func (s *Server) ListenAndServe(ctx context.Context, addr string, workers int) error {
+	started := func(addr net.Addr) {
+		s.queue.log.Printf("API escuchando en http://%s", addr)
+	}
+	return httpserve.ListenAndServe(ctx, addr, s.Handler(), started, func(ctx context.Context) {
+		s.queue.Run(ctx, workers)
+	})
+}
+
+type scanRequest struct {
+	Domain string `json:"domain"`
+	Options
+}
+
+func (s *Server) createScan(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+
+	var req scanRequest
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("cuerpo inválido: %v", err))
+		return
+	}
+
+	domain := strings.ToLower(strings.TrimSpace(req.Domain))
+	job, existing, err := s.queue.Submit(domain, req.Options)
+	switch {
+	case errors.Is(err, ErrQueueFull):
+		w.Header().Set("Retry-After", "60")
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Location", "/scans/"+job.ID)
+	status := http.StatusAccepted
+	if existing {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, job)
+}
+
+func (s *Server) getScan(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.queue.Get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "trabajo no encontrado")
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// latestHost devuelve el último análisis del dominio con el mismo documento
+// que --format json
+func (s *Server) latestHost(w http.ResponseWriter, r *http.Request) {
+	domain := strings.ToLower(r.PathValue("domain"))
+
+	records, err := s.history.List(domain)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(records) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no hay análisis de %s", domain))
+		return
+	}
+
+	report := output.NewJSONReport()
+	report.AddHost(records[len(records)-1].Host)
+
+	w.Header().Set("Content-Type", "application/json")
+	report.Write(w)
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+
+	expected := []byte("Bearer " + s.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "token inválido o ausente")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
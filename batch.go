@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"sslscanner/output"
+	"sslscanner/service"
+)
+
+// runBatch analiza varios dominios y devuelve error si alguno falló
+func runBatch(ctx context.Context, scanner *service.Scanner, progress progressRenderer, formatter *output.Formatter, status io.Writer, config reportConfig, domains []string, opts service.ScanOptions, concurrency int) int {
+	fmt.Fprintf(status, "Iniciando análisis TLS por lotes para %d dominios\n", len(domains))
+	fmt.Fprintln(status, "Este proceso puede demorar...")
+	fmt.Fprintln(status)
+
+	completed := 0
+	policyFailures := 0
+	results, err := scanner.RunBatch(ctx, domains, opts, concurrency, func(result service.BatchResult) {
+		completed++
+		// el reporte se imprime por encima de los análisis que siguen en curso
+		progress.hold(func() {
+			if result.Err != nil {
+				fmt.Fprintf(os.Stderr, "Error en %s: %v\n", result.Domain, result.Err)
+			}
+			if config.format != formatText {
+				fmt.Fprintf(status, "[%d/%d] %s terminado\n", completed, len(domains), result.Domain)
+			} else {
+				formatter.PrintDomainHeader(result.Domain, completed, len(domains))
+				if result.Err == nil {
+					formatter.PrintReport(result.Host)
+				}
+			}
+			if result.Err == nil && !checkPolicy(formatter, config, result.Host) {
+				policyFailures++
+			}
+		})
+	})
+	progress.stop()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeAnalysisError
+	}
+
+	// los errores de análisis tienen prioridad sobre los incumplimientos de política
+	exitCode := exitCodeSuccess
+	if policyFailures > 0 {
+		exitCode = exitCodePolicyFailure
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			exitCode = exitCodeAnalysisError
+		}
+	}
+
+	if config.format != formatText {
+		if err := writeDocument(os.Stdout, config, results); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCodeAnalysisError
+		}
+		return exitCode
+	}
+
+	entries := make([]output.BatchEntry, len(results))
+	for i, result := range results {
+		entries[i] = output.BatchEntry{
+			Domain:   result.Domain,
+			Host:     result.Host,
+			Err:      result.Err,
+			Duration: result.Duration,
+		}
+	}
+
+	formatter.PrintBatchSummary(entries)
+	if config.rules != nil {
+		formatter.PrintPolicyFailures(policyFailures)
+	}
+
+	return exitCode
+}
+
+// collectDomains junta los dominios de los argumentos y del archivo (o stdin),
+// ignorando líneas vacías, comentarios (#) y duplicados
+func collectDomains(args []string, filePath string) ([]string, error) {
+	candidates := append([]string{}, args...)
+
+	if filePath != "" {
+		var reader io.Reader = os.Stdin
+		if filePath != "-" {
+			file, err := os.Open(filePath)
+			if err != nil {
+				return nil, fmt.Errorf("no se pudo abrir el archivo de dominios: %w", err)
+			}
+			defer file.Close()
+			reader = file
+		}
+
+		lines := bufio.NewScanner(reader)
+		for lines.Scan() {
+			candidates = append(candidates, lines.Text())
+		}
+		if err := lines.Err(); err != nil {
+			return nil, fmt.Errorf("falló al leer la lista de dominios: %w", err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	domains := []string{}
+	for _, candidate := range candidates {
+		domain := strings.TrimSpace(candidate)
+		if domain == "" || strings.HasPrefix(domain, "#") || seen[domain] {
+			continue
+		}
+		seen[domain] = true
+		domains = append(domains, domain)
+	}
+
+	return domains, nil
+}
@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"sslscanner/internal/fsutil"
+	"sslscanner/model"
+)
+
+const (
+	DefaultTTL = 24 * time.Hour
+
+	// dirName es el subdirectorio dentro del directorio de caché del usuario
+	dirName = "sslscanner"
+)
+
+var (
+	// ErrNotFound indica que no hay entrada en caché para la clave pedida
+	ErrNotFound = errors.New("no hay resultado en caché")
+	// ErrExpired indica que la entrada existe pero superó el TTL
+	ErrExpired = errors.New("el resultado en caché expiró")
+)
+
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9.\-]`)
+
+// Entry es lo que se guarda en disco por cada análisis completado
+type Entry struct {
+	Key             string      `json:"key"`
+	FetchedAt       time.Time   `json:"fetchedAt"`
+	EngineVersion   string      `json:"engineVersion"`
+	CriteriaVersion string      `json:"criteriaVersion"`
+	Host            *model.Host `json:"host"`
+}
+
+// Age devuelve el tiempo transcurrido desde que se obtuvo el resultado
+func (e *Entry) Age() time.Duration {
+	return time.Since(e.FetchedAt)
+}
+
+// Store guarda los resultados terminados (READY) en un directorio, un archivo por clave
+type Store struct {
+	dir string
+	ttl time.Duration
+}
+
+// DefaultDir devuelve el directorio de caché del usuario ($XDG_CACHE_HOME/sslscanner en Linux)
+func DefaultDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("no se pudo determinar el directorio de caché: %w", err)
+	}
+	return filepath.Join(base, dirName), nil
+}
+
+func New(dir string, ttl time.Duration) *Store {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	return &Store{
+		dir: dir,
+		ttl: ttl,
+	}
+}
+
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Key arma la clave de caché a partir del host y las opciones que afectan el resultado
+func Key(host string, options ...string) string {
+	key := strings.ToLower(host)
+	if len(options) > 0 {
+		key += "?" + strings.Join(options, "&")
+	}
+	return key
+}
+
+// Get devuelve la entrada si existe y sigue fresca según el TTL.
+// Si expiró devuelve la entrada junto con ErrExpired.
+func (s *Store) Get(key string) (*Entry, error) {
+	entry, err := s.Load(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Age() > s.ttl {
+		return entry, ErrExpired
+	}
+
+	return entry, nil
+}
+
+// Load devuelve la entrada guardada sin importar su antigüedad
+func (s *Store) Load(key string) (*Entry, error) {
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("falló al leer caché desde archivo: %w", err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("falló al decodificar caché: %w", err)
+	}
+
+	// protege contra colisiones de nombres de archivo
+	if entry.Key != key || entry.Host == nil {
+		return nil, ErrNotFound
+	}
+
+	return &entry, nil
+}
+
+// Put guarda un resultado terminado. Los análisis en curso o con error no se guardan.
+func (s *Store) Put(key string, host *model.Host) error {
+	if host == nil || host.Status != "READY" {
+		return fmt.Errorf("solo se guardan en caché análisis terminados (estado: %s)", hostStatus(host))
+	}
+
+	entry := Entry{
+		Key:             key,
+		FetchedAt:       time.Now().UTC(),
+		EngineVersion:   host.EngineVersion,
+		CriteriaVersion: host.CriteriaVersion,
+		Host:            host,
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("falló al codificar caché: %w", err)
+	}
+
+	if err := fsutil.WriteFileAtomic(s.path(key), data, 0o644); err != nil {
+		return fmt.Errorf("falló al escribir caché en archivo: %w", err)
+	}
+
+	return nil
+}
+
+// path arma un nombre de archivo legible y seguro; las opciones se resumen con un hash
+func (s *Store) path(key string) string {
+	host, options, _ := strings.Cut(key, "?")
+	name := unsafeChars.ReplaceAllString(host, "_")
+	if options != "" {
+		sum := sha256.Sum256([]byte(options))
+		name += "-" + hex.EncodeToString(sum[:4])
+	}
+	return filepath.Join(s.dir, name+".json")
+}
+
+func hostStatus(host *model.Host) string {
+	if host == nil {
+		return "sin resultado"
+	}
+	return host.Status
+}
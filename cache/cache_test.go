@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"sslscanner/model"
+)
+
+func readyHost() *model.Host {
+	return &model.Host{
+		Host:            "example.com",
+		Status:          "READY",
+		EngineVersion:   "2.3.0",
+		CriteriaVersion: "2009q",
+		Endpoints:       []model.Endpoint{{IPAddress: "192.0.2.1", Grade: "A"}},
+	}
+}
+
+func TestPutGet(t *testing.T) {
+	store := New(t.TempDir(), time.Hour)
+
+	if err := store.Put("example.com", readyHost()); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	entry, err := store.Get("example.com")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if entry.Host.Endpoints[0].Grade != "A" || entry.EngineVersion != "2.3.0" || entry.CriteriaVersion != "2009q" {
+		t.Errorf("entrada = %+v", entry)
+	}
+	if age := entry.Age(); age < 0 || age > time.Minute {
+		t.Errorf("Age() = %v", age)
+	}
+}
+
+func TestGetNotFound(t *testing.T) {
+	store := New(t.TempDir(), time.Hour)
+
+	if _, err := store.Get("example.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, se esperaba ErrNotFound", err)
+	}
+}
+
+func TestGetExpired(t *testing.T) {
+	store := New(t.TempDir(), time.Hour)
+
+	entry := Entry{Key: "example.com", FetchedAt: time.Now().Add(-2 * time.Hour), Host: readyHost()}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(store.path("example.com"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	expired, err := store.Get("example.com")
+	if !errors.Is(err, ErrExpired) {
+		t.Fatalf("err = %v, se esperaba ErrExpired", err)
+	}
+	if expired == nil || expired.Host == nil {
+		t.Error("una entrada vencida también se devuelve")
+	}
+
+	// Load no aplica el TTL
+	if _, err := store.Load("example.com"); err != nil {
+		t.Errorf("Load: %v", err)
+	}
+}
+
+func TestPutOnlyReady(t *testing.T) {
+	store := New(t.TempDir(), time.Hour)
+
+	inProgress := readyHost()
+	inProgress.Status = "IN_PROGRESS"
+	failed := readyHost()
+	failed.Status = "ERROR"
+
+	for _, host := range []*model.Host{nil, inProgress, failed} {
+		if err := store.Put("example.com", host); err == nil {
+			t.Errorf("Put aceptó un análisis sin terminar: %+v", host)
+		}
+	}
+	if _, err := store.Load("example.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("no debería haberse escrito nada: %v", err)
+	}
+}
+
+func TestKey(t *testing.T) {
+	if key := Key("Example.COM"); key != "example.com" {
+		t.Errorf("Key = %q", key)
+	}
+	if key := Key("example.com", "ignoreMismatch=on"); key != "example.com?ignoreMismatch=on" {
+		t.Errorf("Key con opciones = %q", key)
+	}
+}
+
+func TestKeyOptionsAreStoredSeparately(t *testing.T) {
+	store := New(t.TempDir(), time.Hour)
+	plain := Key("example.com")
+	mismatch := Key("example.com", "ignoreMismatch=on")
+
+	if store.path(plain) == store.path(mismatch) {
+		t.Fatal("las opciones deberían cambiar el archivo de la entrada")
+	}
+
+	if err := store.Put(plain, readyHost()); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := store.Get(mismatch); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, un resultado sin ignoreMismatch no sirve para la clave con ignoreMismatch", err)
+	}
+}
+
+func TestLoadFileNameCollision(t *testing.T) {
+	store := New(t.TempDir(), time.Hour)
+
+	// ambos nombres se sanean al mismo archivo
+	if store.path("a:b") != store.path("a_b") {
+		t.Fatal("se esperaba que las claves compartieran archivo")
+	}
+	if err := store.Put("a:b", readyHost()); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := store.Get("a_b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, la entrada de otra clave no debería devolverse", err)
+	}
+}
+
+func TestNewDefaultTTL(t *testing.T) {
+	if store := New(t.TempDir(), 0); store.ttl != DefaultTTL {
+		t.Errorf("ttl = %v, se esperaba DefaultTTL", store.ttl)
+	}
+}
@@ -0,0 +1,262 @@
+// Package cassette graba el tráfico HTTP con la API de SSL Labs en un archivo
+// y lo reproduce después sin red, para adjuntar a un reporte de error la
+// secuencia exacta de respuestas que vio un análisis.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"sslscanner/internal/fsutil"
+)
+
+// FormatVersion es la versión del formato del archivo
+const FormatVersion = 1
+
+// ErrExhausted se devuelve al reproducir una solicitud que no está grabada
+// o cuyas respuestas grabadas ya se consumieron
+var ErrExhausted = errors.New("no hay más respuestas grabadas para la solicitud")
+
+// sensitiveHeaders no se guardan en el archivo: identifican al usuario
+var sensitiveHeaders = []string{"Email", "Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Cassette es la secuencia de solicitudes y respuestas de una ejecución
+type Cassette struct {
+	Version      int           `json:"version"`
+	RecordedAt   time.Time     `json:"recordedAt"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+	// Elapsed es el tiempo desde el inicio de la grabación
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Load lee un archivo grabado con Recorder
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("falló al leer la grabación: %w", err)
+	}
+
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("grabación inválida %s: %w", path, err)
+	}
+	if c.Version != FormatVersion {
+		return nil, fmt.Errorf("versión de grabación no soportada: %d", c.Version)
+	}
+
+	return &c, nil
+}
+
+// Save escribe el archivo de forma atómica
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("falló al codificar la grabación: %w", err)
+	}
+
+	if err := fsutil.WriteFileAtomic(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("falló al escribir la grabación: %w", err)
+	}
+	return nil
+}
+
+// Recorder es un http.RoundTripper que guarda cada solicitud y su respuesta.
+// El archivo se reescribe tras cada interacción, así queda completo aunque el
+// programa termine con error o se interrumpa.
+type Recorder struct {
+	transport http.RoundTripper
+	path      string
+	started   time.Time
+	redact    []string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder graba en path las solicitudes que pasan por transport
+// (http.DefaultTransport si es nil). redact son cabeceras de la solicitud que
+// tampoco se guardan, como las que agrega el usuario con --header.
+func NewRecorder(path string, transport http.RoundTripper, redact ...string) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	now := time.Now()
+	return &Recorder{
+		transport: transport,
+		path:      path,
+		started:   now,
+		redact:    redact,
+		cassette:  Cassette{Version: FormatVersion, RecordedAt: now.UTC(), Interactions: []Interaction{}},
+	}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("falló al grabar la solicitud: %w", err)
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("falló al grabar la respuesta: %w", err)
+	}
+
+	interaction := Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: sanitize(req.Header, r.redact...),
+			Body:    string(requestBody),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    sanitize(resp.Header),
+			Body:       string(responseBody),
+		},
+		Elapsed: time.Since(r.started),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if err := r.cassette.Save(r.path); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Replayer es un http.RoundTripper que responde con las interacciones grabadas.
+// Las solicitudes se emparejan por método, ruta y query, sin importar el host,
+// y las respuestas a una misma solicitud se entregan en el orden grabado.
+type Replayer struct {
+	mu      sync.Mutex
+	pending map[string][]Response
+}
+
+func NewReplayer(c *Cassette) *Replayer {
+	pending := make(map[string][]Response)
+	for _, interaction := range c.Interactions {
+		key, err := requestKey(interaction.Request.Method, interaction.Request.URL)
+		if err != nil {
+			continue
+		}
+		pending[key] = append(pending[key], interaction.Response)
+	}
+
+	return &Replayer{pending: pending}
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	key, err := requestKey(req.Method, req.URL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	responses := r.pending[key]
+	if len(responses) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%s: %w", key, ErrExhausted)
+	}
+
+	recorded := responses[0]
+	// la última respuesta se repite: el polling puede hacer una consulta más que al grabar
+	if len(responses) > 1 {
+		r.pending[key] = responses[1:]
+	}
+	r.mu.Unlock()
+
+	header := recorded.Headers.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(recorded.Body))),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// readBody lee el cuerpo y lo reemplaza por una copia para que siga disponible
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+func sanitize(header http.Header, redact ...string) http.Header {
+	clean := header.Clone()
+	for _, name := range sensitiveHeaders {
+		clean.Del(name)
+	}
+	for _, name := range redact {
+		clean.Del(name)
+	}
+	if len(clean) == 0 {
+		return nil
+	}
+	return clean
+}
+
+// requestKey identifica una solicitud por método, ruta y query normalizada
+func requestKey(method, rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("URL grabada inválida %s: %w", rawURL, err)
+	}
+	return method + " " + parsed.Path + "?" + parsed.Query().Encode(), nil
+}
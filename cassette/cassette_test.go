@@ -0,0 +1,126 @@
+package cassette
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, client *http.Client, url string, header http.Header) (int, string, error) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header = header
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+func TestRecordReplay(t *testing.T) {
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/info":
+			w.Header().Set("Set-Cookie", "session=secreta")
+			w.Header().Set("X-Max-Assessments", "25")
+			io.WriteString(w, `{"engineVersion":"2.3.0"}`)
+		case "/api/v3/analyze":
+			polls++
+			if polls == 1 {
+				io.WriteString(w, `{"status":"IN_PROGRESS"}`)
+				return
+			}
+			io.WriteString(w, `{"status":"READY"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	// Save crea el directorio si no existe
+	path := filepath.Join(t.TempDir(), "grabaciones", "run.json")
+	header := http.Header{
+		"Email":               {"user@example.com"},
+		"Proxy-Authorization": {"Basic c2VjcmV0YQ=="},
+		"X-Api-Key":           {"clave-secreta"},
+		"User-Agent":          {"sslscanner/test"},
+	}
+
+	recording := &http.Client{Transport: NewRecorder(path, nil, "X-Api-Key")}
+	for _, url := range []string{"/api/v3/info", "/api/v3/analyze?host=example.com&all=done", "/api/v3/analyze?all=done&host=example.com"} {
+		if _, _, err := get(t, recording, server.URL+url, header.Clone()); err != nil {
+			t.Fatalf("GET %s: %v", url, err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("falta la grabación: %v", err)
+	}
+	for _, secret := range []string{"user@example.com", "c2VjcmV0YQ==", "clave-secreta", "session=secreta"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("la grabación contiene %q", secret)
+		}
+	}
+
+	recorded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(recorded.Interactions) != 3 {
+		t.Fatalf("interacciones = %d, se esperaban 3", len(recorded.Interactions))
+	}
+	if got := recorded.Interactions[0].Request.Headers.Get("User-Agent"); got != "sslscanner/test" {
+		t.Errorf("User-Agent grabado = %q", got)
+	}
+
+	// la reproducción ignora el host y el orden de la query
+	replaying := &http.Client{Transport: NewReplayer(recorded)}
+	for _, tc := range []struct {
+		url  string
+		want string
+	}{
+		{"/api/v3/analyze?host=example.com&all=done", `{"status":"IN_PROGRESS"}`},
+		{"/api/v3/analyze?all=done&host=example.com", `{"status":"READY"}`},
+		// la última respuesta se repite
+		{"/api/v3/analyze?host=example.com&all=done", `{"status":"READY"}`},
+		{"/api/v3/info", `{"engineVersion":"2.3.0"}`},
+	} {
+		status, body, err := get(t, replaying, "http://otro.invalid"+tc.url, nil)
+		if err != nil || status != http.StatusOK || body != tc.want {
+			t.Errorf("GET %s = %d %s, %v; se esperaba %s", tc.url, status, body, err, tc.want)
+		}
+	}
+
+	if _, _, err := get(t, replaying, "http://otro.invalid/api/v3/getEndpointData", nil); !errors.Is(err, ErrExhausted) {
+		t.Errorf("err = %v, se esperaba ErrExhausted", err)
+	}
+}
+
+func TestLoadVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	if err := os.WriteFile(path, []byte(`{"version":99,"interactions":[]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path); err == nil {
+		t.Error("Load aceptó una versión desconocida")
+	}
+}
@@ -1,13 +1,15 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"os"
+	"strconv"
 	"time"
 
 	"sslscanner/model"
@@ -18,27 +20,114 @@ const (
 	DefaultTimeout = 30 * time.Second
 )
 
+// Valores del parámetro all de /analyze
+const (
+	AllOn   = "on"
+	AllDone = "done"
+)
+
+// AnalyzeOptions agrupa los parámetros opcionales de /analyze
+type AnalyzeOptions struct {
+	// Publish publica el resultado en los tableros públicos de SSL Labs
+	Publish bool
+	// StartNew fuerza un análisis nuevo; no se puede combinar con FromCache
+	StartNew bool
+	// FromCache entrega un reporte en caché de SSL Labs si existe
+	FromCache bool
+	// MaxAge es la antigüedad máxima en horas del reporte en caché (0 = cualquiera)
+	MaxAge int
+	// All controla cuánto detalle de los endpoints se incluye (on, done o vacío)
+	All string
+	// IgnoreMismatch continúa el análisis aunque el certificado no coincida con el host
+	IgnoreMismatch bool
+}
+
+// Validate verifica que las opciones sean compatibles entre sí
+func (o AnalyzeOptions) Validate() error {
+	if o.StartNew && o.FromCache {
+		return fmt.Errorf("startNew y fromCache no se pueden usar juntos")
+	}
+	if o.MaxAge < 0 {
+		return fmt.Errorf("maxAge no puede ser negativo")
+	}
+	if o.MaxAge > 0 && !o.FromCache {
+		return fmt.Errorf("maxAge solo se puede usar con fromCache")
+	}
+	if o.All != "" && o.All != AllOn && o.All != AllDone {
+		return fmt.Errorf("valor inválido para all: %s", o.All)
+	}
+	return nil
+}
+
+// params arma la query de /analyze. Al consultar el estado (start=false) no se
+// envía startNew, porque reiniciaría el análisis en cada consulta.
+func (o AnalyzeOptions) params(domain string, start bool) url.Values {
+	params := url.Values{}
+	params.Set("host", domain)
+
+	if o.Publish {
+		params.Set("publish", "on")
+	}
+	if o.StartNew && start {
+		params.Set("startNew", "on")
+	}
+	if o.FromCache {
+		params.Set("fromCache", "on")
+		if o.MaxAge > 0 {
+			params.Set("maxAge", strconv.Itoa(o.MaxAge))
+		}
+	}
+	if o.All != "" {
+		params.Set("all", o.All)
+	}
+	if o.IgnoreMismatch {
+		params.Set("ignoreMismatch", "on")
+	}
+
+	return params
+}
+
 type Client struct {
 	httpClient *http.Client
 	baseURL    string
+	// customBaseURL indica que baseURL vino de WithBaseURL y no de la versión
+	customBaseURL bool
+	userAgent     string
+	headers       http.Header
+	retry         RetryPolicy
+	governor      *governor
+	version       APIVersion
+	email         string
 }
 
+// NewClient crea un cliente con la configuración por defecto. Para configurar
+// URL base, transport, proxy o cabeceras use New.
 func NewClient() *Client {
-	return &Client{
-		httpClient: &http.Client{
-			Timeout: DefaultTimeout,
-		},
-		baseURL: BaseURL,
-	}
+	c, _ := New()
+	return c
 }
 
 func NewClientWithTimeout(timeout time.Duration) *Client {
-	return &Client{
-		httpClient: &http.Client{
-			Timeout: timeout,
-		},
-		baseURL: BaseURL,
-	}
+	c, _ := New(WithHTTPClient(&http.Client{Timeout: timeout}))
+	return c
+}
+
+// SetRetryPolicy reemplaza la política de reintentos ante 429, 503 y 529
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy
+}
+
+// RetryPolicy devuelve la política de reintentos en uso
+func (c *Client) RetryPolicy() RetryPolicy {
+	return c.retry
+}
+
+// WithRetry devuelve una copia del cliente con otra política de reintentos. La
+// copia comparte el http.Client y el control de cupos con el original.
+func (c *Client) WithRetry(policy RetryPolicy) *Client {
+	clone := *c
+	clone.retry = policy
+	return &clone
 }
 
 func (c *Client) GetInfo(ctx context.Context) (*model.Info, error) {
@@ -54,17 +143,25 @@ func (c *Client) GetInfo(ctx context.Context) (*model.Info, error) {
 		return nil, fmt.Errorf("falló al decodificar respuesta de info: %w", err)
 	}
 
+	c.governor.setCoolOff(time.Duration(info.NewAssessmentCoolOff) * time.Millisecond)
+
 	return &info, nil
 }
 
-// StartAnalysis inicia un nuevo análisis con startNew=on
-func (c *Client) StartAnalysis(ctx context.Context, domain string) (*model.Host, error) {
-	params := url.Values{}
-	params.Set("host", domain)
-	params.Set("startNew", "on")
-	params.Set("all", "done")
+// StartAnalysis inicia un análisis (nuevo o desde la caché de SSL Labs según opts).
+// Bloquea mientras no haya cupo libre o no haya pasado el cool-off desde el último
+// análisis iniciado por cualquier goroutine que use este Client.
+func (c *Client) StartAnalysis(ctx context.Context, domain string, opts AnalyzeOptions) (*model.Host, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("opciones de análisis inválidas: %w", err)
+	}
 
-	endpoint := fmt.Sprintf("%s/analyze?%s", c.baseURL, params.Encode())
+	if err := c.acquireAssessment(ctx); err != nil {
+		return nil, fmt.Errorf("cancelado mientras se esperaba un cupo de análisis: %w", err)
+	}
+	defer c.governor.release()
+
+	endpoint := fmt.Sprintf("%s/analyze?%s", c.baseURL, opts.params(domain, true).Encode())
 
 	body, err := c.doRequest(ctx, endpoint)
 	if err != nil {
@@ -75,17 +172,14 @@ func (c *Client) StartAnalysis(ctx context.Context, domain string) (*model.Host,
 	if err := json.Unmarshal(body, &host); err != nil {
 		return nil, fmt.Errorf("falló al decodificar respuesta de análisis: %w", err)
 	}
+	NormalizeHost(&host)
 
 	return &host, nil
 }
 
 // CheckAnalysisStatus consulta el estado sin iniciar uno nuevo
-func (c *Client) CheckAnalysisStatus(ctx context.Context, domain string) (*model.Host, error) {
-	params := url.Values{}
-	params.Set("host", domain)
-	params.Set("all", "done")
-
-	endpoint := fmt.Sprintf("%s/analyze?%s", c.baseURL, params.Encode())
+func (c *Client) CheckAnalysisStatus(ctx context.Context, domain string, opts AnalyzeOptions) (*model.Host, error) {
+	endpoint := fmt.Sprintf("%s/analyze?%s", c.baseURL, opts.params(domain, false).Encode())
 
 	body, err := c.doRequest(ctx, endpoint)
 	if err != nil {
@@ -96,69 +190,14 @@ func (c *Client) CheckAnalysisStatus(ctx context.Context, domain string) (*model
 	if err := json.Unmarshal(body, &host); err != nil {
 		return nil, fmt.Errorf("falló al decodificar respuesta de estado: %w", err)
 	}
+	NormalizeHost(&host)
 
 	return &host, nil
 }
 
-// función para añadir a la caché (carpeta cache) los resultados de manera local recibe el host resultado y la ruta del archivo donde se guardará
-// con base a un dominio específico
-func SaveToLocalCache(filePath string, host *model.Host) error {
-	data, err := json.MarshalIndent(host, "", "  ")
-	if err != nil {
-		return fmt.Errorf("falló al codificar caché: %w", err)
-	}
-
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("falló al escribir caché en archivo: %w", err)
-	}
-
-	return nil
-}
-
-func CheckDomainInCache(filePath string, domain string) (bool, error) {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return false, nil // El archivo no existe, por lo que no está en caché
-		}
-		return false, fmt.Errorf("falló al leer caché desde archivo: %w", err)
-	}
-
-	var host model.Host
-	if err := json.Unmarshal(data, &host); err != nil {
-		return false, fmt.Errorf("falló al decodificar caché: %w", err)
-	}
-
-	// Verificar que el dominio coincida
-	if host.Host == domain {
-		return true, nil
-	}
-
-	return false, nil
-}
-
-// función para cargar la caché de los resultados desde un archivo local dependiendo del dominio específico
-// parámetros: ruta del archivo donde se encuentra la caché
-// retorna: host resultado y error en caso de que ocurra algún problema
-func LoadLocalCache(filePath string, domain string) (*model.Host, error) {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("falló al leer caché desde archivo: %w", err)
-	}
-
-	var host model.Host
-	if err := json.Unmarshal(data, &host); err != nil {
-		return nil, fmt.Errorf("falló al decodificar caché: %w", err)
-	}
-
-	// Verificar que el dominio coincida
-	if host.Host != domain {
-		return nil, fmt.Errorf("la caché no corresponde al dominio solicitado")
-	}
-
-	return &host, nil
-}
-
+// GetEndpointDetails obtiene los detalles de un endpoint. En las API v3 y v4 los
+// certificados están a nivel de host, por lo que Details.Cert llega vacío; para
+// obtenerlos use StartAnalysis o CheckAnalysisStatus con all=done.
 func (c *Client) GetEndpointDetails(ctx context.Context, domain, ipAddress string) (*model.Endpoint, error) {
 	params := url.Values{}
 	params.Set("host", domain)
@@ -180,12 +219,57 @@ func (c *Client) GetEndpointDetails(ctx context.Context, domain, ipAddress strin
 }
 
 func (c *Client) doRequest(ctx context.Context, endpoint string) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	return c.doRequestWithBody(ctx, http.MethodGet, endpoint, nil)
+}
+
+// doRequestWithBody ejecuta la solicitud y reintenta con backoff los errores que
+// SSL Labs considera transitorios (429, 503 y 529), respetando la cancelación de ctx
+func (c *Client) doRequestWithBody(ctx context.Context, method, endpoint string, payload []byte) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		body, err := c.doRequestOnce(ctx, method, endpoint, payload)
+		if err == nil {
+			return body, nil
+		}
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || !apiErr.Retryable() || attempt >= c.retry.MaxRetries {
+			return nil, err
+		}
+
+		delay := c.retry.backoff(attempt+1, apiErr.RetryAfter)
+		if c.retry.OnRetry != nil {
+			c.retry.OnRetry(attempt+1, delay, err)
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, fmt.Errorf("reintento cancelado: %w", err)
+		}
+	}
+}
+
+func (c *Client) doRequestOnce(ctx context.Context, method, endpoint string, payload []byte) ([]byte, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("falló al crear solicitud HTTP: %w", err)
 	}
 
-	req.Header.Set("User-Agent", "sslscanner/1.0")
+	for name, values := range c.headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.email != "" {
+		req.Header.Set("email", c.email)
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -193,37 +277,38 @@ func (c *Client) doRequest(ctx context.Context, endpoint string) ([]byte, error)
 	}
 	defer resp.Body.Close()
 
+	c.governor.observe(resp.Header)
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("falló al leer cuerpo de respuesta: %w", err)
 	}
 
-	if err := c.checkHTTPStatus(resp.StatusCode, body); err != nil {
+	if err := c.checkHTTPStatus(resp, body); err != nil {
 		return nil, err
 	}
 
 	return body, nil
 }
 
-func (c *Client) checkHTTPStatus(statusCode int, body []byte) error {
-	switch statusCode {
-	case http.StatusOK:
+// checkHTTPStatus convierte las respuestas de error en un *APIError tipado
+func (c *Client) checkHTTPStatus(resp *http.Response, body []byte) error {
+	if resp.StatusCode == http.StatusOK {
 		return nil
-	case http.StatusBadRequest:
-		var apiErr model.APIError
-		if err := json.Unmarshal(body, &apiErr); err == nil && len(apiErr.Errors) > 0 {
-			return fmt.Errorf("error de invocación (400): %s - %s", apiErr.Errors[0].Field, apiErr.Errors[0].Message)
+	}
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Class:      classifyStatus(resp.StatusCode),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	if resp.StatusCode == http.StatusBadRequest {
+		var details model.APIError
+		if err := json.Unmarshal(body, &details); err == nil {
+			apiErr.Details = details.Errors
 		}
-		return fmt.Errorf("error de invocación (400): parámetros inválidos")
-	case http.StatusTooManyRequests:
-		return fmt.Errorf("límite de tasa excedido (429): demasiadas solicitudes, espere antes de reintentar")
-	case http.StatusInternalServerError:
-		return fmt.Errorf("error interno del servidor (500): problema en SSL Labs")
-	case http.StatusServiceUnavailable:
-		return fmt.Errorf("servicio no disponible (503): SSL Labs en mantenimiento")
-	case 529:
-		return fmt.Errorf("servicio sobrecargado (529): SSL Labs está sobrecargado, intente más tarde")
-	default:
-		return fmt.Errorf("código de estado HTTP inesperado: %d", statusCode)
 	}
+
+	return apiErr
 }
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sslscanner/model"
+)
+
+// StatusOverloaded es el código que usa SSL Labs cuando está sobrecargado
+const StatusOverloaded = 529
+
+// Clases de error de la API. Se comparan con errors.Is sobre el error devuelto por el cliente.
+var (
+	ErrRateLimited  = errors.New("límite de tasa excedido")
+	ErrOverloaded   = errors.New("servicio sobrecargado")
+	ErrMaintenance  = errors.New("servicio en mantenimiento")
+	ErrInvalidInput = errors.New("parámetros inválidos")
+	ErrServerError  = errors.New("error del servidor")
+)
+
+// APIError describe una respuesta de error de SSL Labs. Se obtiene con errors.As.
+type APIError struct {
+	StatusCode int
+	// Class es uno de los errores centinela (ErrRateLimited, ErrOverloaded, ...)
+	Class error
+	// Details trae los errores de validación de una respuesta 400
+	Details []model.ErrorDetail
+	// RetryAfter es la espera sugerida por el servidor (cabecera Retry-After), si la envió
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		if len(e.Details) > 0 {
+			return fmt.Sprintf("error de invocación (400): %s - %s", e.Details[0].Field, e.Details[0].Message)
+		}
+		return "error de invocación (400): parámetros inválidos"
+	case http.StatusTooManyRequests:
+		return "límite de tasa excedido (429): demasiadas solicitudes, espere antes de reintentar"
+	case http.StatusInternalServerError:
+		return "error interno del servidor (500): problema en SSL Labs"
+	case http.StatusServiceUnavailable:
+		return "servicio no disponible (503): SSL Labs en mantenimiento"
+	case StatusOverloaded:
+		return "servicio sobrecargado (529): SSL Labs está sobrecargado, intente más tarde"
+	default:
+		return fmt.Sprintf("código de estado HTTP inesperado: %d", e.StatusCode)
+	}
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Class
+}
+
+// Retryable indica si SSL Labs espera que el cliente vuelva a intentar más tarde
+func (e *APIError) Retryable() bool {
+	return IsRetryable(e)
+}
+
+// IsRetryable indica si err pertenece a una clase que se puede reintentar (429, 503, 529)
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrOverloaded) || errors.Is(err, ErrMaintenance)
+}
+
+// classifyStatus asigna la clase de error según el código HTTP
+func classifyStatus(statusCode int) error {
+	switch {
+	case statusCode == http.StatusBadRequest:
+		return ErrInvalidInput
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode == http.StatusServiceUnavailable:
+		return ErrMaintenance
+	case statusCode == StatusOverloaded:
+		return ErrOverloaded
+	default:
+		return ErrServerError
+	}
+}
+
+// parseRetryAfter interpreta la cabecera Retry-After en segundos o como fecha HTTP
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
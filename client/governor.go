@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// governorRecheck es cada cuánto se consulta /info mientras se espera un cupo,
+// por si los cupos los ocupan otros procesos que no vemos en nuestras respuestas
+const governorRecheck = 30 * time.Second
+
+// governor coordina los análisis nuevos de todas las goroutines que comparten
+// un Client: respeta el cupo que informan las cabeceras X-Current-Assessments y
+// X-Max-Assessments y el período newAssessmentCoolOff entre análisis nuevos
+type governor struct {
+	mu        sync.Mutex
+	changed   chan struct{}
+	current   int
+	max       int
+	inFlight  int
+	coolOff   time.Duration
+	lastStart time.Time
+}
+
+func newGovernor() *governor {
+	return &governor{
+		changed: make(chan struct{}),
+	}
+}
+
+// observe actualiza el cupo con las cabeceras de cualquier respuesta de SSL Labs
+func (g *governor) observe(header http.Header) {
+	current, hasCurrent := headerInt(header, "X-Current-Assessments")
+	max, hasMax := headerInt(header, "X-Max-Assessments")
+	if !hasMax {
+		// nombre usado por versiones anteriores de la API
+		max, hasMax = headerInt(header, "X-ClientMaxAssessments")
+	}
+
+	if !hasCurrent && !hasMax {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if hasCurrent {
+		g.current = current
+	}
+	if hasMax {
+		g.max = max
+	}
+	g.notifyLocked()
+}
+
+// setCoolOff registra el período mínimo entre análisis nuevos publicado en /info
+func (g *governor) setCoolOff(coolOff time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.coolOff = coolOff
+	g.notifyLocked()
+}
+
+// tryAcquire reserva un cupo si hay uno libre y ya pasó el cool-off.
+// Si no, devuelve cuánto esperar (0 si la espera depende de que se libere un cupo).
+func (g *governor) tryAcquire() (bool, time.Duration, <-chan struct{}) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.max > 0 && g.current+g.inFlight >= g.max {
+		return false, 0, g.changed
+	}
+
+	if wait := g.coolOff - time.Since(g.lastStart); !g.lastStart.IsZero() && wait > 0 {
+		return false, wait, g.changed
+	}
+
+	g.inFlight++
+	g.lastStart = time.Now()
+	return true, 0, nil
+}
+
+// release libera la reserva; la respuesta ya actualizó current con las cabeceras
+func (g *governor) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.inFlight--
+	g.notifyLocked()
+}
+
+func (g *governor) notifyLocked() {
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
+
+// acquireAssessment bloquea hasta que se pueda iniciar un análisis nuevo
+func (c *Client) acquireAssessment(ctx context.Context) error {
+	for {
+		ok, wait, changed := c.governor.tryAcquire()
+		if ok {
+			return nil
+		}
+
+		blockedBySlots := wait == 0
+		if blockedBySlots {
+			wait = governorRecheck
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-changed:
+			timer.Stop()
+		case <-timer.C:
+			if blockedBySlots {
+				// actualiza el cupo consultando /info; un error aquí no es fatal
+				_, _ = c.GetInfo(ctx)
+			}
+		}
+	}
+}
+
+func headerInt(header http.Header, name string) (int, bool) {
+	value := header.Get(name)
+	if value == "" {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return n, true
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func slotHeaders(current, max string) http.Header {
+	header := http.Header{}
+	header.Set("X-Current-Assessments", current)
+	header.Set("X-Max-Assessments", max)
+	return header
+}
+
+func TestGovernorSlots(t *testing.T) {
+	g := newGovernor()
+	g.observe(slotHeaders("1", "2"))
+
+	if ok, _, _ := g.tryAcquire(); !ok {
+		t.Fatal("con 1 de 2 cupos ocupados debería poder iniciarse un análisis")
+	}
+
+	ok, wait, changed := g.tryAcquire()
+	if ok || wait != 0 {
+		t.Fatalf("tryAcquire = %t, %v; el cupo propio en curso cuenta como ocupado", ok, wait)
+	}
+
+	g.release()
+	select {
+	case <-changed:
+	default:
+		t.Fatal("release debería avisar a quien espera un cupo")
+	}
+
+	if ok, _, _ := g.tryAcquire(); !ok {
+		t.Error("después de release debería haber un cupo libre")
+	}
+}
+
+func TestGovernorObserve(t *testing.T) {
+	g := newGovernor()
+
+	g.observe(http.Header{})
+	g.observe(slotHeaders("x", ""))
+	if g.current != 0 || g.max != 0 {
+		t.Errorf("cabeceras ausentes o inválidas no deberían cambiar el cupo: %d/%d", g.current, g.max)
+	}
+
+	legacy := http.Header{}
+	legacy.Set("X-ClientMaxAssessments", "5")
+	g.observe(legacy)
+	if g.max != 5 {
+		t.Errorf("max = %d, se esperaba el de X-ClientMaxAssessments", g.max)
+	}
+
+	g.observe(slotHeaders("3", "10"))
+	if g.current != 3 || g.max != 10 {
+		t.Errorf("cupo = %d/%d, se esperaba 3/10", g.current, g.max)
+	}
+}
+
+func TestGovernorCoolOff(t *testing.T) {
+	g := newGovernor()
+	g.setCoolOff(time.Hour)
+
+	if ok, _, _ := g.tryAcquire(); !ok {
+		t.Fatal("el primer análisis no espera el cool-off")
+	}
+	g.release()
+
+	ok, wait, _ := g.tryAcquire()
+	if ok || wait <= 59*time.Minute || wait > time.Hour {
+		t.Errorf("tryAcquire = %t, %v; se esperaba esperar el cool-off", ok, wait)
+	}
+}
+
+func TestAcquireAssessmentWaitsForSlot(t *testing.T) {
+	c := &Client{governor: newGovernor()}
+	c.governor.observe(slotHeaders("0", "1"))
+
+	if err := c.acquireAssessment(context.Background()); err != nil {
+		t.Fatalf("acquireAssessment: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- c.acquireAssessment(context.Background()) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("acquireAssessment volvió sin cupo libre: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	c.governor.release()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("acquireAssessment: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("acquireAssessment no volvió al liberarse el cupo")
+	}
+}
+
+func TestAcquireAssessmentCanceled(t *testing.T) {
+	c := &Client{governor: newGovernor()}
+	c.governor.observe(slotHeaders("1", "1"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := c.acquireAssessment(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, se esperaba el error del contexto", err)
+	}
+}
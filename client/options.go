@@ -0,0 +1,303 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const DefaultUserAgent = "sslscanner/1.0"
+
+// Option configura un Client creado con New
+type Option func(*settings) error
+
+// settings reúne las opciones antes de armar el http.Client, para que el
+// resultado no dependa del orden en que se pasan
+type settings struct {
+	baseURL    string
+	httpClient *http.Client
+	transport  http.RoundTripper
+	wrappers   []func(http.RoundTripper) http.RoundTripper
+	proxy      *url.URL
+	rootCAs    *x509.CertPool
+	timeout    time.Duration
+	timeoutSet bool
+	userAgent  string
+	headers    http.Header
+	version    APIVersion
+	email      string
+	retry      RetryPolicy
+}
+
+// WithBaseURL apunta el cliente a un espejo o proxy interno de la API en lugar
+// de api.ssllabs.com. La URL debe incluir la ruta de la versión (p. ej. /api/v3).
+func WithBaseURL(baseURL string) Option {
+	return func(s *settings) error {
+		parsed, err := url.Parse(baseURL)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("URL base inválida: %s", baseURL)
+		}
+		if parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return fmt.Errorf("URL base inválida: esquema %s no soportado", parsed.Scheme)
+		}
+		s.baseURL = strings.TrimRight(baseURL, "/")
+		return nil
+	}
+}
+
+// WithHTTPClient usa httpClient para todas las solicitudes. El cliente recibido
+// no se modifica: WithTransport, WithProxy o WithTimeout se aplican sobre una copia.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(s *settings) error {
+		if httpClient == nil {
+			return fmt.Errorf("el http.Client no puede ser nil")
+		}
+		s.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithTransport reemplaza el RoundTripper del http.Client
+func WithTransport(transport http.RoundTripper) Option {
+	return func(s *settings) error {
+		if transport == nil {
+			return fmt.Errorf("el transport no puede ser nil")
+		}
+		s.transport = transport
+		return nil
+	}
+}
+
+// WithTransportWrapper envuelve el transport final, ya con proxy y CA
+// aplicados, p. ej. para grabar el tráfico. Puede repetirse; el último
+// wrapper es el más externo.
+func WithTransportWrapper(wrap func(http.RoundTripper) http.RoundTripper) Option {
+	return func(s *settings) error {
+		if wrap == nil {
+			return fmt.Errorf("el wrapper del transport no puede ser nil")
+		}
+		s.wrappers = append(s.wrappers, wrap)
+		return nil
+	}
+}
+
+// WithProxy envía las solicitudes por el proxy indicado. Sin esta opción se
+// respetan HTTPS_PROXY y NO_PROXY como en cualquier cliente de Go.
+func WithProxy(proxyURL string) Option {
+	return func(s *settings) error {
+		parsed, err := url.Parse(proxyURL)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("URL de proxy inválida: %s", proxyURL)
+		}
+		s.proxy = parsed
+		return nil
+	}
+}
+
+// WithRootCAs reemplaza las CA de confianza, p. ej. para un proxy corporativo
+// que intercepta TLS
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(s *settings) error {
+		if pool == nil {
+			return fmt.Errorf("el pool de CA no puede ser nil")
+		}
+		s.rootCAs = pool
+		return nil
+	}
+}
+
+// WithCACertFile agrega a las CA del sistema los certificados PEM del archivo
+func WithCACertFile(path string) Option {
+	return func(s *settings) error {
+		pool, err := LoadCACertPool(path)
+		if err != nil {
+			return err
+		}
+
+		s.rootCAs = pool
+		return nil
+	}
+}
+
+// LoadCACertPool devuelve las CA del sistema más los certificados PEM del archivo
+func LoadCACertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("falló al leer certificados CA: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no se encontraron certificados PEM en %s", path)
+	}
+
+	return pool, nil
+}
+
+// WithTimeout define el tiempo máximo de cada solicitud HTTP
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *settings) error {
+		if timeout <= 0 {
+			return fmt.Errorf("el timeout debe ser mayor que cero")
+		}
+		s.timeout = timeout
+		s.timeoutSet = true
+		return nil
+	}
+}
+
+// WithUserAgent reemplaza el User-Agent por defecto (sslscanner/1.0)
+func WithUserAgent(userAgent string) Option {
+	return func(s *settings) error {
+		if userAgent == "" {
+			return fmt.Errorf("el User-Agent no puede estar vacío")
+		}
+		s.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithHeader agrega una cabecera a todas las solicitudes, p. ej. la
+// autenticación de un proxy interno. Puede repetirse.
+func WithHeader(name, value string) Option {
+	return func(s *settings) error {
+		if name == "" {
+			return fmt.Errorf("el nombre de la cabecera no puede estar vacío")
+		}
+		s.headers.Add(name, value)
+		return nil
+	}
+}
+
+// WithAPIVersion selecciona la versión de la API, igual que SetAPIVersion
+func WithAPIVersion(version APIVersion, email string) Option {
+	return func(s *settings) error {
+		if err := validateAPIVersion(version, email); err != nil {
+			return err
+		}
+		s.version = version
+		s.email = email
+		return nil
+	}
+}
+
+// WithRetryPolicy reemplaza la política de reintentos, igual que SetRetryPolicy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(s *settings) error {
+		if policy.MaxRetries < 0 {
+			return fmt.Errorf("la cantidad de reintentos no puede ser negativa")
+		}
+		s.retry = policy
+		return nil
+	}
+}
+
+// ParseHeader interpreta una cabecera con el formato "Nombre: valor"
+func ParseHeader(header string) (name, value string, err error) {
+	name, value, ok := strings.Cut(header, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("cabecera inválida: %q (use \"Nombre: valor\")", header)
+	}
+	return name, strings.TrimSpace(value), nil
+}
+
+// New crea un cliente con las opciones indicadas. Sin opciones equivale a NewClient.
+func New(opts ...Option) (*Client, error) {
+	s := settings{
+		timeout:   DefaultTimeout,
+		userAgent: DefaultUserAgent,
+		headers:   http.Header{},
+		version:   APIv2,
+		retry:     DefaultRetryPolicy,
+	}
+
+	for _, opt := range opts {
+		if err := opt(&s); err != nil {
+			return nil, err
+		}
+	}
+
+	httpClient, err := s.buildHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := s.baseURL
+	if baseURL == "" {
+		baseURL = s.version.baseURL()
+	}
+
+	return &Client{
+		httpClient:    httpClient,
+		baseURL:       baseURL,
+		customBaseURL: s.baseURL != "",
+		userAgent:     s.userAgent,
+		headers:       s.headers,
+		retry:         s.retry,
+		governor:      newGovernor(),
+		version:       s.version,
+		email:         s.email,
+	}, nil
+}
+
+// buildHTTPClient arma el http.Client a partir del recibido con WithHTTPClient
+// (o uno nuevo) aplicando transport, proxy, CA y timeout
+func (s *settings) buildHTTPClient() (*http.Client, error) {
+	var httpClient http.Client
+	if s.httpClient != nil {
+		httpClient = *s.httpClient
+		if s.timeoutSet {
+			httpClient.Timeout = s.timeout
+		}
+	} else {
+		httpClient.Timeout = s.timeout
+	}
+
+	transport := s.transport
+	if transport == nil {
+		transport = httpClient.Transport
+	}
+
+	if s.proxy != nil || s.rootCAs != nil {
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+
+		base, ok := transport.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("el proxy y las CA solo se pueden configurar sobre un *http.Transport")
+		}
+
+		custom := base.Clone()
+		if s.proxy != nil {
+			custom.Proxy = http.ProxyURL(s.proxy)
+		}
+		if s.rootCAs != nil {
+			if custom.TLSClientConfig == nil {
+				custom.TLSClientConfig = &tls.Config{}
+			}
+			custom.TLSClientConfig.RootCAs = s.rootCAs
+		}
+		transport = custom
+	}
+
+	if len(s.wrappers) > 0 && transport == nil {
+		transport = http.DefaultTransport
+	}
+	for _, wrap := range s.wrappers {
+		transport = wrap(transport)
+	}
+
+	httpClient.Transport = transport
+
+	return &httpClient, nil
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy define cuántas veces y con qué espera se reintentan los errores
+// 429, 503 y 529. La espera crece exponencialmente con jitter hasta MaxDelay.
+type RetryPolicy struct {
+	// MaxRetries es la cantidad de reintentos después del primer intento (0 los desactiva)
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	// OnRetry (opcional) se invoca antes de cada espera, por ejemplo para informar al usuario
+	OnRetry func(attempt int, delay time.Duration, err error)
+}
+
+// DefaultRetryPolicy es la política usada por NewClient
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 5,
+	BaseDelay:  2 * time.Second,
+	MaxDelay:   2 * time.Minute,
+}
+
+// backoff calcula la espera antes del reintento attempt (desde 1): base*2^(attempt-1)
+// con jitter entre la mitad y el total, acotado por MaxDelay. Si el servidor
+// sugirió una espera con Retry-After se respeta, también acotada.
+func (p RetryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = DefaultRetryPolicy.MaxDelay
+	}
+
+	if retryAfter > 0 {
+		return min(retryAfter, maxDelay)
+	}
+
+	delay := p.BaseDelay
+	if delay <= 0 {
+		delay = DefaultRetryPolicy.BaseDelay
+	}
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxDelay)
+
+	half := delay / 2
+	return half + time.Duration(rand.Int64N(int64(half)+1))
+}
+
+// sleep espera d o hasta que se cancele el contexto
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
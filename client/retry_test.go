@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackoffGrowsWithJitter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for attempt := 1; attempt <= 6; attempt++ {
+		full := min(100*time.Millisecond<<(attempt-1), time.Second)
+		for range 50 {
+			if delay := policy.backoff(attempt, 0); delay < full/2 || delay > full {
+				t.Fatalf("backoff(%d) = %v, se esperaba entre %v y %v", attempt, delay, full/2, full)
+			}
+		}
+	}
+}
+
+func TestBackoffRetryAfter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	if delay := policy.backoff(1, 500*time.Millisecond); delay != 500*time.Millisecond {
+		t.Errorf("con Retry-After de 500ms la espera fue %v", delay)
+	}
+	if delay := policy.backoff(1, time.Minute); delay != time.Second {
+		t.Errorf("Retry-After debería acotarse a MaxDelay, la espera fue %v", delay)
+	}
+}
+
+func TestBackoffDefaults(t *testing.T) {
+	delay := RetryPolicy{}.backoff(1, 0)
+	if base := DefaultRetryPolicy.BaseDelay; delay < base/2 || delay > base {
+		t.Errorf("una política vacía debería usar BaseDelay por defecto, la espera fue %v", delay)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+
+	for _, tc := range []struct {
+		value    string
+		min, max time.Duration
+	}{
+		{"", 0, 0},
+		{"30", 30 * time.Second, 30 * time.Second},
+		{" 5 ", 5 * time.Second, 5 * time.Second},
+		{"0", 0, 0},
+		{"-3", 0, 0},
+		{"pronto", 0, 0},
+		{future, 59 * time.Minute, time.Hour},
+		{"Mon, 01 Jan 2001 00:00:00 GMT", 0, 0},
+	} {
+		if got := parseRetryAfter(tc.value); got < tc.min || got > tc.max {
+			t.Errorf("parseRetryAfter(%q) = %v, se esperaba entre %v y %v", tc.value, got, tc.min, tc.max)
+		}
+	}
+}
+
+// scriptedServer responde con los códigos de statuses en orden; el último se repite
+func scriptedServer(t *testing.T, statuses ...int) (*httptest.Server, func() int) {
+	t.Helper()
+
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		status := statuses[min(requests, len(statuses)-1)]
+		requests++
+		mu.Unlock()
+
+		switch status {
+		case http.StatusOK:
+			fmt.Fprint(w, `{"engineVersion":"2.3.0","maxAssessments":25}`)
+		case http.StatusBadRequest:
+			w.WriteHeader(status)
+			fmt.Fprint(w, `{"errors":[{"field":"host","message":"parámetro obligatorio"}]}`)
+		case http.StatusServiceUnavailable:
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(status)
+		default:
+			w.WriteHeader(status)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+// newTestClient crea un cliente contra server con esperas de milisegundos
+func newTestClient(t *testing.T, server *httptest.Server, maxRetries int, onRetry func(int, time.Duration, error)) *Client {
+	t.Helper()
+
+	c, err := New(WithBaseURL(server.URL+"/api/v2"), WithRetryPolicy(RetryPolicy{
+		MaxRetries: maxRetries,
+		BaseDelay:  time.Millisecond,
+		MaxDelay:   2 * time.Millisecond,
+		OnRetry:    onRetry,
+	}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func TestRequestRetriesTransientErrors(t *testing.T) {
+	server, requests := scriptedServer(t, http.StatusTooManyRequests, StatusOverloaded, http.StatusOK)
+
+	var attempts []int
+	c := newTestClient(t, server, 3, func(attempt int, delay time.Duration, err error) {
+		attempts = append(attempts, attempt)
+		if !IsRetryable(err) {
+			t.Errorf("OnRetry recibió un error que no se reintenta: %v", err)
+		}
+	})
+
+	info, err := c.GetInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GetInfo: %v", err)
+	}
+	if info.EngineVersion != "2.3.0" {
+		t.Errorf("info = %+v", info)
+	}
+	if got := requests(); got != 3 {
+		t.Errorf("%d solicitudes, se esperaban 3", got)
+	}
+	if fmt.Sprint(attempts) != "[1 2]" {
+		t.Errorf("reintentos informados: %v", attempts)
+	}
+}
+
+func TestRequestRetriesExhausted(t *testing.T) {
+	server, requests := scriptedServer(t, http.StatusServiceUnavailable)
+
+	var delays []time.Duration
+	c := newTestClient(t, server, 2, func(attempt int, delay time.Duration, err error) {
+		delays = append(delays, delay)
+	})
+
+	_, err := c.GetInfo(context.Background())
+	if !errors.Is(err, ErrMaintenance) {
+		t.Fatalf("err = %v, se esperaba ErrMaintenance", err)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != 7*time.Second {
+		t.Errorf("APIError = %+v, se esperaba RetryAfter de 7s", apiErr)
+	}
+	if got := requests(); got != 3 {
+		t.Errorf("%d solicitudes, se esperaban 3 (1 intento y 2 reintentos)", got)
+	}
+	// Retry-After se respeta pero acotado por MaxDelay
+	for _, delay := range delays {
+		if delay != 2*time.Millisecond {
+			t.Errorf("espera = %v, se esperaba MaxDelay", delay)
+		}
+	}
+}
+
+func TestRequestDoesNotRetryInvalidInput(t *testing.T) {
+	server, requests := scriptedServer(t, http.StatusBadRequest)
+	c := newTestClient(t, server, 3, nil)
+
+	_, err := c.StartAnalysis(context.Background(), "example.com", AnalyzeOptions{})
+	if !errors.Is(err, ErrInvalidInput) || !strings.Contains(err.Error(), "host - parámetro obligatorio") {
+		t.Fatalf("err = %v", err)
+	}
+	if got := requests(); got != 1 {
+		t.Errorf("%d solicitudes, un 400 no debería reintentarse", got)
+	}
+}
+
+func TestRequestRetryCanceled(t *testing.T) {
+	server, requests := scriptedServer(t, http.StatusTooManyRequests)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := newTestClient(t, server, 5, nil)
+	c.SetRetryPolicy(RetryPolicy{
+		MaxRetries: 5,
+		BaseDelay:  time.Hour,
+		MaxDelay:   time.Hour,
+		OnRetry:    func(int, time.Duration, error) { cancel() },
+	})
+
+	_, err := c.GetInfo(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, se esperaba context.Canceled", err)
+	}
+	if got := requests(); got != 1 {
+		t.Errorf("%d solicitudes después de cancelar", got)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	c, err := New(WithRetryPolicy(RetryPolicy{MaxRetries: 2}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	clone := c.WithRetry(RetryPolicy{MaxRetries: 7})
+	if c.RetryPolicy().MaxRetries != 2 || clone.RetryPolicy().MaxRetries != 7 {
+		t.Errorf("políticas = %d y %d", c.RetryPolicy().MaxRetries, clone.RetryPolicy().MaxRetries)
+	}
+	if clone.governor != c.governor || clone.httpClient != c.httpClient {
+		t.Error("la copia debería compartir el http.Client y el control de cupos")
+	}
+}
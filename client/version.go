@@ -0,0 +1,229 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"sslscanner/model"
+)
+
+// APIVersion identifica la versión de la API de SSL Labs
+type APIVersion int
+
+const (
+	APIv2 APIVersion = 2
+	APIv3 APIVersion = 3
+	APIv4 APIVersion = 4
+
+	BaseURLV3 = "https://api.ssllabs.com/api/v3"
+	BaseURLV4 = "https://api.ssllabs.com/api/v4"
+)
+
+// ParseAPIVersion acepta "2", "v2", "3", "v3", "4" o "v4"
+func ParseAPIVersion(value string) (APIVersion, error) {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(value)), "v") {
+	case "2":
+		return APIv2, nil
+	case "3":
+		return APIv3, nil
+	case "4":
+		return APIv4, nil
+	default:
+		return 0, fmt.Errorf("versión de API no soportada: %s (use 2, 3 o 4)", value)
+	}
+}
+
+func (v APIVersion) baseURL() string {
+	switch v {
+	case APIv3:
+		return BaseURLV3
+	case APIv4:
+		return BaseURLV4
+	default:
+		return BaseURL
+	}
+}
+
+// SetAPIVersion selecciona la versión de la API. La v4 exige el email de una
+// cuenta registrada, que se envía en la cabecera email de cada solicitud.
+// Si el cliente se creó con WithBaseURL la URL base no cambia.
+func (c *Client) SetAPIVersion(version APIVersion, email string) error {
+	if err := validateAPIVersion(version, email); err != nil {
+		return err
+	}
+
+	c.version = version
+	if !c.customBaseURL {
+		c.baseURL = version.baseURL()
+	}
+	c.email = email
+
+	return nil
+}
+
+func validateAPIVersion(version APIVersion, email string) error {
+	if version != APIv2 && version != APIv3 && version != APIv4 {
+		return fmt.Errorf("versión de API no soportada: %d", version)
+	}
+
+	if version == APIv4 && email == "" {
+		return fmt.Errorf("la API v4 requiere el email de una cuenta registrada (use register)")
+	}
+
+	return nil
+}
+
+func (c *Client) APIVersion() APIVersion {
+	return c.version
+}
+
+// Registration son los datos que pide el endpoint /register de la API v4
+type Registration struct {
+	FirstName    string `json:"firstName"`
+	LastName     string `json:"lastName"`
+	Email        string `json:"email"`
+	Organization string `json:"organization"`
+}
+
+type RegistrationResponse struct {
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+// Register registra una cuenta en la API v4. El email debe ser corporativo:
+// SSL Labs rechaza dominios de correo gratuitos.
+func (c *Client) Register(ctx context.Context, registration Registration) (*RegistrationResponse, error) {
+	if registration.FirstName == "" || registration.LastName == "" ||
+		registration.Email == "" || registration.Organization == "" {
+		return nil, fmt.Errorf("nombre, apellido, email y organización son obligatorios")
+	}
+
+	payload, err := json.Marshal(registration)
+	if err != nil {
+		return nil, fmt.Errorf("falló al codificar el registro: %w", err)
+	}
+
+	baseURL := c.baseURL
+	if c.version != APIv4 && !c.customBaseURL {
+		baseURL = BaseURLV4
+	}
+
+	body, err := c.doRequestWithBody(ctx, http.MethodPost, baseURL+"/register", payload)
+	if err != nil {
+		return nil, fmt.Errorf("falló el registro en SSL Labs: %w", err)
+	}
+
+	var response RegistrationResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("falló al decodificar respuesta de registro: %w", err)
+	}
+
+	return &response, nil
+}
+
+// NormalizeHost adapta las respuestas v3/v4 al modelo de la v2: el certificado y la
+// cadena de cada endpoint se reconstruyen desde Host.Certs y Details.CertChains,
+// para que el resto del programa no dependa de la versión de la API
+func NormalizeHost(host *model.Host) {
+	if len(host.Certs) == 0 {
+		return
+	}
+
+	certs := make(map[string]model.HostCert, len(host.Certs))
+	for _, cert := range host.Certs {
+		certs[cert.ID] = cert
+	}
+
+	for i := range host.Endpoints {
+		details := host.Endpoints[i].Details
+		if details == nil || details.Cert != nil || len(details.CertChains) == 0 {
+			continue
+		}
+
+		chain := details.CertChains[0]
+		if len(chain.CertIDs) == 0 {
+			continue
+		}
+
+		leaf, ok := certs[chain.CertIDs[0]]
+		if !ok {
+			continue
+		}
+
+		details.Cert = leafCert(leaf)
+		if details.Key == nil {
+			details.Key = &model.Key{
+				Size:       leaf.KeySize,
+				Strength:   leaf.KeyStrength,
+				Alg:        leaf.KeyAlg,
+				DebianFlaw: leaf.KeyKnownDebianInsecure,
+			}
+		}
+
+		if details.Chain == nil {
+			details.Chain = &model.Chain{Issues: chain.Issues}
+			for _, id := range chain.CertIDs {
+				if cert, ok := certs[id]; ok {
+					details.Chain.Certs = append(details.Chain.Certs, chainCert(cert))
+				}
+			}
+		}
+	}
+}
+
+func leafCert(cert model.HostCert) *model.Cert {
+	return &model.Cert{
+		Subject:          cert.Subject,
+		CommonNames:      cert.CommonNames,
+		AltNames:         cert.AltNames,
+		NotBefore:        cert.NotBefore,
+		NotAfter:         cert.NotAfter,
+		IssuerSubject:    cert.IssuerSubject,
+		SigAlg:           cert.SigAlg,
+		IssuerLabel:      commonName(cert.IssuerSubject),
+		RevocationInfo:   cert.RevocationInfo,
+		CrlURIs:          cert.CrlURIs,
+		OcspURIs:         cert.OcspURIs,
+		RevocationStatus: cert.RevocationStatus,
+		SGC:              cert.SGC,
+		ValidationType:   cert.ValidationType,
+		Issues:           cert.Issues,
+		SCT:              cert.SCT,
+		SerialNumber:     cert.SerialNumber,
+		Sha256Hash:       cert.Sha256Hash,
+	}
+}
+
+func chainCert(cert model.HostCert) model.ChainCert {
+	return model.ChainCert{
+		Subject:              cert.Subject,
+		Label:                commonName(cert.Subject),
+		NotBefore:            cert.NotBefore,
+		NotAfter:             cert.NotAfter,
+		IssuerSubject:        cert.IssuerSubject,
+		IssuerLabel:          commonName(cert.IssuerSubject),
+		SigAlg:               cert.SigAlg,
+		Issues:               cert.Issues,
+		KeyAlg:               cert.KeyAlg,
+		KeySize:              cert.KeySize,
+		KeyStrength:          cert.KeyStrength,
+		RevocationStatus:     cert.RevocationStatus,
+		CrlRevocationStatus:  cert.CrlRevocationStatus,
+		OcspRevocationStatus: cert.OcspRevocationStatus,
+		Raw:                  cert.Raw,
+	}
+}
+
+// commonName extrae el CN de un distinguished name como "CN=R3, O=Let's Encrypt, C=US"
+func commonName(dn string) string {
+	for _, part := range strings.Split(dn, ",") {
+		part = strings.TrimSpace(part)
+		if value, ok := strings.CutPrefix(part, "CN="); ok {
+			return value
+		}
+	}
+	return dn
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"testing"
+
+	"sslscanner/model"
+)
+
+func TestNormalizeHost(t *testing.T) {
+	existing := &model.Cert{Subject: "CN=v2.example.com"}
+	host := &model.Host{
+		Certs: []model.HostCert{
+			{
+				ID:            "leaf",
+				Subject:       "CN=example.com",
+				IssuerSubject: "CN=R3, O=Let's Encrypt, C=US",
+				NotAfter:      1767225600000,
+				KeyAlg:        "EC",
+				KeySize:       256,
+				KeyStrength:   3072,
+			},
+			{
+				ID:            "intermediate",
+				Subject:       "CN=R3, O=Let's Encrypt, C=US",
+				IssuerSubject: "CN=ISRG Root X1, O=Internet Security Research Group, C=US",
+				KeyAlg:        "RSA",
+				KeySize:       2048,
+			},
+		},
+		Endpoints: []model.Endpoint{
+			{Details: &model.EndpointDetails{CertChains: []model.CertChain{{CertIDs: []string{"leaf", "intermediate", "desconocido"}, Issues: 2}}}},
+			{Details: &model.EndpointDetails{Cert: existing, CertChains: []model.CertChain{{CertIDs: []string{"leaf"}}}}},
+			{Details: &model.EndpointDetails{CertChains: []model.CertChain{{CertIDs: []string{"desconocido"}}}}},
+			{},
+		},
+	}
+
+	NormalizeHost(host)
+
+	details := host.Endpoints[0].Details
+	if details.Cert == nil || details.Cert.Subject != "CN=example.com" || details.Cert.IssuerLabel != "R3" ||
+		details.Cert.NotAfter != 1767225600000 {
+		t.Fatalf("certificado = %+v", details.Cert)
+	}
+	if details.Key == nil || details.Key.Alg != "EC" || details.Key.Size != 256 || details.Key.Strength != 3072 {
+		t.Errorf("clave = %+v", details.Key)
+	}
+	if details.Chain == nil || details.Chain.Issues != 2 || len(details.Chain.Certs) != 2 {
+		t.Fatalf("cadena = %+v", details.Chain)
+	}
+	if label := details.Chain.Certs[1].Label; label != "R3" {
+		t.Errorf("etiqueta del intermedio = %q", label)
+	}
+	if issuer := details.Chain.Certs[1].IssuerLabel; issuer != "ISRG Root X1" {
+		t.Errorf("emisor del intermedio = %q", issuer)
+	}
+
+	if host.Endpoints[1].Details.Cert != existing {
+		t.Error("un certificado de la v2 no debería reemplazarse")
+	}
+	if host.Endpoints[2].Details.Cert != nil {
+		t.Error("una cadena que referencia un certificado desconocido no debería completarse")
+	}
+}
+
+func TestNormalizeHostWithoutCerts(t *testing.T) {
+	host := &model.Host{Endpoints: []model.Endpoint{{Details: &model.EndpointDetails{}}}}
+	NormalizeHost(host)
+
+	if details := host.Endpoints[0].Details; details.Cert != nil || details.Key != nil || details.Chain != nil {
+		t.Errorf("una respuesta v2 no debería modificarse: %+v", details)
+	}
+}
+
+func TestCommonName(t *testing.T) {
+	for dn, want := range map[string]string{
+		"CN=R3, O=Let's Encrypt, C=US": "R3",
+		"O=Example, CN=example.com":    "example.com",
+		"O=Sin CN":                     "O=Sin CN",
+	} {
+		if got := commonName(dn); got != want {
+			t.Errorf("commonName(%q) = %q, se esperaba %q", dn, got, want)
+		}
+	}
+}
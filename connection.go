@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"sslscanner/client"
+)
+
+// headerFlags acumula los --header repetidos
+type headerFlags []string
+
+func (h *headerFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlags) Set(value string) error {
+	*h = append(*h, value)
+	return nil
+}
+
+// connectionFlags son las opciones de conexión con la API, compartidas por el
+// análisis y los subcomandos que hablan con SSL Labs
+type connectionFlags struct {
+	baseURL   *string
+	proxy     *string
+	caCert    *string
+	timeout   *string
+	userAgent *string
+	headers   headerFlags
+}
+
+func addConnectionFlags(fs *flag.FlagSet) *connectionFlags {
+	f := &connectionFlags{
+		baseURL:   fs.String("base-url", os.Getenv("SSLLABS_BASE_URL"), "URL base de la API, p. ej. un espejo interno (env SSLLABS_BASE_URL)"),
+		proxy:     fs.String("proxy", os.Getenv("SSLLABS_PROXY"), "Proxy HTTP(S) para llegar a la API (env SSLLABS_PROXY; por defecto HTTPS_PROXY)"),
+		caCert:    fs.String("ca-cert", os.Getenv("SSLLABS_CA_CERT"), "Archivo PEM con CA adicionales de confianza (env SSLLABS_CA_CERT)"),
+		timeout:   fs.String("timeout", envOrDefault("SSLLABS_TIMEOUT", client.DefaultTimeout.String()), "Tiempo máximo de cada solicitud HTTP (env SSLLABS_TIMEOUT)"),
+		userAgent: fs.String("user-agent", envOrDefault("SSLLABS_USER_AGENT", client.DefaultUserAgent), "User-Agent de las solicitudes (env SSLLABS_USER_AGENT)"),
+	}
+	fs.Var(&f.headers, "header", "Cabecera extra \"Nombre: valor\" para todas las solicitudes (repetible)")
+	return f
+}
+
+// options traduce los flags a opciones de client.New
+func (f *connectionFlags) options() ([]client.Option, error) {
+	timeout, err := time.ParseDuration(*f.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("--timeout inválido: %s", *f.timeout)
+	}
+
+	opts := []client.Option{
+		client.WithTimeout(timeout),
+		client.WithUserAgent(*f.userAgent),
+	}
+
+	if *f.baseURL != "" {
+		opts = append(opts, client.WithBaseURL(*f.baseURL))
+	}
+	if *f.proxy != "" {
+		opts = append(opts, client.WithProxy(*f.proxy))
+	}
+	if *f.caCert != "" {
+		opts = append(opts, client.WithCACertFile(*f.caCert))
+	}
+
+	for _, header := range f.headers {
+		name, value, err := client.ParseHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithHeader(name, value))
+	}
+
+	return opts, nil
+}
+
+// headerNames devuelve los nombres de las cabeceras de --header, que pueden
+// llevar credenciales; las inválidas las reporta options
+func (f *connectionFlags) headerNames() []string {
+	var names []string
+	for _, header := range f.headers {
+		if name, _, err := client.ParseHeader(header); err == nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"sslscanner/diff"
+	"sslscanner/model"
+	"sslscanner/output"
+	"sslscanner/service"
+)
+
+// runDiff compara dos resultados del mismo host: dos archivos guardados, o un
+// archivo y un análisis nuevo con --scan
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	format := fs.String("format", formatText, "Formato de salida: text o json")
+	scan := fs.Bool("scan", false, "Comparar contra un análisis nuevo en lugar de un segundo archivo")
+	failOnRegression := fs.Bool("fail-on-regression", false, "Terminar con código 3 si algún cambio empeora la seguridad")
+	noColor := fs.Bool("no-color", false, "Deshabilitar colores en la salida")
+	engine := addEngineFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Compara dos análisis del mismo host y muestra la deriva de configuración:
+calificación, protocolos, cipher suites, vulnerabilidades, certificado y HSTS.
+
+Uso:
+  sslscanner diff [opciones] <anterior.json> <actual.json>
+  sslscanner diff [opciones] --scan <anterior.json> [dominio]
+
+Los archivos pueden ser entradas de la caché local o JSON de model.Host. Con
+--scan se analiza el dominio (por defecto, el del archivo anterior).
+
+Opciones:
+`)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitCodeInvalidArgs
+	}
+
+	if *format != formatText && *format != formatJSON {
+		fmt.Fprintf(os.Stderr, "Error: formato de salida inválido: %s\n", *format)
+		return exitCodeInvalidArgs
+	}
+	if (!*scan && fs.NArg() != 2) || (*scan && (fs.NArg() < 1 || fs.NArg() > 2)) {
+		fs.Usage()
+		return exitCodeInvalidArgs
+	}
+
+	before, err := loadHostFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+
+	var after *model.Host
+	if *scan {
+		domain := fs.Arg(1)
+		if domain == "" {
+			domain = scanTarget(before, *engine.engine)
+		}
+		after, err = scanForDiff(engine, domain)
+	} else {
+		after, err = loadHostFile(fs.Arg(1))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeAnalysisError
+	}
+
+	report := diff.Compare(before, after)
+
+	if *format == formatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCodeAnalysisError
+		}
+	} else {
+		output.NewFormatter(os.Stdout, !*noColor).PrintDiff(report)
+	}
+
+	if *failOnRegression && report.HasRegressions() {
+		return exitCodePolicyFailure
+	}
+	return exitCodeSuccess
+}
+
+// scanForDiff analiza el dominio sin caché local: la comparación debe ser
+// contra el estado actual
+func scanForDiff(engine *engineFlags, domain string) (*model.Host, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	setupSignalHandler(cancel)
+
+	scanner, err := engine.newScanner(engine.retryPolicy())
+	if err != nil {
+		return nil, err
+	}
+	progress := newProgressRenderer(os.Stderr, true)
+	defer progress.stop()
+	scanner.SetObserver(progress.observe)
+
+	fmt.Fprintf(os.Stderr, "Analizando %s...\n", domain)
+	return scanner.RunAnalysis(ctx, domain, service.DefaultScanOptions())
+}
+
+// scanTarget arma el destino a partir de un resultado guardado; el motor local
+// necesita el puerto si no es el estándar
+func scanTarget(host *model.Host, engine string) string {
+	if engine == engineLocal && host.Port != 0 && host.Port != 443 {
+		return host.Host + ":" + strconv.Itoa(host.Port)
+	}
+	return host.Host
+}
@@ -0,0 +1,398 @@
+// Package diff compara dos resultados de análisis del mismo host y reporta la
+// deriva de configuración por endpoint: calificación, protocolos, cipher
+// suites, vulnerabilidades, certificado y HSTS.
+package diff
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"sslscanner/model"
+)
+
+// Kind es el tipo de cambio
+type Kind string
+
+const (
+	Added   Kind = "added"
+	Removed Kind = "removed"
+	Changed Kind = "changed"
+)
+
+// Categorías de los cambios
+const (
+	CategoryGrade         = "grade"
+	CategoryProtocol      = "protocol"
+	CategorySuite         = "suite"
+	CategoryVulnerability = "vulnerability"
+	CategoryCertificate   = "certificate"
+	CategoryHSTS          = "hsts"
+	CategoryStatus        = "status"
+)
+
+// Change es una diferencia concreta. Item identifica qué cambió (el protocolo,
+// la suite, la vulnerabilidad o el campo); Before y After son los valores.
+type Change struct {
+	Category string `json:"category"`
+	Kind     Kind   `json:"kind"`
+	Item     string `json:"item"`
+	Before   string `json:"before,omitempty"`
+	After    string `json:"after,omitempty"`
+}
+
+// EndpointDiff son los cambios de un endpoint. Kind es Added o Removed si el
+// endpoint aparece en un solo resultado y Changed si está en ambos.
+type EndpointDiff struct {
+	IPAddress  string   `json:"ipAddress"`
+	ServerName string   `json:"serverName,omitempty"`
+	Kind       Kind     `json:"kind"`
+	Changes    []Change `json:"changes"`
+	// PreviousIP es la IP anterior cuando el endpoint se emparejó por nombre
+	PreviousIP string `json:"previousIp,omitempty"`
+}
+
+// Report es la comparación de dos resultados
+type Report struct {
+	Host       string         `json:"host"`
+	BeforeTime time.Time      `json:"beforeTime,omitzero"`
+	AfterTime  time.Time      `json:"afterTime,omitzero"`
+	Endpoints  []EndpointDiff `json:"endpoints"`
+}
+
+// HasChanges indica si hubo alguna diferencia
+func (r *Report) HasChanges() bool {
+	for _, endpoint := range r.Endpoints {
+		if endpoint.Kind != Changed || len(endpoint.Changes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRegressions indica si algún cambio empeora la seguridad (ver Change.Regression)
+func (r *Report) HasRegressions() bool {
+	for _, endpoint := range r.Endpoints {
+		for _, change := range endpoint.Changes {
+			if change.Regression() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Compare compara before con after. Los endpoints se emparejan por IP y, los
+// que quedan sin pareja, por nombre del servidor.
+func Compare(before, after *model.Host) *Report {
+	report := &Report{
+		Host:       after.Host,
+		BeforeTime: hostTime(before),
+		AfterTime:  hostTime(after),
+		Endpoints:  []EndpointDiff{},
+	}
+
+	pairs, removed, added := matchEndpoints(before.Endpoints, after.Endpoints)
+
+	for _, pair := range pairs {
+		diff := EndpointDiff{
+			IPAddress:  pair.after.IPAddress,
+			ServerName: pair.after.ServerName,
+			Kind:       Changed,
+			Changes:    compareEndpoints(pair.before, pair.after),
+		}
+		if pair.before.IPAddress != pair.after.IPAddress {
+			diff.PreviousIP = pair.before.IPAddress
+		}
+		report.Endpoints = append(report.Endpoints, diff)
+	}
+
+	for _, endpoint := range removed {
+		report.Endpoints = append(report.Endpoints, EndpointDiff{
+			IPAddress: endpoint.IPAddress, ServerName: endpoint.ServerName, Kind: Removed, Changes: []Change{},
+		})
+	}
+	for _, endpoint := range added {
+		report.Endpoints = append(report.Endpoints, EndpointDiff{
+			IPAddress: endpoint.IPAddress, ServerName: endpoint.ServerName, Kind: Added, Changes: []Change{},
+		})
+	}
+
+	return report
+}
+
+type endpointPair struct {
+	before, after *model.Endpoint
+}
+
+func matchEndpoints(before, after []model.Endpoint) ([]endpointPair, []*model.Endpoint, []*model.Endpoint) {
+	pairs := []endpointPair{}
+	matchedBefore := make(map[int]bool)
+	matchedAfter := make(map[int]bool)
+
+	match := func(same func(b, a *model.Endpoint) bool) {
+		for i := range after {
+			if matchedAfter[i] {
+				continue
+			}
+			for j := range before {
+				if !matchedBefore[j] && same(&before[j], &after[i]) {
+					pairs = append(pairs, endpointPair{before: &before[j], after: &after[i]})
+					matchedBefore[j], matchedAfter[i] = true, true
+					break
+				}
+			}
+		}
+	}
+
+	match(func(b, a *model.Endpoint) bool { return b.IPAddress == a.IPAddress })
+	match(func(b, a *model.Endpoint) bool { return b.ServerName != "" && b.ServerName == a.ServerName })
+
+	removed, added := []*model.Endpoint{}, []*model.Endpoint{}
+	for j := range before {
+		if !matchedBefore[j] {
+			removed = append(removed, &before[j])
+		}
+	}
+	for i := range after {
+		if !matchedAfter[i] {
+			added = append(added, &after[i])
+		}
+	}
+
+	return pairs, removed, added
+}
+
+func compareEndpoints(before, after *model.Endpoint) []Change {
+	changes := []Change{}
+
+	if before.StatusMessage != after.StatusMessage {
+		changes = append(changes, changed(CategoryStatus, "statusMessage", before.StatusMessage, after.StatusMessage))
+	}
+	if before.Grade != after.Grade {
+		changes = append(changes, changed(CategoryGrade, "grade", before.Grade, after.Grade))
+	}
+	if before.GradeTrustIgnored != after.GradeTrustIgnored {
+		changes = append(changes, changed(CategoryGrade, "gradeTrustIgnored", before.GradeTrustIgnored, after.GradeTrustIgnored))
+	}
+
+	// sin detalles en alguno de los dos no hay con qué comparar el resto
+	if before.Details == nil || after.Details == nil {
+		return changes
+	}
+
+	changes = append(changes, compareSets(CategoryProtocol, protocolNames(before.Details), protocolNames(after.Details))...)
+	changes = append(changes, compareSets(CategorySuite, suiteNames(before.Details), suiteNames(after.Details))...)
+	changes = append(changes, compareVulnerabilities(before.Details, after.Details)...)
+	changes = append(changes, compareCertificates(before.Details.Cert, after.Details.Cert)...)
+	changes = append(changes, compareHSTS(before.Details.HstsPolicy, after.Details.HstsPolicy)...)
+
+	return changes
+}
+
+// compareSets reporta los elementos que aparecen o desaparecen, en orden alfabético
+func compareSets(category string, before, after []string) []Change {
+	inBefore := make(map[string]bool, len(before))
+	for _, item := range before {
+		inBefore[item] = true
+	}
+	inAfter := make(map[string]bool, len(after))
+	for _, item := range after {
+		inAfter[item] = true
+	}
+
+	changes := []Change{}
+	for _, item := range sortedKeys(inBefore) {
+		if !inAfter[item] {
+			changes = append(changes, Change{Category: category, Kind: Removed, Item: item})
+		}
+	}
+	for _, item := range sortedKeys(inAfter) {
+		if !inBefore[item] {
+			changes = append(changes, Change{Category: category, Kind: Added, Item: item})
+		}
+	}
+	return changes
+}
+
+// vulnerabilityFlags son los indicadores que se comparan; FallbackScsv es una
+// protección, así que su pérdida también es una regresión
+var vulnerabilityFlags = []struct {
+	id    string
+	value func(*model.EndpointDetails) bool
+}{
+	{"heartbleed", func(d *model.EndpointDetails) bool { return d.Heartbleed }},
+	{"poodle", func(d *model.EndpointDetails) bool { return d.Poodle }},
+	{"poodleTls", func(d *model.EndpointDetails) bool { return d.PoodleTLS == 2 }},
+	{"beast", func(d *model.EndpointDetails) bool { return d.VulnBeast }},
+	{"freak", func(d *model.EndpointDetails) bool { return d.Freak }},
+	{"logjam", func(d *model.EndpointDetails) bool { return d.Logjam }},
+	{"opensslCcs", func(d *model.EndpointDetails) bool { return d.OpenSSLCcs == 3 }},
+	{"rc4", func(d *model.EndpointDetails) bool { return d.SupportsRC4 }},
+	{"fallbackScsv", func(d *model.EndpointDetails) bool { return d.FallbackScsv }},
+}
+
+func compareVulnerabilities(before, after *model.EndpointDetails) []Change {
+	changes := []Change{}
+	for _, flag := range vulnerabilityFlags {
+		b, a := flag.value(before), flag.value(after)
+		if b != a {
+			changes = append(changes, changed(CategoryVulnerability, flag.id, strconv.FormatBool(b), strconv.FormatBool(a)))
+		}
+	}
+	return changes
+}
+
+// compareCertificates detecta un certificado reemplazado por número de serie o
+// huella, y además reporta el emisor y el vencimiento si cambiaron
+func compareCertificates(before, after *model.Cert) []Change {
+	switch {
+	case before == nil && after == nil:
+		return []Change{}
+	case before == nil:
+		return []Change{{Category: CategoryCertificate, Kind: Added, Item: "certificate", After: after.Subject}}
+	case after == nil:
+		return []Change{{Category: CategoryCertificate, Kind: Removed, Item: "certificate", Before: before.Subject}}
+	}
+
+	changes := []Change{}
+	if before.SerialNumber != after.SerialNumber {
+		changes = append(changes, changed(CategoryCertificate, "serialNumber", before.SerialNumber, after.SerialNumber))
+	}
+	if before.Sha256Hash != after.Sha256Hash {
+		changes = append(changes, changed(CategoryCertificate, "sha256Hash", before.Sha256Hash, after.Sha256Hash))
+	}
+	if before.IssuerSubject != after.IssuerSubject {
+		changes = append(changes, changed(CategoryCertificate, "issuer", before.IssuerSubject, after.IssuerSubject))
+	}
+	if before.NotAfter != after.NotAfter {
+		changes = append(changes, changed(CategoryCertificate, "notAfter", formatMillis(before.NotAfter), formatMillis(after.NotAfter)))
+	}
+	if before.Issues != after.Issues {
+		changes = append(changes, changed(CategoryCertificate, "issues", strconv.Itoa(before.Issues), strconv.Itoa(after.Issues)))
+	}
+	return changes
+}
+
+func compareHSTS(before, after *model.HstsPolicy) []Change {
+	b, a := hstsSummary(before), hstsSummary(after)
+	changes := []Change{}
+
+	if b.status != a.status {
+		changes = append(changes, changed(CategoryHSTS, "status", b.status, a.status))
+	}
+	if b.maxAge != a.maxAge {
+		changes = append(changes, changed(CategoryHSTS, "maxAge", strconv.FormatInt(b.maxAge, 10), strconv.FormatInt(a.maxAge, 10)))
+	}
+	if b.includeSubDomains != a.includeSubDomains {
+		changes = append(changes, changed(CategoryHSTS, "includeSubDomains", strconv.FormatBool(b.includeSubDomains), strconv.FormatBool(a.includeSubDomains)))
+	}
+	if b.preload != a.preload {
+		changes = append(changes, changed(CategoryHSTS, "preload", strconv.FormatBool(b.preload), strconv.FormatBool(a.preload)))
+	}
+
+	return changes
+}
+
+type hsts struct {
+	status            string
+	maxAge            int64
+	includeSubDomains bool
+	preload           bool
+}
+
+func hstsSummary(policy *model.HstsPolicy) hsts {
+	if policy == nil {
+		return hsts{status: "unknown"}
+	}
+	return hsts{
+		status:            policy.Status,
+		maxAge:            policy.MaxAge,
+		includeSubDomains: policy.IncludeSubDomains,
+		preload:           policy.Preload,
+	}
+}
+
+func protocolNames(details *model.EndpointDetails) []string {
+	names := []string{}
+	for _, proto := range details.Protocols {
+		names = append(names, proto.Name+" "+proto.Version)
+	}
+	return names
+}
+
+func suiteNames(details *model.EndpointDetails) []string {
+	names := []string{}
+	if details.Suites != nil {
+		for _, suite := range details.Suites.List {
+			names = append(names, suite.Name)
+		}
+	}
+	return names
+}
+
+func changed(category, item, before, after string) Change {
+	return Change{Category: category, Kind: Changed, Item: item, Before: before, After: after}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func hostTime(host *model.Host) time.Time {
+	switch {
+	case host.TestTime > 0:
+		return time.UnixMilli(host.TestTime).UTC()
+	case host.StartTime > 0:
+		return time.UnixMilli(host.StartTime).UTC()
+	default:
+		return time.Time{}
+	}
+}
+
+func formatMillis(ms int64) string {
+	if ms == 0 {
+		return ""
+	}
+	return time.UnixMilli(ms).UTC().Format("2006-01-02")
+}
+
+// Regression indica si el cambio empeora la seguridad: calificación menor,
+// vulnerabilidad nueva, protocolo obsoleto agregado o moderno quitado,
+// problemas nuevos en el certificado o HSTS debilitado
+func (c Change) Regression() bool {
+	switch c.Category {
+	case CategoryStatus:
+		return c.After != "Ready"
+	case CategoryGrade:
+		return model.GradeRank(c.After) < model.GradeRank(c.Before)
+	case CategoryVulnerability:
+		if c.Item == "fallbackScsv" {
+			return c.After == "false"
+		}
+		return c.After == "true"
+	case CategoryProtocol:
+		modern := c.Item == "TLS 1.2" || c.Item == "TLS 1.3"
+		return (c.Kind == Added && !modern) || (c.Kind == Removed && modern)
+	case CategoryCertificate:
+		return (c.Item == "issues" && c.After != "0") || (c.Kind == Removed)
+	case CategoryHSTS:
+		switch c.Item {
+		case "status":
+			return c.Before == "present"
+		case "maxAge":
+			before, _ := strconv.ParseInt(c.Before, 10, 64)
+			after, _ := strconv.ParseInt(c.After, 10, 64)
+			return after < before
+		default:
+			return c.After == "false"
+		}
+	default:
+		return false
+	}
+}
@@ -0,0 +1,211 @@
+package diff
+
+import (
+	"fmt"
+	"testing"
+
+	"sslscanner/model"
+)
+
+func baseEndpoint(ip string) model.Endpoint {
+	return model.Endpoint{
+		IPAddress:     ip,
+		ServerName:    "web1.example.com",
+		StatusMessage: "Ready",
+		Grade:         "A",
+		Details: &model.EndpointDetails{
+			Protocols: []model.Protocol{{Name: "TLS", Version: "1.2"}, {Name: "TLS", Version: "1.3"}},
+			Suites: &model.Suites{List: []model.Suite{
+				{Name: "TLS_AES_128_GCM_SHA256"},
+				{Name: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+			}},
+			FallbackScsv: true,
+			Cert: &model.Cert{
+				Subject:       "CN=example.com",
+				IssuerSubject: "CN=R3",
+				SerialNumber:  "01",
+				Sha256Hash:    "aaaa",
+				NotAfter:      1790000000000,
+			},
+			HstsPolicy: &model.HstsPolicy{Status: "present", MaxAge: 31536000, IncludeSubDomains: true},
+		},
+	}
+}
+
+func host(endpoints ...model.Endpoint) *model.Host {
+	return &model.Host{Host: "example.com", Endpoints: endpoints}
+}
+
+// describe resume los cambios de un endpoint como categoría/tipo/item
+func describe(changes []Change) []string {
+	items := []string{}
+	for _, change := range changes {
+		items = append(items, fmt.Sprintf("%s/%s/%s", change.Category, change.Kind, change.Item))
+	}
+	return items
+}
+
+func TestCompareUnchanged(t *testing.T) {
+	report := Compare(host(baseEndpoint("192.0.2.1")), host(baseEndpoint("192.0.2.1")))
+
+	if report.HasChanges() || report.HasRegressions() || len(report.Endpoints) != 1 {
+		t.Errorf("reporte = %+v", report)
+	}
+}
+
+func TestCompareEndpointChanges(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		modify     func(*model.Endpoint)
+		want       string
+		regression bool
+	}{
+		{
+			name:       "calificación",
+			modify:     func(e *model.Endpoint) { e.Grade = "B" },
+			want:       "[grade/changed/grade]",
+			regression: true,
+		},
+		{
+			name:   "calificación mejor",
+			modify: func(e *model.Endpoint) { e.Grade = "A+" },
+			want:   "[grade/changed/grade]",
+		},
+		{
+			name:       "estado",
+			modify:     func(e *model.Endpoint) { e.StatusMessage = "Unable to connect to the server"; e.Details = nil },
+			want:       "[status/changed/statusMessage]",
+			regression: true,
+		},
+		{
+			name: "protocolos",
+			modify: func(e *model.Endpoint) {
+				e.Details.Protocols = []model.Protocol{{Name: "TLS", Version: "1.0"}, {Name: "TLS", Version: "1.2"}}
+			},
+			want:       "[protocol/removed/TLS 1.3 protocol/added/TLS 1.0]",
+			regression: true,
+		},
+		{
+			name: "suites",
+			modify: func(e *model.Endpoint) {
+				e.Details.Suites.List = e.Details.Suites.List[:1]
+			},
+			want: "[suite/removed/TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256]",
+		},
+		{
+			name:       "vulnerabilidad",
+			modify:     func(e *model.Endpoint) { e.Details.Heartbleed = true },
+			want:       "[vulnerability/changed/heartbleed]",
+			regression: true,
+		},
+		{
+			name:       "sin TLS_FALLBACK_SCSV",
+			modify:     func(e *model.Endpoint) { e.Details.FallbackScsv = false },
+			want:       "[vulnerability/changed/fallbackScsv]",
+			regression: true,
+		},
+		{
+			name: "certificado renovado",
+			modify: func(e *model.Endpoint) {
+				e.Details.Cert.SerialNumber = "02"
+				e.Details.Cert.Sha256Hash = "bbbb"
+				e.Details.Cert.NotAfter = 1800000000000
+			},
+			want: "[certificate/changed/serialNumber certificate/changed/sha256Hash certificate/changed/notAfter]",
+		},
+		{
+			name:   "solo la huella",
+			modify: func(e *model.Endpoint) { e.Details.Cert.Sha256Hash = "bbbb" },
+			want:   "[certificate/changed/sha256Hash]",
+		},
+		{
+			name:       "problemas del certificado",
+			modify:     func(e *model.Endpoint) { e.Details.Cert.Issues = 8 },
+			want:       "[certificate/changed/issues]",
+			regression: true,
+		},
+		{
+			name:       "certificado quitado",
+			modify:     func(e *model.Endpoint) { e.Details.Cert = nil },
+			want:       "[certificate/removed/certificate]",
+			regression: true,
+		},
+		{
+			name:       "HSTS más corto",
+			modify:     func(e *model.Endpoint) { e.Details.HstsPolicy.MaxAge = 86400 },
+			want:       "[hsts/changed/maxAge]",
+			regression: true,
+		},
+		{
+			name:       "HSTS sin subdominios",
+			modify:     func(e *model.Endpoint) { e.Details.HstsPolicy.IncludeSubDomains = false },
+			want:       "[hsts/changed/includeSubDomains]",
+			regression: true,
+		},
+		{
+			name:   "HSTS con preload",
+			modify: func(e *model.Endpoint) { e.Details.HstsPolicy.Preload = true },
+			want:   "[hsts/changed/preload]",
+		},
+		{
+			name:       "HSTS desconocido",
+			modify:     func(e *model.Endpoint) { e.Details.HstsPolicy = nil },
+			want:       "[hsts/changed/status hsts/changed/maxAge hsts/changed/includeSubDomains]",
+			regression: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			after := baseEndpoint("192.0.2.1")
+			tc.modify(&after)
+
+			report := Compare(host(baseEndpoint("192.0.2.1")), host(after))
+			if len(report.Endpoints) != 1 {
+				t.Fatalf("endpoints = %+v", report.Endpoints)
+			}
+			if got := fmt.Sprint(describe(report.Endpoints[0].Changes)); got != tc.want {
+				t.Errorf("cambios = %s, se esperaba %s", got, tc.want)
+			}
+			if !report.HasChanges() || report.HasRegressions() != tc.regression {
+				t.Errorf("HasRegressions = %t, se esperaba %t", report.HasRegressions(), tc.regression)
+			}
+		})
+	}
+}
+
+func TestCompareMatchesEndpoints(t *testing.T) {
+	moved := baseEndpoint("192.0.2.9")
+	other := baseEndpoint("192.0.2.2")
+	other.ServerName = "web2.example.com"
+	added := baseEndpoint("2001:db8::1")
+	added.ServerName = ""
+
+	report := Compare(host(baseEndpoint("192.0.2.1"), other), host(moved, added))
+
+	var got []string
+	for _, endpoint := range report.Endpoints {
+		got = append(got, fmt.Sprintf("%s:%s:%s", endpoint.Kind, endpoint.IPAddress, endpoint.PreviousIP))
+	}
+	want := "[changed:192.0.2.9:192.0.2.1 removed:192.0.2.2: added:2001:db8::1:]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("endpoints = %v, se esperaba %s", got, want)
+	}
+	if !report.HasChanges() {
+		t.Error("un endpoint agregado o quitado es un cambio")
+	}
+	if report.HasRegressions() {
+		t.Error("agregar o quitar endpoints no es una regresión")
+	}
+}
+
+func TestCompareTimes(t *testing.T) {
+	before := host(baseEndpoint("192.0.2.1"))
+	before.StartTime = 1700000000000
+	after := host(baseEndpoint("192.0.2.1"))
+	after.StartTime = 1700000000000
+	after.TestTime = 1700000600000
+
+	report := Compare(before, after)
+	if report.BeforeTime.UnixMilli() != before.StartTime || report.AfterTime.UnixMilli() != after.TestTime {
+		t.Errorf("tiempos = %v, %v", report.BeforeTime, report.AfterTime)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"sslscanner/client"
+	"sslscanner/prober"
+	"sslscanner/service"
+)
+
+// Motores de análisis disponibles
+const (
+	engineSSLLabs = "ssllabs"
+	engineLocal   = "local"
+)
+
+// engineFlags eligen y configuran el motor de análisis; los comparten el
+// análisis y los subcomandos que analizan dominios
+type engineFlags struct {
+	engine        *string
+	probeTimeout  *time.Duration
+	apiVersion    *string
+	email         *string
+	maxRetries    *int
+	retryMaxDelay *time.Duration
+	connection    *connectionFlags
+}
+
+func addEngineFlags(fs *flag.FlagSet) *engineFlags {
+	return &engineFlags{
+		engine:        fs.String("engine", envOrDefault("SSLSCANNER_ENGINE", engineSSLLabs), "Motor de análisis: ssllabs o local (env SSLSCANNER_ENGINE)"),
+		probeTimeout:  fs.Duration("probe-timeout", prober.DefaultTimeout, "Tiempo máximo de cada conexión del motor local"),
+		apiVersion:    fs.String("api-version", envOrDefault("SSLLABS_API_VERSION", "2"), "Versión de la API de SSL Labs: 2, 3 o 4 (env SSLLABS_API_VERSION)"),
+		email:         fs.String("email", os.Getenv("SSLLABS_EMAIL"), "Email registrado, obligatorio para la API v4 (env SSLLABS_EMAIL)"),
+		maxRetries:    fs.Int("max-retries", client.DefaultRetryPolicy.MaxRetries, "Reintentos ante respuestas 429, 503 y 529 de SSL Labs (0 los desactiva)"),
+		retryMaxDelay: fs.Duration("retry-max-delay", client.DefaultRetryPolicy.MaxDelay, "Espera máxima entre reintentos"),
+		connection:    addConnectionFlags(fs),
+	}
+}
+
+// retryPolicy arma la política de reintentos; el Scanner informa cada
+// reintento con un evento service.Retry
+func (f *engineFlags) retryPolicy() client.RetryPolicy {
+	return client.RetryPolicy{
+		MaxRetries: *f.maxRetries,
+		BaseDelay:  client.DefaultRetryPolicy.BaseDelay,
+		MaxDelay:   *f.retryMaxDelay,
+	}
+}
+
+// newScanner crea el Scanner del motor elegido. extra son opciones adicionales
+// del cliente de SSL Labs y no se aceptan con el motor local.
+func (f *engineFlags) newScanner(retry client.RetryPolicy, extra ...client.Option) (*service.Scanner, error) {
+	if *f.maxRetries < 0 {
+		return nil, fmt.Errorf("--max-retries no puede ser negativo")
+	}
+
+	switch *f.engine {
+	case engineSSLLabs:
+		version, err := client.ParseAPIVersion(*f.apiVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		clientOpts, err := f.connection.options()
+		if err != nil {
+			return nil, err
+		}
+		clientOpts = append(clientOpts, extra...)
+		clientOpts = append(clientOpts,
+			client.WithAPIVersion(version, *f.email),
+			client.WithRetryPolicy(retry),
+		)
+
+		return service.NewScanner(clientOpts...)
+	case engineLocal:
+		if len(extra) > 0 {
+			return nil, fmt.Errorf("--record y --replay solo se aplican al motor ssllabs")
+		}
+		return newLocalScanner(*f.connection.caCert, *f.probeTimeout)
+	default:
+		return nil, fmt.Errorf("motor de análisis inválido: %s", *f.engine)
+	}
+}
+
+// newLocalScanner crea un Scanner sobre el prober local; caCert agrega CA
+// propias a la validación de certificados, útil en entornos internos
+func newLocalScanner(caCert string, timeout time.Duration) (*service.Scanner, error) {
+	if timeout <= 0 {
+		return nil, fmt.Errorf("--probe-timeout debe ser mayor que cero")
+	}
+
+	opts := prober.Options{Timeout: timeout}
+	if caCert != "" {
+		roots, err := client.LoadCACertPool(caCert)
+		if err != nil {
+			return nil, err
+		}
+		opts.RootCAs = roots
+	}
+
+	scanner := service.NewScannerWithBackend(prober.NewBackend(opts))
+	// el prober entrega el resultado terminado: no hay nada que esperar
+	scanner.SetPolling(service.Polling{Initial: time.Millisecond, Running: time.Millisecond})
+
+	return scanner, nil
+}
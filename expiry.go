@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"sslscanner/cache"
+	"sslscanner/expiry"
+	"sslscanner/output"
+	"sslscanner/service"
+)
+
+// runExpiry revisa el vencimiento de los certificados de una lista de dominios,
+// usando la caché local cuando el resultado está fresco
+func runExpiry(args []string) int {
+	fs := flag.NewFlagSet("expiry", flag.ContinueOnError)
+	format := fs.String("format", formatText, "Formato de salida: text o json")
+	domainsFile := fs.String("file", "", "Archivo con un dominio por línea (\"-\" para leer de stdin)")
+	warnDays := fs.Int("warn-days", expiry.DefaultWarningDays, "Avisar si al certificado le quedan menos de estos días")
+	criticalDays := fs.Int("critical-days", expiry.DefaultCriticalDays, "Terminar con código 3 si a algún certificado le quedan menos de estos días")
+	concurrency := fs.Int("concurrency", service.DefaultBatchConcurrency, "Máximo de análisis simultáneos")
+	cacheDir := fs.String("cache-dir", "", "Directorio de la caché local (por defecto $XDG_CACHE_HOME/sslscanner)")
+	cacheTTL := fs.Duration("cache-ttl", cache.DefaultTTL, "Tiempo durante el cual un resultado en caché se considera fresco")
+	refresh := fs.Bool("refresh", false, "Ignorar la caché local y volver a analizar")
+	offline := fs.Bool("offline", false, "Usar solo resultados de la caché local, sin conexión")
+	noCache := fs.Bool("no-cache", false, "No leer ni escribir la caché local")
+	noColor := fs.Bool("no-color", false, "Deshabilitar colores en la salida")
+	engine := addEngineFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Revisa el vencimiento de los certificados (leaf y cadena) de uno o varios
+dominios y los muestra ordenados por días restantes.
+
+Uso:
+  sslscanner expiry [opciones] <dominio>...
+  sslscanner expiry [opciones] --file <archivo|->
+
+Códigos de salida:
+  0  ningún certificado en la ventana crítica
+  1  argumentos inválidos
+  2  no se pudo revisar algún dominio
+  3  algún certificado vencido o en la ventana crítica
+
+Opciones:
+`)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitCodeInvalidArgs
+	}
+
+	if *format != formatText && *format != formatJSON {
+		fmt.Fprintf(os.Stderr, "Error: formato de salida inválido: %s\n", *format)
+		return exitCodeInvalidArgs
+	}
+
+	thresholds := expiry.Thresholds{Warning: *warnDays, Critical: *criticalDays}
+	if err := thresholds.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+
+	domains, err := collectDomains(fs.Args(), *domainsFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+	if len(domains) == 0 {
+		fs.Usage()
+		return exitCodeInvalidArgs
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	setupSignalHandler(cancel)
+
+	// stdout queda reservado para la tabla o el documento
+	scanner, err := engine.newScanner(engine.retryPolicy())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+	progress := newProgressRenderer(os.Stderr, true)
+	defer progress.stop()
+	scanner.SetObserver(progress.observe)
+
+	if err := configureCache(scanner, *engine.engine, *cacheDir, *cacheTTL, *refresh, *offline, *noCache); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+
+	completed := 0
+	results, err := scanner.RunBatch(ctx, domains, service.DefaultScanOptions(), *concurrency, func(result service.BatchResult) {
+		completed++
+		progress.hold(func() {
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s terminado\n", completed, len(domains), result.Domain)
+		})
+	})
+	progress.stop()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeAnalysisError
+	}
+
+	report := expiry.NewReport(thresholds, time.Now())
+	for _, result := range results {
+		if result.Err != nil {
+			report.AddError(result.Domain, result.Err)
+			continue
+		}
+		report.AddHost(result.Domain, result.Host)
+	}
+
+	if *format == formatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCodeAnalysisError
+		}
+	} else {
+		output.NewFormatter(os.Stdout, !*noColor).PrintExpiry(report)
+	}
+
+	// como en el modo por lotes, los errores de análisis tienen prioridad
+	switch {
+	case len(report.Errors) > 0:
+		return exitCodeAnalysisError
+	case report.HasCritical():
+		return exitCodePolicyFailure
+	}
+	return exitCodeSuccess
+}
@@ -0,0 +1,246 @@
+// Package expiry revisa el vencimiento de los certificados (leaf y cadena) de
+// uno o varios hosts y los clasifica según umbrales en días.
+package expiry
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"sslscanner/model"
+)
+
+const (
+	DefaultWarningDays  = 30
+	DefaultCriticalDays = 7
+)
+
+// Estados de un certificado según los días que le quedan
+const (
+	StatusOK       = "ok"
+	StatusWarning  = "warning"
+	StatusCritical = "critical"
+	StatusExpired  = "expired"
+)
+
+// Posición del certificado en la cadena
+const (
+	PositionLeaf  = "leaf"
+	PositionChain = "chain"
+)
+
+// Thresholds define las ventanas de aviso en días: un certificado con menos de
+// Critical días es crítico y con menos de Warning días genera un aviso
+type Thresholds struct {
+	Warning  int `json:"warningDays"`
+	Critical int `json:"criticalDays"`
+}
+
+// DefaultThresholds avisa a 30 días y considera crítico a 7
+var DefaultThresholds = Thresholds{Warning: DefaultWarningDays, Critical: DefaultCriticalDays}
+
+// Validate verifica que los umbrales tengan sentido
+func (t Thresholds) Validate() error {
+	if t.Warning < 0 || t.Critical < 0 {
+		return fmt.Errorf("los umbrales de vencimiento no pueden ser negativos")
+	}
+	if t.Critical > t.Warning {
+		return fmt.Errorf("el umbral crítico (%d días) no puede superar al de aviso (%d días)", t.Critical, t.Warning)
+	}
+	return nil
+}
+
+// status clasifica los días restantes según los umbrales
+func (t Thresholds) status(days int, expired bool) string {
+	switch {
+	case expired:
+		return StatusExpired
+	case days < t.Critical:
+		return StatusCritical
+	case days < t.Warning:
+		return StatusWarning
+	}
+	return StatusOK
+}
+
+// Certificate es un certificado visto en uno o más endpoints de un dominio
+type Certificate struct {
+	Domain        string    `json:"domain"`
+	IPAddresses   []string  `json:"ipAddresses"`
+	Position      string    `json:"position"`
+	Subject       string    `json:"subject"`
+	Issuer        string    `json:"issuer,omitempty"`
+	NotAfter      time.Time `json:"notAfter"`
+	DaysRemaining int       `json:"daysRemaining"`
+	Status        string    `json:"status"`
+}
+
+// Critical indica si el certificado está vencido o dentro de la ventana crítica
+func (c Certificate) Critical() bool {
+	return c.Status == StatusCritical || c.Status == StatusExpired
+}
+
+// HostError es un dominio que no se pudo revisar
+type HostError struct {
+	Domain string `json:"domain"`
+	Error  string `json:"error"`
+}
+
+// Report reúne los certificados de todos los dominios, del más próximo a
+// vencer al más lejano
+type Report struct {
+	CheckedAt    time.Time     `json:"checkedAt"`
+	Thresholds   Thresholds    `json:"thresholds"`
+	Certificates []Certificate `json:"certificates"`
+	Errors       []HostError   `json:"errors,omitempty"`
+}
+
+func NewReport(thresholds Thresholds, now time.Time) *Report {
+	return &Report{
+		CheckedAt:    now,
+		Thresholds:   thresholds,
+		Certificates: []Certificate{},
+	}
+}
+
+// AddHost agrega los certificados del host; los que se repiten entre endpoints
+// se informan una sola vez con todas sus IPs. Si ningún endpoint informa el
+// certificado del servidor, el dominio se registra con AddError.
+func (r *Report) AddHost(domain string, host *model.Host) {
+	seen := make(map[string]int)
+	foundLeaf := false
+
+	add := func(ip, position, subject, issuer string, notAfter int64) {
+		if notAfter <= 0 {
+			return
+		}
+
+		key := position + "|" + subject + "|" + issuer + "|" + fmt.Sprint(notAfter)
+		if i, ok := seen[key]; ok {
+			r.Certificates[i].IPAddresses = appendUnique(r.Certificates[i].IPAddresses, ip)
+			return
+		}
+
+		expires := time.UnixMilli(notAfter).UTC()
+		remaining := expires.Sub(r.CheckedAt)
+		days := int(remaining.Hours() / 24)
+
+		seen[key] = len(r.Certificates)
+		r.Certificates = append(r.Certificates, Certificate{
+			Domain:        domain,
+			IPAddresses:   []string{ip},
+			Position:      position,
+			Subject:       subject,
+			Issuer:        issuer,
+			NotAfter:      expires,
+			DaysRemaining: days,
+			Status:        r.Thresholds.status(days, remaining < 0),
+		})
+	}
+
+	for _, endpoint := range host.Endpoints {
+		details := endpoint.Details
+		if details == nil {
+			continue
+		}
+
+		leafSubject := ""
+		var leafNotAfter int64
+		if cert := details.Cert; cert != nil {
+			foundLeaf = foundLeaf || cert.NotAfter > 0
+			leafSubject, leafNotAfter = cert.Subject, cert.NotAfter
+			add(endpoint.IPAddress, PositionLeaf, certLabel(cert.CommonNames, cert.Subject), cert.IssuerLabel, cert.NotAfter)
+		}
+
+		if details.Chain == nil {
+			continue
+		}
+		for _, cert := range details.Chain.Certs {
+			// la cadena empieza por el leaf, que ya se informó
+			if cert.Subject == leafSubject && cert.NotAfter == leafNotAfter {
+				continue
+			}
+			add(endpoint.IPAddress, PositionChain, certLabel([]string{cert.Label}, cert.Subject), cert.IssuerLabel, cert.NotAfter)
+		}
+	}
+
+	if !foundLeaf {
+		message := "no se encontró el certificado del servidor"
+		if status := hostStatusMessage(host); status != "" {
+			message += ": " + status
+		}
+		r.AddError(domain, errors.New(message))
+	}
+
+	r.sort()
+}
+
+// AddError registra un dominio que no se pudo revisar
+func (r *Report) AddError(domain string, err error) {
+	r.Errors = append(r.Errors, HostError{Domain: domain, Error: err.Error()})
+}
+
+// HasCritical indica si algún certificado está vencido o en la ventana crítica
+func (r *Report) HasCritical() bool {
+	for _, cert := range r.Certificates {
+		if cert.Critical() {
+			return true
+		}
+	}
+	return false
+}
+
+// Count devuelve cuántos certificados hay en el estado indicado
+func (r *Report) Count(status string) int {
+	count := 0
+	for _, cert := range r.Certificates {
+		if cert.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// hostStatusMessage explica por qué el host no tiene certificado: el mensaje
+// del host o, si no lo hay, el del primer endpoint que no terminó
+func hostStatusMessage(host *model.Host) string {
+	if host.StatusMessage != "" {
+		return host.StatusMessage
+	}
+	for _, endpoint := range host.Endpoints {
+		if endpoint.StatusMessage != "" && endpoint.StatusMessage != "Ready" {
+			return endpoint.StatusMessage
+		}
+	}
+	return ""
+}
+
+func (r *Report) sort() {
+	sort.SliceStable(r.Certificates, func(i, j int) bool {
+		return r.Certificates[i].NotAfter.Before(r.Certificates[j].NotAfter)
+	})
+}
+
+// certLabel prefiere el common name al subject completo
+func certLabel(commonNames []string, subject string) string {
+	if len(commonNames) > 0 && commonNames[0] != "" {
+		return commonNames[0]
+	}
+	for _, part := range strings.Split(subject, ",") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(part), "CN="); ok {
+			return name
+		}
+	}
+	return subject
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
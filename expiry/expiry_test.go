@@ -0,0 +1,171 @@
+package expiry
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"sslscanner/model"
+)
+
+var now = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// in devuelve el instante en milisegundos a days días (y una hora) de now
+func in(days int) int64 {
+	return now.Add(time.Duration(days)*24*time.Hour + time.Hour).UnixMilli()
+}
+
+func endpoint(ip string, leaf *model.Cert, chain ...model.ChainCert) model.Endpoint {
+	return model.Endpoint{
+		IPAddress:     ip,
+		StatusMessage: "Ready",
+		Details:       &model.EndpointDetails{Cert: leaf, Chain: &model.Chain{Certs: chain}},
+	}
+}
+
+func TestThresholdStatus(t *testing.T) {
+	for _, tc := range []struct {
+		days    int
+		expired bool
+		want    string
+	}{
+		{days: 90, want: StatusOK},
+		{days: 30, want: StatusOK},
+		{days: 29, want: StatusWarning},
+		{days: 7, want: StatusWarning},
+		{days: 6, want: StatusCritical},
+		{days: 0, want: StatusCritical},
+		{days: 0, expired: true, want: StatusExpired},
+	} {
+		if got := DefaultThresholds.status(tc.days, tc.expired); got != tc.want {
+			t.Errorf("status(%d, %t) = %s, se esperaba %s", tc.days, tc.expired, got, tc.want)
+		}
+	}
+}
+
+func TestThresholdsValidate(t *testing.T) {
+	if err := DefaultThresholds.Validate(); err != nil {
+		t.Errorf("DefaultThresholds: %v", err)
+	}
+	for _, thresholds := range []Thresholds{{Warning: -1}, {Warning: 7, Critical: 30}} {
+		if err := thresholds.Validate(); err == nil {
+			t.Errorf("Validate(%+v) no falló", thresholds)
+		}
+	}
+}
+
+func TestAddHost(t *testing.T) {
+	leaf := &model.Cert{
+		Subject:     "CN=example.com",
+		CommonNames: []string{"example.com"},
+		IssuerLabel: "R3",
+		NotAfter:    in(20),
+	}
+	intermediate := model.ChainCert{Subject: "CN=R3, O=Let's Encrypt", IssuerLabel: "ISRG Root X1", NotAfter: in(400)}
+	chainLeaf := model.ChainCert{Subject: leaf.Subject, Label: "example.com", NotAfter: leaf.NotAfter}
+
+	report := NewReport(DefaultThresholds, now)
+	report.AddHost("example.com", &model.Host{Endpoints: []model.Endpoint{
+		endpoint("192.0.2.1", leaf, chainLeaf, intermediate),
+		endpoint("192.0.2.2", leaf, chainLeaf, intermediate),
+	}})
+
+	if len(report.Certificates) != 2 || len(report.Errors) != 0 {
+		t.Fatalf("certificados = %+v, errores = %+v", report.Certificates, report.Errors)
+	}
+
+	got := report.Certificates[0]
+	if got.Position != PositionLeaf || got.Subject != "example.com" || got.Issuer != "R3" ||
+		got.DaysRemaining != 20 || got.Status != StatusWarning || fmt.Sprint(got.IPAddresses) != "[192.0.2.1 192.0.2.2]" {
+		t.Errorf("leaf = %+v", got)
+	}
+
+	got = report.Certificates[1]
+	if got.Position != PositionChain || got.Subject != "R3" || got.Status != StatusOK {
+		t.Errorf("intermedio = %+v", got)
+	}
+}
+
+func TestReportSortedByExpiration(t *testing.T) {
+	report := NewReport(DefaultThresholds, now)
+	for domain, days := range map[string]int{"a.example.com": 90, "b.example.com": 3, "c.example.com": -2, "d.example.com": 15} {
+		cert := &model.Cert{Subject: "CN=" + domain, NotAfter: in(days)}
+		report.AddHost(domain, &model.Host{Endpoints: []model.Endpoint{endpoint("192.0.2.1", cert)}})
+	}
+
+	var order []string
+	for _, cert := range report.Certificates {
+		order = append(order, cert.Domain+":"+cert.Status)
+	}
+	want := "[c.example.com:expired b.example.com:critical d.example.com:warning a.example.com:ok]"
+	if fmt.Sprint(order) != want {
+		t.Errorf("orden = %v, se esperaba %s", order, want)
+	}
+
+	if !report.HasCritical() {
+		t.Error("HasCritical debería ser verdadero con un certificado vencido")
+	}
+	if report.Count(StatusWarning) != 1 || report.Count(StatusOK) != 1 {
+		t.Errorf("Count: %d avisos, %d ok", report.Count(StatusWarning), report.Count(StatusOK))
+	}
+}
+
+func TestHasCriticalOnlyWithinWindow(t *testing.T) {
+	report := NewReport(Thresholds{Warning: 60, Critical: 10}, now)
+	report.AddHost("example.com", &model.Host{Endpoints: []model.Endpoint{
+		endpoint("192.0.2.1", &model.Cert{Subject: "CN=example.com", NotAfter: in(45)}),
+	}})
+
+	if cert := report.Certificates[0]; cert.Status != StatusWarning || report.HasCritical() {
+		t.Errorf("con umbrales 60/10, 45 días es un aviso: %+v", cert)
+	}
+}
+
+func TestAddHostWithoutLeaf(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		host *model.Host
+		want string
+	}{
+		{
+			name: "error del host",
+			host: &model.Host{Status: "ERROR", StatusMessage: "Unable to resolve domain name"},
+			want: "no se encontró el certificado del servidor: Unable to resolve domain name",
+		},
+		{
+			name: "endpoint sin terminar",
+			host: &model.Host{Endpoints: []model.Endpoint{{IPAddress: "192.0.2.1", StatusMessage: "Unable to connect to the server"}}},
+			want: "no se encontró el certificado del servidor: Unable to connect to the server",
+		},
+		{
+			name: "sin mensaje",
+			host: &model.Host{Endpoints: []model.Endpoint{endpoint("192.0.2.1", nil)}},
+			want: "no se encontró el certificado del servidor",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			report := NewReport(DefaultThresholds, now)
+			report.AddHost("example.com", tc.host)
+
+			if len(report.Errors) != 1 || report.Errors[0].Error != tc.want || report.Errors[0].Domain != "example.com" {
+				t.Errorf("errores = %+v, se esperaba %q", report.Errors, tc.want)
+			}
+		})
+	}
+}
+
+func TestCertLabel(t *testing.T) {
+	for _, tc := range []struct {
+		names   []string
+		subject string
+		want    string
+	}{
+		{[]string{"example.com"}, "CN=otro", "example.com"},
+		{[]string{""}, "O=Example, CN=example.com", "example.com"},
+		{nil, "O=Example", "O=Example"},
+	} {
+		if got := certLabel(tc.names, tc.subject); got != tc.want {
+			t.Errorf("certLabel(%v, %q) = %q, se esperaba %q", tc.names, tc.subject, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"sslscanner/exporter"
+	"sslscanner/service"
+)
+
+// runExporter publica métricas de Prometheus de una lista de dominios
+func runExporter(args []string) int {
+	fs := flag.NewFlagSet("exporter", flag.ContinueOnError)
+	addr := fs.String("listen", exporter.DefaultAddr, "Dirección en la que servir /metrics")
+	domainsFile := fs.String("file", "", "Archivo con un dominio por línea (\"-\" para leer de stdin)")
+	interval := fs.Duration("interval", exporter.DefaultInterval, "Cada cuánto se vuelven a analizar los dominios")
+	concurrency := fs.Int("concurrency", service.DefaultBatchConcurrency, "Máximo de análisis simultáneos")
+	cacheDir := fs.String("cache-dir", "", "Directorio de la caché local (por defecto $XDG_CACHE_HOME/sslscanner)")
+	noCache := fs.Bool("no-cache", false, "No leer ni escribir la caché local")
+	verbose := fs.Bool("verbose", false, "Mostrar el progreso de cada análisis")
+	engine := addEngineFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Publica el estado TLS de los dominios en formato Prometheus. Los análisis se
+actualizan en segundo plano cada --interval; consultar /metrics nunca inicia
+un análisis. Al arrancar se usan los resultados de la caché local más recientes
+que --interval.
+
+Uso:
+  sslscanner exporter [opciones] <dominio>...
+  sslscanner exporter [opciones] --file <archivo|->
+
+Opciones:
+`)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitCodeInvalidArgs
+	}
+
+	if *interval < time.Minute {
+		fmt.Fprintln(os.Stderr, "Error: --interval debe ser de al menos 1m")
+		return exitCodeInvalidArgs
+	}
+
+	domains, err := collectDomains(fs.Args(), *domainsFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+	if len(domains) == 0 {
+		fs.Usage()
+		return exitCodeInvalidArgs
+	}
+
+	scanner, err := engine.newScanner(engine.retryPolicy())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+	scanner.SetObserver(newProgressPrinter(os.Stderr, *verbose).observe)
+
+	// la caché dura lo mismo que el intervalo: tras un reinicio no se repiten
+	// análisis que todavía no tocaban
+	if err := configureCache(scanner, *engine.engine, *cacheDir, *interval, false, false, *noCache); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+
+	metrics := exporter.New(scanner, domains, *interval)
+	metrics.SetConcurrency(*concurrency)
+	metrics.SetLogOutput(os.Stdout)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	setupSignalHandler(cancel)
+
+	if err := metrics.ListenAndServe(ctx, *addr); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeAnalysisError
+	}
+
+	return exitCodeSuccess
+}
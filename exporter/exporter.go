@@ -0,0 +1,151 @@
+// Package exporter publica el estado TLS de una lista de dominios en formato
+// Prometheus. Los análisis se actualizan en segundo plano cada cierto tiempo;
+// una consulta a /metrics solo lee el último resultado y nunca inicia un análisis.
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"sslscanner/internal/httpserve"
+	"sslscanner/internal/logging"
+	"sslscanner/output"
+	"sslscanner/service"
+)
+
+const (
+	DefaultAddr     = "127.0.0.1:9219"
+	DefaultInterval = 6 * time.Hour
+)
+
+// Exporter mantiene el último análisis de cada dominio y lo sirve por HTTP
+type Exporter struct {
+	scanner     *service.Scanner
+	domains     []string
+	interval    time.Duration
+	opts        service.ScanOptions
+	concurrency int
+	log         logging.Logger
+
+	mu      sync.RWMutex
+	targets map[string]*output.MetricsTarget
+}
+
+func New(scanner *service.Scanner, domains []string, interval time.Duration) *Exporter {
+	targets := make(map[string]*output.MetricsTarget, len(domains))
+	for _, domain := range domains {
+		targets[domain] = &output.MetricsTarget{Domain: domain}
+	}
+
+	return &Exporter{
+		scanner:     scanner,
+		domains:     domains,
+		interval:    interval,
+		opts:        service.DefaultScanOptions(),
+		concurrency: service.DefaultBatchConcurrency,
+		targets:     targets,
+	}
+}
+
+func (e *Exporter) SetScanOptions(opts service.ScanOptions) {
+	e.opts = opts
+}
+
+// SetConcurrency limita los análisis simultáneos de cada actualización
+func (e *Exporter) SetConcurrency(concurrency int) {
+	e.concurrency = concurrency
+}
+
+// SetLogOutput redirige el registro de actividad (por defecto se descarta)
+func (e *Exporter) SetLogOutput(out io.Writer) {
+	e.log.SetOutput(out)
+}
+
+// ListenAndServe sirve /metrics en addr y actualiza los análisis hasta que se
+// cancela ctx; entonces cierra el servidor esperando las consultas en curso
+func (e *Exporter) ListenAndServe(ctx context.Context, addr string) error {
+	started := func(addr net.Addr) {
+		e.log.Printf("Publicando métricas en http://%s/metrics", addr)
+	}
+	return httpserve.ListenAndServe(ctx, addr, e.Handler(), started, e.Refresh)
+}
+
+// Refresh analiza todos los dominios ahora y luego cada intervalo, hasta que
+// se cancela ctx. La concurrencia respeta el límite de SSL Labs como en el
+// modo por lotes.
+func (e *Exporter) Refresh(ctx context.Context) {
+	for {
+		e.refreshOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.interval):
+		}
+	}
+}
+
+func (e *Exporter) refreshOnce(ctx context.Context) {
+	e.log.Printf("Actualizando %d dominios", len(e.domains))
+
+	_, err := e.scanner.RunBatch(ctx, e.domains, e.opts, e.concurrency, func(result service.BatchResult) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		target := e.targets[result.Domain]
+		target.LastAttempt = time.Now()
+		target.LastError = result.Err
+		if result.Err != nil {
+			target.Failures++
+			e.log.Printf("%s: %v", result.Domain, result.Err)
+			return
+		}
+		target.Host = result.Host
+	})
+	if err != nil && ctx.Err() == nil {
+		// sin el servicio disponible no se analizó ningún dominio
+		e.log.Printf("Error: %v", err)
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		for _, target := range e.targets {
+			target.LastAttempt = time.Now()
+			target.LastError = err
+			target.Failures++
+		}
+	}
+}
+
+// Handler sirve /metrics con el último resultado de cada dominio
+func (e *Exporter) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", e.serveMetrics)
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><body><h1>sslscanner exporter</h1><p><a href="/metrics">/metrics</a></p></body></html>`)
+	})
+	return mux
+}
+
+func (e *Exporter) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	e.mu.RLock()
+	targets := make([]output.MetricsTarget, 0, len(e.targets))
+	for _, target := range e.targets {
+		targets = append(targets, *target)
+	}
+	e.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := output.WritePrometheus(w, targets, time.Now()); err != nil {
+		e.log.Printf("Error al escribir las métricas: %v", err)
+	}
+}
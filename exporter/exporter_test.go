@@ -0,0 +1,87 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sslscanner/model"
+	"sslscanner/service"
+	"sslscanner/service/scantest"
+)
+
+func newTestExporter(backend *scantest.Backend, domains ...string) *Exporter {
+	scanner := service.NewScannerWithBackend(backend)
+	scanner.SetPolling(service.Polling{Initial: time.Millisecond, Running: time.Millisecond})
+	return New(scanner, domains, time.Hour)
+}
+
+func scrape(t *testing.T, exporter *Exporter) string {
+	t.Helper()
+
+	server := httptest.NewServer(exporter.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func assertSamples(t *testing.T, metrics string, samples ...string) {
+	t.Helper()
+	for _, sample := range samples {
+		if !strings.Contains(metrics, sample+"\n") {
+			t.Errorf("falta %q en:\n%s", sample, metrics)
+		}
+	}
+}
+
+func TestRefreshPublishesResults(t *testing.T) {
+	backend := scantest.New()
+	backend.Script("a.example.com", &model.Host{
+		Host:      "a.example.com",
+		Endpoints: []model.Endpoint{{IPAddress: "192.0.2.1", StatusMessage: "Ready", Grade: "A"}},
+	}, scantest.DNS(), scantest.Ready())
+	backend.Script("b.example.com", nil, scantest.Failed("Unable to resolve domain name"))
+
+	exporter := newTestExporter(backend, "a.example.com", "b.example.com")
+	exporter.refreshOnce(context.Background())
+
+	assertSamples(t, scrape(t, exporter),
+		`sslscanner_scan_success{host="a.example.com"} 1`,
+		`sslscanner_scan_success{host="b.example.com"} 0`,
+		`sslscanner_scan_failures_total{host="b.example.com"} 1`,
+		`sslscanner_grade_score{host="a.example.com",ip="192.0.2.1"} 8`,
+	)
+}
+
+func TestRefreshServiceUnavailable(t *testing.T) {
+	backend := scantest.New()
+	backend.SetInfo(model.Info{}, errors.New("servicio en mantenimiento"))
+
+	exporter := newTestExporter(backend, "a.example.com", "b.example.com")
+	exporter.refreshOnce(context.Background())
+	exporter.refreshOnce(context.Background())
+
+	assertSamples(t, scrape(t, exporter),
+		`sslscanner_scan_success{host="a.example.com"} 0`,
+		`sslscanner_scan_failures_total{host="a.example.com"} 2`,
+		`sslscanner_scan_failures_total{host="b.example.com"} 2`,
+	)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"sslscanner/cache"
+	"sslscanner/client"
+	"sslscanner/grading"
+	"sslscanner/model"
+)
+
+// errNotAHost indica un JSON válido que no es un model.Host ni una entrada de caché
+var errNotAHost = errors.New("el archivo no contiene un resultado de análisis")
+
+// endpointGrade es la calificación calculada de un endpoint junto a la que trae el resultado
+type endpointGrade struct {
+	Host          string          `json:"host"`
+	IPAddress     string          `json:"ipAddress"`
+	ReportedGrade string          `json:"reportedGrade,omitempty"`
+	Result        *grading.Result `json:"result,omitempty"`
+	Error         string          `json:"error,omitempty"`
+}
+
+// runGrade recalcula la calificación de resultados guardados y explica los topes
+func runGrade(args []string) int {
+	fs := flag.NewFlagSet("grade", flag.ContinueOnError)
+	format := fs.String("format", formatText, "Formato de salida: text o json")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Calcula la calificación de resultados guardados con la metodología de SSL Labs
+y muestra los puntajes y los motivos de cada tope.
+
+Uso:
+  sslscanner grade [opciones] <archivo.json>...
+
+Los archivos pueden ser entradas de la caché local o JSON de model.Host
+(la respuesta de /analyze de SSL Labs).
+
+Opciones:
+`)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitCodeInvalidArgs
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitCodeInvalidArgs
+	}
+	if *format != formatText && *format != formatJSON {
+		fmt.Fprintf(os.Stderr, "Error: formato de salida inválido: %s\n", *format)
+		return exitCodeInvalidArgs
+	}
+
+	grades := []endpointGrade{}
+	exitCode := exitCodeSuccess
+
+	for _, path := range fs.Args() {
+		host, err := loadHostFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exitCode = exitCodeAnalysisError
+			continue
+		}
+
+		for _, endpoint := range host.Endpoints {
+			entry := endpointGrade{Host: host.Host, IPAddress: endpoint.IPAddress, ReportedGrade: endpoint.Grade}
+			result, err := grading.Grade(endpoint.Details)
+			if err != nil {
+				entry.Error = err.Error()
+			}
+			entry.Result = result
+			grades = append(grades, entry)
+		}
+	}
+
+	if *format == formatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(grades); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCodeAnalysisError
+		}
+		return exitCode
+	}
+
+	printGrades(os.Stdout, grades)
+	return exitCode
+}
+
+func printGrades(w io.Writer, grades []endpointGrade) {
+	for _, g := range grades {
+		fmt.Fprintf(w, "%s (%s)\n", g.Host, g.IPAddress)
+
+		if g.Result == nil {
+			fmt.Fprintf(w, "  Sin calificación: %s\n\n", g.Error)
+			continue
+		}
+
+		r := g.Result
+		fmt.Fprintf(w, "  Calificación calculada: %s", r.Grade)
+		if r.Grade != r.GradeTrustIgnored {
+			fmt.Fprintf(w, " (%s ignorando la confianza del certificado)", r.GradeTrustIgnored)
+		}
+		fmt.Fprintln(w)
+		if g.ReportedGrade != "" {
+			fmt.Fprintf(w, "  Calificación informada: %s\n", g.ReportedGrade)
+		}
+		fmt.Fprintf(w, "  Puntaje: %d (protocolo %d, intercambio de claves %d, cifrado %d)\n",
+			r.Score, r.ProtocolScore, r.KeyExchangeScore, r.CipherScore)
+
+		if len(r.Caps) > 0 {
+			fmt.Fprintln(w, "  Topes:")
+			for _, c := range r.Caps {
+				fmt.Fprintf(w, "    %-2s %s [%s]\n", c.Grade, c.Reason, c.Rule)
+			}
+		}
+		if len(r.Warnings) > 0 {
+			fmt.Fprintln(w, "  Advertencias:")
+			for _, warning := range r.Warnings {
+				fmt.Fprintf(w, "    • %s\n", warning)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// loadHostFile lee un model.Host de una entrada de la caché local o de un JSON
+// con la respuesta de SSL Labs
+func loadHostFile(path string) (*model.Host, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo leer %s: %w", path, err)
+	}
+
+	// una entrada de caché tiene el host como objeto; un model.Host, como nombre
+	var probe struct {
+		Host json.RawMessage `json:"host"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("JSON inválido en %s: %w", path, err)
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(probe.Host), []byte("{")) {
+		var entry cache.Entry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("entrada de caché inválida en %s: %w", path, err)
+		}
+		if entry.Host != nil {
+			client.NormalizeHost(entry.Host)
+		}
+		return entry.Host, nil
+	}
+
+	var host model.Host
+	if err := json.Unmarshal(data, &host); err != nil {
+		return nil, fmt.Errorf("JSON inválido en %s: %w", path, err)
+	}
+	if host.Host == "" {
+		return nil, fmt.Errorf("%s: %w", path, errNotAHost)
+	}
+	// las respuestas v3/v4 traen los certificados en Host.Certs
+	client.NormalizeHost(&host)
+
+	return &host, nil
+}
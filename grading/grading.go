@@ -0,0 +1,269 @@
+// Package grading calcula la calificación de un endpoint a partir de
+// model.EndpointDetails siguiendo la metodología publicada en el SSL Labs
+// Rating Guide: puntajes de protocolo, intercambio de claves y cifrado, la
+// letra que corresponde al puntaje combinado y los topes que imponen las
+// vulnerabilidades y configuraciones débiles.
+package grading
+
+import (
+	"errors"
+	"strings"
+
+	"sslscanner/model"
+)
+
+// Pesos de cada categoría en el puntaje final
+const (
+	protocolWeight    = 30
+	keyExchangeWeight = 30
+	cipherWeight      = 40
+)
+
+// hstsMinMaxAge es el max-age mínimo (180 días) que exige A+
+const hstsMinMaxAge = 180 * 24 * 60 * 60
+
+// ErrNoDetails se devuelve cuando faltan los protocolos, las suites o la clave del certificado
+var ErrNoDetails = errors.New("el endpoint no tiene detalles suficientes para calificarlo")
+
+// Result es la calificación de un endpoint con el detalle de cómo se obtuvo
+type Result struct {
+	// Grade es la calificación final, incluidas T (sin confianza) y M (nombre no coincide)
+	Grade string `json:"grade"`
+	// GradeTrustIgnored es la calificación si el certificado fuera de confianza
+	GradeTrustIgnored string `json:"gradeTrustIgnored"`
+
+	Score            int `json:"score"`
+	ProtocolScore    int `json:"protocolScore"`
+	KeyExchangeScore int `json:"keyExchangeScore"`
+	CipherScore      int `json:"cipherScore"`
+
+	// Caps son los topes aplicados, en el orden en que se evaluaron
+	Caps []Cap `json:"caps"`
+	// Warnings son las observaciones que bajan una A a A- o impiden A+
+	Warnings []string `json:"warnings"`
+	// Exceptional indica una A+
+	Exceptional bool `json:"exceptional"`
+}
+
+// Cap es un tope sobre la calificación y su motivo
+type Cap struct {
+	Rule   string `json:"rule"`
+	Grade  string `json:"grade"`
+	Reason string `json:"reason"`
+}
+
+// Grade califica un endpoint
+func Grade(details *model.EndpointDetails) (*Result, error) {
+	if details == nil || details.Key == nil || len(details.Protocols) == 0 ||
+		details.Suites == nil || len(details.Suites.List) == 0 {
+		return nil, ErrNoDetails
+	}
+
+	result := &Result{
+		ProtocolScore:    protocolScore(details.Protocols),
+		KeyExchangeScore: keyExchangeScore(details),
+		CipherScore:      cipherScore(details.Suites.List),
+		Caps:             []Cap{},
+		Warnings:         []string{},
+	}
+
+	result.Score = (result.ProtocolScore*protocolWeight +
+		result.KeyExchangeScore*keyExchangeWeight +
+		result.CipherScore*cipherWeight) / 100
+
+	grade := scoreGrade(result.Score)
+	for _, c := range caps(details) {
+		result.Caps = append(result.Caps, c)
+		if model.GradeRank(c.Grade) < model.GradeRank(grade) {
+			grade = c.Grade
+		}
+	}
+
+	if grade == "A" {
+		result.Warnings = warnings(details)
+		switch {
+		case len(result.Warnings) > 0:
+			grade = "A-"
+		case hstsLongLived(details.HstsPolicy):
+			grade = "A+"
+			result.Exceptional = true
+		}
+	}
+
+	result.GradeTrustIgnored = grade
+	result.Grade = trustGrade(details.Cert, grade)
+
+	return result, nil
+}
+
+// Apply califica el endpoint y completa Grade, GradeTrustIgnored, HasWarnings
+// e IsExceptional. Los endpoints sin detalles quedan sin calificación.
+func Apply(endpoint *model.Endpoint) (*Result, error) {
+	result, err := Grade(endpoint.Details)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint.Grade = result.Grade
+	endpoint.GradeTrustIgnored = result.GradeTrustIgnored
+	endpoint.HasWarnings = len(result.Warnings) > 0
+	endpoint.IsExceptional = result.Exceptional
+
+	return result, nil
+}
+
+// protocolScore promedia el mejor y el peor protocolo soportado
+func protocolScore(protocols []model.Protocol) int {
+	best, worst := -1, 101
+	for _, proto := range protocols {
+		score := protocolPoints(proto)
+		if score > best {
+			best = score
+		}
+		if score < worst {
+			worst = score
+		}
+	}
+	return (best + worst) / 2
+}
+
+func protocolPoints(proto model.Protocol) int {
+	switch proto.Name + " " + proto.Version {
+	case "SSL 2.0":
+		return 0
+	case "SSL 3.0":
+		return 80
+	case "TLS 1.0":
+		return 90
+	case "TLS 1.1":
+		return 95
+	default:
+		return 100
+	}
+}
+
+// keyExchangeScore puntúa el intercambio más débil entre la clave del
+// certificado y los parámetros efímeros (DH y ECDH) de las suites
+func keyExchangeScore(details *model.EndpointDetails) int {
+	if details.Key.DebianFlaw {
+		return 0
+	}
+
+	weakest := details.Key.Strength
+
+	for _, suite := range details.Suites.List {
+		if anonymousSuite(suite) {
+			return 0
+		}
+		if exportSuite(suite) {
+			// las suites export negocian claves temporales de 512 bits
+			weakest = minStrength(weakest, 512)
+		}
+		if suite.DhStrength > 0 {
+			weakest = minStrength(weakest, suite.DhStrength)
+		}
+		if suite.EcdhStrength > 0 {
+			weakest = minStrength(weakest, suite.EcdhStrength)
+		}
+	}
+
+	switch {
+	case weakest <= 0:
+		return 0
+	case weakest < 512:
+		return 20
+	case weakest < 1024:
+		return 40
+	case weakest < 2048:
+		return 80
+	case weakest < 4096:
+		return 90
+	default:
+		return 100
+	}
+}
+
+// cipherScore promedia el cifrado más fuerte y el más débil
+func cipherScore(suites []model.Suite) int {
+	strongest, weakest := -1, 101
+	for _, suite := range suites {
+		score := cipherPoints(suite.CipherStrength)
+		if score > strongest {
+			strongest = score
+		}
+		if score < weakest {
+			weakest = score
+		}
+	}
+	return (strongest + weakest) / 2
+}
+
+func cipherPoints(bits int) int {
+	switch {
+	case bits <= 0:
+		return 0
+	case bits < 128:
+		return 20
+	case bits < 256:
+		return 80
+	default:
+		return 100
+	}
+}
+
+// scoreGrade convierte el puntaje combinado en letra
+func scoreGrade(score int) string {
+	switch {
+	case score >= 80:
+		return "A"
+	case score >= 65:
+		return "B"
+	case score >= 50:
+		return "C"
+	case score >= 35:
+		return "D"
+	case score >= 20:
+		return "E"
+	default:
+		return "F"
+	}
+}
+
+// Bits de model.Cert.Issues que hacen que el certificado no sea de confianza
+const (
+	issueHostnameMismatch = 8
+	untrustedIssues       = 1 | 2 | 4 | 16 | 64 | 128 | 256
+)
+
+// trustGrade reemplaza la calificación por M o T si el certificado no sirve
+func trustGrade(cert *model.Cert, grade string) string {
+	switch {
+	case cert == nil:
+		return grade
+	case cert.Issues&issueHostnameMismatch != 0:
+		return "M"
+	case cert.Issues&untrustedIssues != 0:
+		return "T"
+	default:
+		return grade
+	}
+}
+
+func hstsLongLived(hsts *model.HstsPolicy) bool {
+	return hsts != nil && hsts.Status == "present" && hsts.MaxAge >= hstsMinMaxAge
+}
+
+func minStrength(current, candidate int) int {
+	if current <= 0 || candidate < current {
+		return candidate
+	}
+	return current
+}
+
+func anonymousSuite(suite model.Suite) bool {
+	return strings.Contains(suite.Name, "_anon_")
+}
+
+func exportSuite(suite model.Suite) bool {
+	return strings.Contains(suite.Name, "_EXPORT")
+}
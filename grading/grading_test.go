@@ -0,0 +1,344 @@
+package grading
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"sslscanner/model"
+)
+
+// exceptionalDetails es una configuración que obtiene A+
+func exceptionalDetails() *model.EndpointDetails {
+	return &model.EndpointDetails{
+		Protocols: []model.Protocol{{Name: "TLS", Version: "1.2"}, {Name: "TLS", Version: "1.3"}},
+		Suites: &model.Suites{List: []model.Suite{
+			{Name: "TLS_AES_128_GCM_SHA256", CipherStrength: 128, EcdhStrength: 3072},
+			{Name: "TLS_AES_256_GCM_SHA384", CipherStrength: 256, EcdhStrength: 3072},
+			{Name: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", CipherStrength: 128, EcdhStrength: 3072},
+		}},
+		Key:            &model.Key{Alg: "RSA", Size: 2048, Strength: 2048},
+		Cert:           &model.Cert{SigAlg: "SHA256withRSA"},
+		ForwardSecrecy: 4,
+		FallbackScsv:   true,
+		HstsPolicy:     &model.HstsPolicy{Status: "present", MaxAge: 31536000},
+	}
+}
+
+func TestProtocolScore(t *testing.T) {
+	for _, tc := range []struct {
+		versions []string
+		want     int
+	}{
+		{[]string{"TLS 1.2", "TLS 1.3"}, 100},
+		{[]string{"TLS 1.1", "TLS 1.2"}, 97},
+		{[]string{"TLS 1.0", "TLS 1.1"}, 92},
+		{[]string{"SSL 3.0", "TLS 1.0"}, 85},
+		{[]string{"SSL 2.0", "TLS 1.2"}, 50},
+		{[]string{"SSL 2.0"}, 0},
+	} {
+		var protocols []model.Protocol
+		for _, version := range tc.versions {
+			name, number, _ := strings.Cut(version, " ")
+			protocols = append(protocols, model.Protocol{Name: name, Version: number})
+		}
+		if got := protocolScore(protocols); got != tc.want {
+			t.Errorf("protocolScore(%v) = %d, se esperaba %d", tc.versions, got, tc.want)
+		}
+	}
+}
+
+func TestKeyExchangeScore(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		key    model.Key
+		suites []model.Suite
+		want   int
+	}{
+		{name: "RSA 4096", key: model.Key{Strength: 4096}, want: 100},
+		{name: "RSA 2048", key: model.Key{Strength: 2048}, want: 90},
+		{name: "RSA 1024", key: model.Key{Strength: 1024}, want: 80},
+		{name: "RSA 512", key: model.Key{Strength: 512}, want: 40},
+		{name: "RSA 256", key: model.Key{Strength: 256}, want: 20},
+		{name: "sin clave", key: model.Key{}, want: 0},
+		{name: "Debian", key: model.Key{Strength: 2048, DebianFlaw: true}, want: 0},
+		{
+			name:   "DH más débil que la clave",
+			key:    model.Key{Strength: 4096},
+			suites: []model.Suite{{Name: "TLS_DHE_RSA_WITH_AES_128_GCM_SHA256", DhStrength: 1024}},
+			want:   80,
+		},
+		{
+			name:   "ECDH",
+			key:    model.Key{Strength: 4096},
+			suites: []model.Suite{{Name: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", EcdhStrength: 3072}},
+			want:   90,
+		},
+		{
+			name:   "export",
+			key:    model.Key{Strength: 2048},
+			suites: []model.Suite{{Name: "TLS_RSA_EXPORT_WITH_RC4_40_MD5"}},
+			want:   40,
+		},
+		{
+			name:   "anónima",
+			key:    model.Key{Strength: 2048},
+			suites: []model.Suite{{Name: "TLS_DH_anon_WITH_AES_128_CBC_SHA"}},
+			want:   0,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			details := &model.EndpointDetails{Key: &tc.key, Suites: &model.Suites{List: tc.suites}}
+			if got := keyExchangeScore(details); got != tc.want {
+				t.Errorf("keyExchangeScore = %d, se esperaba %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCipherScore(t *testing.T) {
+	for _, tc := range []struct {
+		bits []int
+		want int
+	}{
+		{[]int{256}, 100},
+		{[]int{128, 256}, 90},
+		{[]int{128}, 80},
+		{[]int{40, 128}, 50},
+		{[]int{112}, 20},
+		{[]int{0, 256}, 50},
+	} {
+		var suites []model.Suite
+		for _, bits := range tc.bits {
+			suites = append(suites, model.Suite{CipherStrength: bits})
+		}
+		if got := cipherScore(suites); got != tc.want {
+			t.Errorf("cipherScore(%v) = %d, se esperaba %d", tc.bits, got, tc.want)
+		}
+	}
+}
+
+func TestScoreGrade(t *testing.T) {
+	for score, want := range map[int]string{
+		100: "A", 80: "A",
+		79: "B", 65: "B",
+		64: "C", 50: "C",
+		49: "D", 35: "D",
+		34: "E", 20: "E",
+		19: "F", 0: "F",
+	} {
+		if got := scoreGrade(score); got != want {
+			t.Errorf("scoreGrade(%d) = %s, se esperaba %s", score, got, want)
+		}
+	}
+}
+
+func TestGradeCaps(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		modify func(*model.EndpointDetails)
+		rule   string
+		cap    string
+	}{
+		{
+			name: "SSL 2.0",
+			modify: func(d *model.EndpointDetails) {
+				d.Protocols = append(d.Protocols, model.Protocol{Name: "SSL", Version: "2.0"})
+			},
+			rule: RuleSSL2,
+			cap:  "F",
+		},
+		{name: "Heartbleed", modify: func(d *model.EndpointDetails) { d.Heartbleed = true }, rule: RuleHeartbleed, cap: "F"},
+		{name: "OpenSSL CCS", modify: func(d *model.EndpointDetails) { d.OpenSSLCcs = ccsVulnerable }, rule: RuleOpenSSLCcs, cap: "F"},
+		{name: "POODLE TLS", modify: func(d *model.EndpointDetails) { d.PoodleTLS = poodleTLSVulnerable }, rule: RulePoodleTLS, cap: "F"},
+		{name: "FREAK", modify: func(d *model.EndpointDetails) { d.Freak = true }, rule: RuleFreak, cap: "F"},
+		{name: "Logjam", modify: func(d *model.EndpointDetails) { d.Logjam = true }, rule: RuleLogjam, cap: "F"},
+		{name: "renegociación insegura", modify: func(d *model.EndpointDetails) { d.RenegSupport = 1 }, rule: RuleInsecureReneg, cap: "F"},
+		{
+			name: "suites export",
+			modify: func(d *model.EndpointDetails) {
+				d.Suites.List = append(d.Suites.List, model.Suite{Name: "TLS_RSA_EXPORT_WITH_RC4_40_MD5", CipherStrength: 40})
+			},
+			rule: RuleExportSuites,
+			cap:  "F",
+		},
+		{
+			name: "cifrado nulo",
+			modify: func(d *model.EndpointDetails) {
+				d.Suites.List = append(d.Suites.List, model.Suite{Name: "TLS_RSA_WITH_NULL_SHA256"})
+			},
+			rule: RuleInsecureCipher,
+			cap:  "F",
+		},
+		{name: "clave insegura", modify: func(d *model.EndpointDetails) { d.Key.Strength = 512 }, rule: RuleWeakKey, cap: "F"},
+		{name: "firma MD5", modify: func(d *model.EndpointDetails) { d.Cert.SigAlg = "MD5withRSA" }, rule: RuleMD5Signature, cap: "F"},
+		{name: "DH de 768 bits", modify: func(d *model.EndpointDetails) { d.Suites.List[2].DhStrength = 768 }, rule: RuleWeakDH, cap: "F"},
+		{name: "POODLE", modify: func(d *model.EndpointDetails) { d.Poodle = true }, rule: RulePoodle, cap: "C"},
+		{name: "RC4 con TLS moderno", modify: func(d *model.EndpointDetails) { d.RC4WithModern = true }, rule: RuleRC4, cap: "C"},
+		{
+			name:   "sin TLS 1.2",
+			modify: func(d *model.EndpointDetails) { d.Protocols = []model.Protocol{{Name: "TLS", Version: "1.0"}} },
+			rule:   RuleNoTLS12,
+			cap:    "C",
+		},
+		{
+			name: "3DES",
+			modify: func(d *model.EndpointDetails) {
+				d.Suites.List = append(d.Suites.List, model.Suite{Name: "TLS_RSA_WITH_3DES_EDE_CBC_SHA", CipherStrength: 112})
+			},
+			rule: RuleWeakCipher,
+			cap:  "C",
+		},
+		{
+			name: "SSL 3.0",
+			modify: func(d *model.EndpointDetails) {
+				d.Protocols = append(d.Protocols, model.Protocol{Name: "SSL", Version: "3.0"})
+			},
+			rule: RuleSSL3,
+			cap:  "B",
+		},
+		{name: "RC4", modify: func(d *model.EndpointDetails) { d.SupportsRC4 = true }, rule: RuleRC4, cap: "B"},
+		{
+			name: "TLS 1.0",
+			modify: func(d *model.EndpointDetails) {
+				d.Protocols = append(d.Protocols, model.Protocol{Name: "TLS", Version: "1.0"})
+			},
+			rule: RuleLegacyTLS,
+			cap:  "B",
+		},
+		{
+			name:   "posible OpenSSL CCS",
+			modify: func(d *model.EndpointDetails) { d.OpenSSLCcs = ccsPossiblyVulnerable },
+			rule:   RuleOpenSSLCcs,
+			cap:    "B",
+		},
+		{name: "DH de 1024 bits", modify: func(d *model.EndpointDetails) { d.Suites.List[2].DhStrength = 1024 }, rule: RuleWeakDH, cap: "B"},
+		{name: "clave de 1024 bits", modify: func(d *model.EndpointDetails) { d.Key.Strength = 1024 }, rule: RuleWeakKey, cap: "B"},
+		{name: "sin forward secrecy", modify: func(d *model.EndpointDetails) { d.ForwardSecrecy = 0 }, rule: RuleNoForwardSecrecy, cap: "B"},
+		{
+			name: "sin AEAD",
+			modify: func(d *model.EndpointDetails) {
+				d.Protocols = []model.Protocol{{Name: "TLS", Version: "1.2"}}
+				d.Suites.List = []model.Suite{
+					{Name: "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256", CipherStrength: 128},
+					{Name: "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA384", CipherStrength: 256},
+				}
+			},
+			rule: RuleNoAEAD,
+			cap:  "B",
+		},
+		{
+			name:   "cadena incompleta",
+			modify: func(d *model.EndpointDetails) { d.Chain = &model.Chain{Issues: chainIncomplete} },
+			rule:   RuleIncompleteChain,
+			cap:    "B",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			details := exceptionalDetails()
+			tc.modify(details)
+
+			result, err := Grade(details)
+			if err != nil {
+				t.Fatalf("Grade: %v", err)
+			}
+
+			found := false
+			for _, c := range result.Caps {
+				if c.Rule == tc.rule && c.Grade == tc.cap {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("topes = %+v, falta %s con %s", result.Caps, tc.rule, tc.cap)
+			}
+			if result.Grade != tc.cap || result.Exceptional {
+				t.Errorf("calificación = %s (puntaje %d), se esperaba %s", result.Grade, result.Score, tc.cap)
+			}
+		})
+	}
+}
+
+func TestGradeSpecial(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		modify       func(*model.EndpointDetails)
+		grade        string
+		trustIgnored string
+		warnings     int
+	}{
+		{name: "A+", modify: func(*model.EndpointDetails) {}, grade: "A+", trustIgnored: "A+"},
+		{name: "sin HSTS", modify: func(d *model.EndpointDetails) { d.HstsPolicy = nil }, grade: "A", trustIgnored: "A"},
+		{
+			name:         "HSTS corto",
+			modify:       func(d *model.EndpointDetails) { d.HstsPolicy.MaxAge = 86400 },
+			grade:        "A",
+			trustIgnored: "A",
+		},
+		{
+			name:         "forward secrecy parcial",
+			modify:       func(d *model.EndpointDetails) { d.ForwardSecrecy = 1 },
+			grade:        "A-",
+			trustIgnored: "A-",
+			warnings:     1,
+		},
+		{
+			name:         "sin TLS_FALLBACK_SCSV",
+			modify:       func(d *model.EndpointDetails) { d.FallbackScsv = false },
+			grade:        "A-",
+			trustIgnored: "A-",
+			warnings:     1,
+		},
+		{name: "sin confianza", modify: func(d *model.EndpointDetails) { d.Cert.Issues = 1 }, grade: "T", trustIgnored: "A+"},
+		{name: "nombre no coincide", modify: func(d *model.EndpointDetails) { d.Cert.Issues = issueHostnameMismatch }, grade: "M", trustIgnored: "A+"},
+		{
+			name:         "sin confianza y con tope",
+			modify:       func(d *model.EndpointDetails) { d.Cert.Issues = 1; d.Heartbleed = true },
+			grade:        "T",
+			trustIgnored: "F",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			details := exceptionalDetails()
+			tc.modify(details)
+
+			result, err := Grade(details)
+			if err != nil {
+				t.Fatalf("Grade: %v", err)
+			}
+			if result.Grade != tc.grade || result.GradeTrustIgnored != tc.trustIgnored || len(result.Warnings) != tc.warnings {
+				t.Errorf("resultado = %s / %s con avisos %v, se esperaba %s / %s",
+					result.Grade, result.GradeTrustIgnored, result.Warnings, tc.grade, tc.trustIgnored)
+			}
+			if result.Exceptional != (result.GradeTrustIgnored == "A+") {
+				t.Errorf("Exceptional = %t con %s", result.Exceptional, result.GradeTrustIgnored)
+			}
+		})
+	}
+}
+
+func TestGradeWithoutDetails(t *testing.T) {
+	for name, details := range map[string]*model.EndpointDetails{
+		"nil":            nil,
+		"sin clave":      {Protocols: exceptionalDetails().Protocols, Suites: exceptionalDetails().Suites},
+		"sin protocolos": {Key: &model.Key{Strength: 2048}, Suites: exceptionalDetails().Suites},
+		"sin suites":     {Key: &model.Key{Strength: 2048}, Protocols: exceptionalDetails().Protocols},
+	} {
+		if _, err := Grade(details); !errors.Is(err, ErrNoDetails) {
+			t.Errorf("%s: err = %v, se esperaba ErrNoDetails", name, err)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	details := exceptionalDetails()
+	details.ForwardSecrecy = 1
+	endpoint := &model.Endpoint{IPAddress: "192.0.2.1", Details: details}
+
+	if _, err := Apply(endpoint); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if endpoint.Grade != "A-" || endpoint.GradeTrustIgnored != "A-" || !endpoint.HasWarnings || endpoint.IsExceptional {
+		t.Errorf("endpoint = %+v", endpoint)
+	}
+}
@@ -0,0 +1,173 @@
+package grading
+
+import (
+	"strings"
+
+	"sslscanner/model"
+)
+
+// Identificadores de los topes
+const (
+	RuleSSL2             = "ssl2"
+	RuleSSL3             = "ssl3"
+	RulePoodle           = "poodle"
+	RulePoodleTLS        = "poodle-tls"
+	RuleHeartbleed       = "heartbleed"
+	RuleOpenSSLCcs       = "openssl-ccs"
+	RuleFreak            = "freak"
+	RuleLogjam           = "logjam"
+	RuleInsecureReneg    = "insecure-renegotiation"
+	RuleExportSuites     = "export-suites"
+	RuleInsecureCipher   = "insecure-cipher"
+	RuleRC4              = "rc4"
+	RuleWeakCipher       = "weak-cipher"
+	RuleNoTLS12          = "no-tls12"
+	RuleLegacyTLS        = "legacy-tls"
+	RuleWeakDH           = "weak-dh"
+	RuleWeakKey          = "weak-key"
+	RuleMD5Signature     = "md5-signature"
+	RuleNoForwardSecrecy = "no-forward-secrecy"
+	RuleNoAEAD           = "no-aead"
+	RuleIncompleteChain  = "incomplete-chain"
+)
+
+// Bits de model.Chain.Issues
+const chainIncomplete = 2
+
+// Valores de OpenSSLCcs y PoodleTLS que indican vulnerabilidad
+const (
+	ccsPossiblyVulnerable = 2
+	ccsVulnerable         = 3
+	poodleTLSVulnerable   = 2
+)
+
+// caps evalúa las reglas del Rating Guide que limitan la calificación máxima
+func caps(details *model.EndpointDetails) []Cap {
+	result := []Cap{}
+	add := func(rule, grade, reason string) {
+		result = append(result, Cap{Rule: rule, Grade: grade, Reason: reason})
+	}
+
+	versions := protocolVersions(details.Protocols)
+	suites := details.Suites.List
+
+	// vulnerabilidades y protocolos rotos: F
+	if versions["SSL 2.0"] {
+		add(RuleSSL2, "F", "soporta SSL 2.0")
+	}
+	if details.Heartbleed {
+		add(RuleHeartbleed, "F", "vulnerable a Heartbleed")
+	}
+	if details.OpenSSLCcs == ccsVulnerable {
+		add(RuleOpenSSLCcs, "F", "vulnerable a OpenSSL CCS (CVE-2014-0224)")
+	}
+	if details.PoodleTLS == poodleTLSVulnerable {
+		add(RulePoodleTLS, "F", "vulnerable a POODLE sobre TLS")
+	}
+	if details.Freak {
+		add(RuleFreak, "F", "vulnerable a FREAK")
+	}
+	if details.Logjam {
+		add(RuleLogjam, "F", "vulnerable a Logjam")
+	}
+	if details.RenegSupport&1 != 0 {
+		add(RuleInsecureReneg, "F", "permite renegociación insegura iniciada por el cliente")
+	}
+	if hasSuite(suites, exportSuite) {
+		add(RuleExportSuites, "F", "soporta suites export")
+	}
+	if hasSuite(suites, func(s model.Suite) bool { return s.CipherStrength < 112 || anonymousSuite(s) }) {
+		add(RuleInsecureCipher, "F", "soporta suites inseguras (NULL, anónimas o de menos de 112 bits)")
+	}
+	if details.Key.DebianFlaw || details.Key.Strength < 1024 {
+		add(RuleWeakKey, "F", "clave del certificado insegura (menos de 1024 bits o Debian OpenSSL)")
+	}
+	if details.Cert != nil && strings.HasPrefix(details.Cert.SigAlg, "MD5") {
+		add(RuleMD5Signature, "F", "certificado firmado con MD5")
+	}
+	if hasSuite(suites, func(s model.Suite) bool { return s.DhStrength > 0 && s.DhStrength < 1024 }) {
+		add(RuleWeakDH, "F", "parámetros DH de menos de 1024 bits")
+	}
+
+	// configuraciones obsoletas: C
+	if details.Poodle {
+		add(RulePoodle, "C", "vulnerable a POODLE (SSL 3.0 con CBC)")
+	}
+	if details.RC4WithModern || details.RC4Only {
+		add(RuleRC4, "C", "usa RC4 con TLS 1.1 o superior")
+	}
+	if !versions["TLS 1.2"] && !versions["TLS 1.3"] {
+		add(RuleNoTLS12, "C", "no soporta TLS 1.2 ni TLS 1.3")
+	}
+	if hasSuite(suites, func(s model.Suite) bool { return s.CipherStrength >= 112 && s.CipherStrength < 128 }) {
+		add(RuleWeakCipher, "C", "soporta cifrados de menos de 128 bits (3DES, vulnerable a Sweet32)")
+	}
+
+	// buenas prácticas: B
+	if versions["SSL 3.0"] {
+		add(RuleSSL3, "B", "soporta SSL 3.0")
+	}
+	if details.SupportsRC4 && !details.RC4WithModern && !details.RC4Only {
+		add(RuleRC4, "B", "soporta RC4")
+	}
+	if versions["TLS 1.0"] || versions["TLS 1.1"] {
+		add(RuleLegacyTLS, "B", "soporta TLS 1.0 o TLS 1.1")
+	}
+	if details.OpenSSLCcs == ccsPossiblyVulnerable {
+		add(RuleOpenSSLCcs, "B", "posiblemente vulnerable a OpenSSL CCS")
+	}
+	if hasSuite(suites, func(s model.Suite) bool { return s.DhStrength >= 1024 && s.DhStrength < 2048 }) {
+		add(RuleWeakDH, "B", "parámetros DH de menos de 2048 bits")
+	}
+	if details.Key.Strength >= 1024 && details.Key.Strength < 2048 {
+		add(RuleWeakKey, "B", "clave del certificado de menos de 2048 bits")
+	}
+	if details.ForwardSecrecy == 0 {
+		add(RuleNoForwardSecrecy, "B", "no soporta forward secrecy")
+	}
+	if (versions["TLS 1.2"] || versions["TLS 1.3"]) && !hasSuite(suites, aeadSuite) {
+		add(RuleNoAEAD, "B", "no soporta suites AEAD")
+	}
+	if details.Chain != nil && details.Chain.Issues&chainIncomplete != 0 {
+		add(RuleIncompleteChain, "B", "la cadena de certificados está incompleta")
+	}
+
+	return result
+}
+
+// warnings son las observaciones que dejan una A en A-
+func warnings(details *model.EndpointDetails) []string {
+	result := []string{}
+
+	if details.ForwardSecrecy < 2 {
+		result = append(result, "forward secrecy no se negocia con los clientes modernos")
+	}
+	if len(details.Protocols) > 1 && !details.FallbackScsv {
+		result = append(result, "no soporta TLS_FALLBACK_SCSV para prevenir ataques de downgrade")
+	}
+
+	return result
+}
+
+func protocolVersions(protocols []model.Protocol) map[string]bool {
+	versions := make(map[string]bool, len(protocols))
+	for _, proto := range protocols {
+		versions[proto.Name+" "+proto.Version] = true
+	}
+	return versions
+}
+
+func hasSuite(suites []model.Suite, match func(model.Suite) bool) bool {
+	for _, suite := range suites {
+		if match(suite) {
+			return true
+		}
+	}
+	return false
+}
+
+// aeadSuite reconoce GCM, CCM y ChaCha20-Poly1305, además de todas las suites de TLS 1.3
+func aeadSuite(suite model.Suite) bool {
+	return strings.Contains(suite.Name, "_GCM_") || strings.Contains(suite.Name, "_CCM") ||
+		strings.Contains(suite.Name, "CHACHA20_POLY1305") || strings.HasPrefix(suite.Name, "TLS_AES_")
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sslscanner/history"
+	"sslscanner/model"
+)
+
+// historyFlags configuran el historial de análisis; los comparten el análisis
+// (que agrega registros) y el subcomando history (que los consulta y poda)
+type historyFlags struct {
+	dir        *string
+	maxAge     *time.Duration
+	maxEntries *int
+}
+
+func addHistoryFlags(fs *flag.FlagSet) *historyFlags {
+	return &historyFlags{
+		dir:        fs.String("history-dir", os.Getenv("SSLSCANNER_HISTORY_DIR"), "Directorio del historial (por defecto $XDG_DATA_HOME/sslscanner/history; env SSLSCANNER_HISTORY_DIR)"),
+		maxAge:     fs.Duration("history-max-age", history.DefaultMaxAge, "Conservar en el historial los análisis más recientes que esta antigüedad (0 = sin límite)"),
+		maxEntries: fs.Int("history-max-entries", 0, "Conservar en el historial solo los N análisis más recientes de cada dominio (0 = sin límite)"),
+	}
+}
+
+func (f *historyFlags) retention() (history.Retention, error) {
+	if *f.maxAge < 0 || *f.maxEntries < 0 {
+		return history.Retention{}, fmt.Errorf("la retención del historial no puede ser negativa")
+	}
+	return history.Retention{MaxAge: *f.maxAge, MaxEntries: *f.maxEntries}, nil
+}
+
+// open abre el historial del motor; los análisis del motor local se guardan
+// aparte de los de SSL Labs, como en la caché
+func (f *historyFlags) open(engine string) (*history.Store, error) {
+	retention, err := f.retention()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := *f.dir
+	if dir == "" {
+		dir, err = history.DefaultDir()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if engine == engineLocal {
+		dir = filepath.Join(dir, engineLocal)
+	}
+
+	return history.New(dir, retention), nil
+}
+
+// historyCommands son las acciones del subcomando history
+var historyCommands = map[string]func(store *history.Store, fs *flag.FlagSet, options historyOptions) int{
+	"list":     historyList,
+	"timeline": historyTimeline,
+	"export":   historyExport,
+	"prune":    historyPrune,
+}
+
+type historyOptions struct {
+	format    string
+	output    string
+	retention history.Retention
+}
+
+// runHistory consulta el historial de análisis
+func runHistory(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	format := fs.String("format", "", "Formato de export: json, jsonl o csv (por defecto json)")
+	outputPath := fs.String("output", "", "Archivo de destino del export (por defecto stdout)")
+	engine := fs.String("engine", envOrDefault("SSLSCANNER_ENGINE", engineSSLLabs), "Historial del motor ssllabs o local (env SSLSCANNER_ENGINE)")
+	flags := addHistoryFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Consulta el historial de análisis terminados.
+
+Uso:
+  sslscanner history list [dominio]        análisis de un dominio (sin dominio: dominios con historial)
+  sslscanner history timeline <dominio>    evolución de la calificación
+  sslscanner history export <dominio>      serie completa en JSON, JSONL o CSV
+  sslscanner history prune [dominio]       aplica la retención (sin dominio: a todos)
+
+Opciones:
+`)
+		fs.PrintDefaults()
+	}
+
+	if len(args) == 0 {
+		fs.Usage()
+		return exitCodeInvalidArgs
+	}
+
+	command, ok := historyCommands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: acción de history desconocida: %s\n", args[0])
+		fs.Usage()
+		return exitCodeInvalidArgs
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitCodeInvalidArgs
+	}
+	if *engine != engineSSLLabs && *engine != engineLocal {
+		fmt.Fprintf(os.Stderr, "Error: motor de análisis inválido: %s\n", *engine)
+		return exitCodeInvalidArgs
+	}
+
+	store, err := flags.open(*engine)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+	retention, _ := flags.retention()
+
+	return command(store, fs, historyOptions{format: *format, output: *outputPath, retention: retention})
+}
+
+func historyList(store *history.Store, fs *flag.FlagSet, _ historyOptions) int {
+	if fs.NArg() == 0 {
+		return historyDomains(store)
+	}
+
+	records, code := historyRecords(store, fs)
+	if records == nil {
+		return code
+	}
+
+	fmt.Printf("%s  %s  %s  %s\n", padText("Fecha", 16), padText("Calificación", 14), padText("Vence el certificado", 20), "Motor")
+	for _, record := range records {
+		fmt.Printf("%s  %s  %s  %s\n",
+			padText(record.ScannedAt.Local().Format("2006-01-02 15:04"), 16),
+			padText(hostGrades(record.Host), 14),
+			padText(certExpiry(record.Host), 20),
+			record.Host.EngineVersion)
+	}
+
+	return exitCodeSuccess
+}
+
+func historyDomains(store *history.Store) int {
+	domains, err := store.Domains()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeAnalysisError
+	}
+
+	if len(domains) == 0 {
+		fmt.Printf("No hay análisis en el historial (%s)\n", store.Dir())
+		return exitCodeSuccess
+	}
+
+	for _, domain := range domains {
+		records, err := store.List(domain)
+		if err != nil || len(records) == 0 {
+			continue
+		}
+		last := records[len(records)-1]
+		fmt.Printf("%-40s %4d análisis, último %s (%s)\n", domain, len(records),
+			last.ScannedAt.Local().Format("2006-01-02 15:04"), hostGrades(last.Host))
+	}
+
+	return exitCodeSuccess
+}
+
+// historyTimeline muestra la calificación en cada análisis y marca las subidas y bajadas
+func historyTimeline(store *history.Store, fs *flag.FlagSet, _ historyOptions) int {
+	records, code := historyRecords(store, fs)
+	if records == nil {
+		return code
+	}
+
+	fmt.Printf("Evolución de %s\n\n", records[0].Domain)
+
+	previous := ""
+	for _, record := range records {
+		grade := model.WorstGrade(record.Host)
+
+		trend := "="
+		switch {
+		case previous == "":
+			trend = " "
+		case model.GradeRank(grade) > model.GradeRank(previous):
+			trend = "↑"
+		case model.GradeRank(grade) < model.GradeRank(previous):
+			trend = "↓"
+		}
+		previous = grade
+
+		fmt.Printf("%s  %s %s  %s\n", record.ScannedAt.Local().Format("2006-01-02 15:04"),
+			trend, padText(model.DisplayGrade(grade), 3), endpointGrades(record.Host))
+	}
+
+	return exitCodeSuccess
+}
+
+func historyExport(store *history.Store, fs *flag.FlagSet, options historyOptions) int {
+	records, code := historyRecords(store, fs)
+	if records == nil {
+		return code
+	}
+
+	var w io.Writer = os.Stdout
+	if options.output != "" {
+		file, err := os.Create(options.output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: no se pudo crear %s: %v\n", options.output, err)
+			return exitCodeInvalidArgs
+		}
+		defer file.Close()
+		w = file
+	}
+
+	var err error
+	switch options.format {
+	case "", formatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(records)
+	case "jsonl":
+		encoder := json.NewEncoder(w)
+		for _, record := range records {
+			if err = encoder.Encode(record); err != nil {
+				break
+			}
+		}
+	case "csv":
+		err = writeHistoryCSV(w, records)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: formato de export inválido: %s\n", options.format)
+		return exitCodeInvalidArgs
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: falló al exportar el historial: %v\n", err)
+		return exitCodeAnalysisError
+	}
+	return exitCodeSuccess
+}
+
+// writeHistoryCSV escribe una fila por endpoint y análisis
+func writeHistoryCSV(w io.Writer, records []history.Record) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"scannedAt", "domain", "ipAddress", "grade", "gradeTrustIgnored", "certNotAfter", "engineVersion", "criteriaVersion"})
+
+	for _, record := range records {
+		for _, endpoint := range record.Host.Endpoints {
+			notAfter := ""
+			if endpoint.Details != nil && endpoint.Details.Cert != nil && endpoint.Details.Cert.NotAfter > 0 {
+				notAfter = time.UnixMilli(endpoint.Details.Cert.NotAfter).UTC().Format(time.RFC3339)
+			}
+			out.Write([]string{
+				record.ScannedAt.Format(time.RFC3339),
+				record.Domain,
+				endpoint.IPAddress,
+				endpoint.Grade,
+				endpoint.GradeTrustIgnored,
+				notAfter,
+				record.Host.EngineVersion,
+				record.Host.CriteriaVersion,
+			})
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+func historyPrune(store *history.Store, fs *flag.FlagSet, options historyOptions) int {
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return exitCodeInvalidArgs
+	}
+
+	removed, err := store.Prune(fs.Arg(0), options.retention)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeAnalysisError
+	}
+
+	fmt.Printf("Registros eliminados: %d\n", removed)
+	return exitCodeSuccess
+}
+
+// historyRecords lee el historial del dominio pedido; devuelve nil y el código
+// de salida si no hay registros
+func historyRecords(store *history.Store, fs *flag.FlagSet) ([]history.Record, int) {
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return nil, exitCodeInvalidArgs
+	}
+
+	records, err := store.List(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return nil, exitCodeAnalysisError
+	}
+	if len(records) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no hay análisis de %s en el historial (%s)\n", fs.Arg(0), store.Dir())
+		return nil, exitCodeAnalysisError
+	}
+
+	return records, exitCodeSuccess
+}
+
+// hostGrades junta las calificaciones de los endpoints, p. ej. "A+/A"
+func hostGrades(host *model.Host) string {
+	grades := []string{}
+	for _, endpoint := range host.Endpoints {
+		grades = append(grades, model.DisplayGrade(endpoint.Grade))
+	}
+	if len(grades) == 0 {
+		return "-"
+	}
+	return strings.Join(grades, "/")
+}
+
+// endpointGrades detalla la calificación de cada IP
+func endpointGrades(host *model.Host) string {
+	parts := []string{}
+	for _, endpoint := range host.Endpoints {
+		parts = append(parts, model.DisplayGrade(endpoint.Grade)+" "+endpoint.IPAddress)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// certExpiry devuelve el vencimiento más próximo entre los certificados del host
+func certExpiry(host *model.Host) string {
+	var earliest int64
+	for _, endpoint := range host.Endpoints {
+		if endpoint.Details == nil || endpoint.Details.Cert == nil || endpoint.Details.Cert.NotAfter == 0 {
+			continue
+		}
+		if earliest == 0 || endpoint.Details.Cert.NotAfter < earliest {
+			earliest = endpoint.Details.Cert.NotAfter
+		}
+	}
+	if earliest == 0 {
+		return "-"
+	}
+	return time.UnixMilli(earliest).Local().Format("2006-01-02")
+}
+
+// padText completa con espacios según la cantidad de caracteres visibles
+func padText(text string, width int) string {
+	return text + strings.Repeat(" ", max(0, width-len([]rune(text))))
+}
@@ -0,0 +1,353 @@
+// Package history guarda cada análisis terminado en un archivo JSONL por
+// dominio (solo se agregan líneas), para consultar la evolución de un host a
+// lo largo del tiempo. A diferencia de la caché, nunca reemplaza resultados.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sslscanner/internal/fsutil"
+	"sslscanner/model"
+)
+
+const (
+	// DefaultMaxAge es la retención por defecto de los registros
+	DefaultMaxAge = 365 * 24 * time.Hour
+
+	// dirName es el subdirectorio dentro del directorio de datos del usuario
+	dirName = "sslscanner"
+	// fileExt es la extensión de los archivos de historial
+	fileExt = ".jsonl"
+)
+
+// maxLineSize acota una línea del archivo; un model.Host con detalles ocupa
+// decenas de KB
+const maxLineSize = 16 << 20
+
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9.\-]`)
+
+// Record es un análisis terminado en el historial
+type Record struct {
+	Domain    string      `json:"domain"`
+	ScannedAt time.Time   `json:"scannedAt"`
+	Host      *model.Host `json:"host"`
+}
+
+// Retention define qué registros se conservan al podar. Cero en un campo
+// significa sin límite.
+type Retention struct {
+	// MaxAge descarta los registros más viejos que esta antigüedad
+	MaxAge time.Duration
+	// MaxEntries conserva solo los N registros más recientes de cada dominio
+	MaxEntries int
+}
+
+// DefaultRetention conserva un año de análisis
+var DefaultRetention = Retention{MaxAge: DefaultMaxAge}
+
+// Store guarda el historial en un directorio, un archivo JSONL por dominio
+type Store struct {
+	dir       string
+	retention Retention
+
+	mu sync.Mutex
+}
+
+// DefaultDir devuelve el directorio del historial dentro de DataDir
+func DefaultDir() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history"), nil
+}
+
+// DataDir devuelve el directorio de datos de sslscanner
+// ($XDG_DATA_HOME/sslscanner, por defecto ~/.local/share en Linux)
+func DataDir() (string, error) {
+	base := os.Getenv("XDG_DATA_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("no se pudo determinar el directorio de datos: %w", err)
+		}
+
+		switch runtime.GOOS {
+		case "windows":
+			base = os.Getenv("LocalAppData")
+		case "darwin":
+			base = filepath.Join(home, "Library", "Application Support")
+		}
+		if base == "" {
+			base = filepath.Join(home, ".local", "share")
+		}
+	}
+
+	return filepath.Join(base, dirName), nil
+}
+
+func New(dir string, retention Retention) *Store {
+	return &Store{
+		dir:       dir,
+		retention: retention,
+	}
+}
+
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Append agrega un análisis terminado y aplica la retención al archivo del dominio
+func (s *Store) Append(domain string, host *model.Host) error {
+	if host == nil || host.Status != "READY" {
+		return fmt.Errorf("solo se guardan en el historial análisis terminados")
+	}
+
+	scannedAt := time.Now().UTC()
+	if host.TestTime > 0 {
+		scannedAt = time.UnixMilli(host.TestTime).UTC()
+	}
+
+	record := Record{Domain: strings.ToLower(domain), ScannedAt: scannedAt, Host: host}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("falló al codificar el registro del historial: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("falló al crear el directorio del historial: %w", err)
+	}
+
+	// SSL Labs devuelve el mismo reporte mientras no haya uno nuevo; se guarda una sola vez
+	existing, err := s.read(record.Domain)
+	if err != nil {
+		return err
+	}
+	for _, previous := range existing {
+		if previous.ScannedAt.Equal(record.ScannedAt) {
+			return nil
+		}
+	}
+
+	file, err := os.OpenFile(s.path(record.Domain), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("falló al abrir el historial: %w", err)
+	}
+
+	// una sola escritura por registro para que las líneas no se intercalen
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("falló al escribir el historial: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("falló al escribir el historial: %w", err)
+	}
+
+	if _, err := s.prune(record.Domain, s.retention); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// List devuelve los análisis de un dominio ordenados del más viejo al más nuevo.
+// Las líneas dañadas (p. ej. una escritura interrumpida) se ignoran.
+func (s *Store) List(domain string) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read(strings.ToLower(domain))
+}
+
+// Domains devuelve los dominios con historial, en orden alfabético
+func (s *Store) Domains() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("falló al leer el directorio del historial: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fileExt) {
+			continue
+		}
+
+		// el nombre del archivo está saneado; los dominios reales están en los
+		// registros, y un archivo puede tener más de uno
+		s.mu.Lock()
+		records, err := readFile(filepath.Join(s.dir, entry.Name()), "")
+		s.mu.Unlock()
+		if err != nil {
+			continue
+		}
+		for _, record := range records {
+			seen[record.Domain] = true
+		}
+	}
+
+	domains := make([]string, 0, len(seen))
+	for domain := range seen {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return domains, nil
+}
+
+// Prune aplica retention al dominio, o a todos si domain está vacío, y
+// devuelve cuántos registros se eliminaron
+func (s *Store) Prune(domain string, retention Retention) (int, error) {
+	domains := []string{domain}
+	if domain == "" {
+		all, err := s.Domains()
+		if err != nil {
+			return 0, err
+		}
+		domains = all
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for _, d := range domains {
+		n, err := s.prune(strings.ToLower(d), retention)
+		if err != nil {
+			return removed, err
+		}
+		removed += n
+	}
+
+	return removed, nil
+}
+
+// prune reescribe el archivo sin los registros fuera de la retención. Los
+// registros de otros dominios que comparten el archivo (colisiones de nombres)
+// se conservan. Debe llamarse con s.mu tomado.
+func (s *Store) prune(domain string, retention Retention) (int, error) {
+	if retention.MaxAge <= 0 && retention.MaxEntries <= 0 {
+		return 0, nil
+	}
+
+	all, err := readFile(s.path(domain), "")
+	if err != nil {
+		return 0, err
+	}
+
+	var records, others []Record
+	for _, record := range all {
+		if record.Domain == domain {
+			records = append(records, record)
+		} else {
+			others = append(others, record)
+		}
+	}
+
+	kept := records
+	if retention.MaxAge > 0 {
+		cutoff := time.Now().Add(-retention.MaxAge)
+		kept = kept[:0:0]
+		for _, record := range records {
+			if !record.ScannedAt.Before(cutoff) {
+				kept = append(kept, record)
+			}
+		}
+	}
+	if retention.MaxEntries > 0 && len(kept) > retention.MaxEntries {
+		kept = kept[len(kept)-retention.MaxEntries:]
+	}
+
+	removed := len(records) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	remaining := append(others, kept...)
+	if len(remaining) == 0 {
+		if err := os.Remove(s.path(domain)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, fmt.Errorf("falló al podar el historial: %w", err)
+		}
+		return removed, nil
+	}
+	sort.SliceStable(remaining, func(i, j int) bool {
+		return remaining[i].ScannedAt.Before(remaining[j].ScannedAt)
+	})
+
+	var buf bytes.Buffer
+	for _, record := range remaining {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return 0, fmt.Errorf("falló al codificar el registro del historial: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	if err := fsutil.WriteFileAtomic(s.path(domain), buf.Bytes(), 0o644); err != nil {
+		return 0, fmt.Errorf("falló al podar el historial: %w", err)
+	}
+
+	return removed, nil
+}
+
+func (s *Store) read(domain string) ([]Record, error) {
+	return readFile(s.path(domain), domain)
+}
+
+// readFile lee los registros de un archivo; si domain no está vacío descarta
+// los de otros dominios (colisiones de nombres de archivo)
+func readFile(path, domain string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []Record{}, nil
+		}
+		return nil, fmt.Errorf("falló al leer el historial: %w", err)
+	}
+	defer file.Close()
+
+	records := []Record{}
+	lines := bufio.NewScanner(file)
+	lines.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	for lines.Scan() {
+		var record Record
+		if err := json.Unmarshal(lines.Bytes(), &record); err != nil || record.Host == nil {
+			continue
+		}
+		if domain != "" && record.Domain != domain {
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := lines.Err(); err != nil {
+		return nil, fmt.Errorf("falló al leer el historial: %w", err)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].ScannedAt.Before(records[j].ScannedAt)
+	})
+
+	return records, nil
+}
+
+func (s *Store) path(domain string) string {
+	return filepath.Join(s.dir, unsafeChars.ReplaceAllString(domain, "_")+fileExt)
+}
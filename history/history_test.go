@@ -0,0 +1,129 @@
+package history
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"sslscanner/model"
+)
+
+// readyHost es un análisis terminado hace age
+func readyHost(age time.Duration) *model.Host {
+	return &model.Host{
+		Host:      "example.com",
+		Status:    "READY",
+		TestTime:  time.Now().Add(-age).UnixMilli(),
+		Endpoints: []model.Endpoint{{IPAddress: "192.0.2.1", Grade: "A"}},
+	}
+}
+
+func appendAll(t *testing.T, store *Store, domain string, hosts ...*model.Host) {
+	t.Helper()
+	for _, host := range hosts {
+		if err := store.Append(domain, host); err != nil {
+			t.Fatalf("Append(%s): %v", domain, err)
+		}
+	}
+}
+
+func count(t *testing.T, store *Store, domain string) int {
+	t.Helper()
+	records, err := store.List(domain)
+	if err != nil {
+		t.Fatalf("List(%s): %v", domain, err)
+	}
+	return len(records)
+}
+
+func TestAppendDedupScannedAt(t *testing.T) {
+	store := New(t.TempDir(), Retention{})
+	host := readyHost(time.Hour)
+
+	// el mismo reporte de SSL Labs se guarda una sola vez
+	appendAll(t, store, "Example.com", host, host, readyHost(2*time.Hour))
+
+	records, err := store.List("example.com")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("registros = %d, se esperaban 2", len(records))
+	}
+	if !records[0].ScannedAt.Before(records[1].ScannedAt) || records[1].Domain != "example.com" {
+		t.Errorf("registros = %+v", records)
+	}
+}
+
+func TestAppendOnlyReady(t *testing.T) {
+	store := New(t.TempDir(), Retention{})
+	host := readyHost(time.Hour)
+	host.Status = "IN_PROGRESS"
+
+	for _, host := range []*model.Host{nil, host} {
+		if err := store.Append("example.com", host); err == nil {
+			t.Errorf("Append aceptó un análisis sin terminar: %+v", host)
+		}
+	}
+}
+
+func TestPruneMaxAge(t *testing.T) {
+	store := New(t.TempDir(), Retention{})
+	appendAll(t, store, "a.example.com", readyHost(40*24*time.Hour), readyHost(20*24*time.Hour), readyHost(time.Hour))
+	appendAll(t, store, "b.example.com", readyHost(50*24*time.Hour))
+
+	removed, err := store.Prune("", Retention{MaxAge: 30 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if removed != 2 || count(t, store, "a.example.com") != 2 || count(t, store, "b.example.com") != 0 {
+		t.Errorf("se eliminaron %d registros", removed)
+	}
+
+	// un dominio sin registros deja de listarse
+	if domains, err := store.Domains(); err != nil || fmt.Sprint(domains) != "[a.example.com]" {
+		t.Errorf("Domains = %v, %v", domains, err)
+	}
+}
+
+func TestAppendAppliesMaxEntries(t *testing.T) {
+	store := New(t.TempDir(), Retention{MaxEntries: 2})
+	appendAll(t, store, "example.com", readyHost(3*time.Hour), readyHost(2*time.Hour), readyHost(time.Hour))
+
+	records, err := store.List("example.com")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 2 || time.Since(records[1].ScannedAt) > 90*time.Minute {
+		t.Errorf("se esperaban los 2 registros más recientes: %+v", records)
+	}
+}
+
+func TestPruneFileNameCollision(t *testing.T) {
+	store := New(t.TempDir(), Retention{})
+
+	// ambos dominios se sanean al mismo archivo
+	if store.path("example.com:8443") != store.path("example.com_8443") {
+		t.Fatal("se esperaba que los dominios compartieran archivo")
+	}
+	appendAll(t, store, "example.com:8443", readyHost(40*24*time.Hour))
+	appendAll(t, store, "example.com_8443", readyHost(time.Hour), readyHost(50*24*time.Hour))
+
+	if domains, err := store.Domains(); err != nil || fmt.Sprint(domains) != "[example.com:8443 example.com_8443]" {
+		t.Errorf("Domains = %v, %v", domains, err)
+	}
+
+	// podar todo un dominio no borra el historial del otro
+	removed, err := store.Prune("example.com:8443", Retention{MaxAge: 30 * 24 * time.Hour})
+	if err != nil || removed != 1 {
+		t.Fatalf("Prune = %d, %v", removed, err)
+	}
+	if count(t, store, "example.com:8443") != 0 || count(t, store, "example.com_8443") != 2 {
+		t.Error("la poda afectó registros de otro dominio")
+	}
+
+	removed, err = store.Prune("", Retention{MaxEntries: 1})
+	if err != nil || removed != 1 || count(t, store, "example.com_8443") != 1 {
+		t.Errorf("Prune = %d, %v", removed, err)
+	}
+}
@@ -0,0 +1,59 @@
+// Package httpserve atiende un http.Handler junto a un trabajo en segundo
+// plano, como hacen el exporter y la API, y cierra ambos al cancelar el contexto.
+package httpserve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ShutdownTimeout es la espera máxima de las consultas en curso al cerrar
+const ShutdownTimeout = 5 * time.Second
+
+// ListenAndServe escucha en addr, informa la dirección a started y atiende
+// handler mientras worker corre en segundo plano. Al cancelar ctx cierra el
+// servidor esperando las consultas en curso y luego espera a que worker termine.
+func ListenAndServe(ctx context.Context, addr string, handler http.Handler, started func(net.Addr), worker func(context.Context)) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("no se pudo escuchar en %s: %w", addr, err)
+	}
+
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+	if started != nil {
+		started(listener.Addr())
+	}
+
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		worker(ctx)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("falló el servidor HTTP: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("falló al cerrar el servidor HTTP: %w", err)
+	}
+	<-workerDone
+
+	return nil
+}
@@ -0,0 +1,27 @@
+// Package logging es el registro de actividad de los procesos de larga
+// duración (exporter, watch y serve): una línea por evento con fecha y hora.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Logger escribe el registro de actividad. El valor cero descarta todo.
+type Logger struct {
+	out io.Writer
+}
+
+// SetOutput redirige el registro; con nil se descarta
+func (l *Logger) SetOutput(out io.Writer) {
+	l.out = out
+}
+
+// Printf escribe una línea precedida por la fecha y hora actuales
+func (l *Logger) Printf(format string, args ...any) {
+	if l.out == nil {
+		return
+	}
+	fmt.Fprintf(l.out, "%s %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
+}
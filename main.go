@@ -4,11 +4,20 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
 
+	"sslscanner/cache"
+	"sslscanner/cassette"
+	"sslscanner/client"
+	"sslscanner/model"
 	"sslscanner/output"
+	"sslscanner/policy"
 	"sslscanner/service"
 )
 
@@ -16,15 +25,56 @@ const (
 	exitCodeSuccess       = 0
 	exitCodeInvalidArgs   = 1
 	exitCodeAnalysisError = 2
+	exitCodePolicyFailure = 3
 )
 
+// commands son los subcomandos; sin subcomando se analizan los dominios recibidos
+var commands = map[string]func(args []string) int{
+	"register":   runRegister,
+	"mockserver": runMockServer,
+	"grade":      runGrade,
+	"diff":       runDiff,
+	"history":    runHistory,
+	"expiry":     runExpiry,
+	"watch":      runWatch,
+	"exporter":   runExporter,
+	"serve":      runServe,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+
 	os.Exit(run())
 }
 
 func run() int {
 	noColor := flag.Bool("no-color", false, "Deshabilitar colores en la salida")
 	showInfo := flag.Bool("info", false, "Mostrar información del servicio SSL Labs")
+	domainsFile := flag.String("file", "", "Archivo con un dominio por línea (\"-\" para leer de stdin)")
+	concurrency := flag.Int("concurrency", service.DefaultBatchConcurrency, "Máximo de análisis simultáneos en modo por lotes")
+	format := flag.String("format", formatText, "Formato de salida: text, json, sarif, junit")
+	minGrade := flag.String("min-grade", output.DefaultJUnitMinGrade, "Calificación mínima aceptada en el reporte JUnit")
+	certDays := flag.Int("cert-days", output.DefaultJUnitCertDays, "Días mínimos de vigencia del certificado en el reporte JUnit")
+	forceNew := flag.Bool("new", false, "Forzar un análisis nuevo en SSL Labs (startNew) en lugar de reutilizar uno reciente")
+	maxAge := flag.Int("max-age", service.DefaultMaxAgeHours, "Reutilizar reportes de SSL Labs más recientes que estas horas (0 = cualquiera)")
+	publish := flag.Bool("publish", false, "Publicar el resultado en los tableros públicos de SSL Labs")
+	ignoreMismatch := flag.Bool("ignore-mismatch", false, "Continuar el análisis aunque el certificado no coincida con el dominio")
+	cacheDir := flag.String("cache-dir", "", "Directorio de la caché local (por defecto $XDG_CACHE_HOME/sslscanner)")
+	cacheTTL := flag.Duration("cache-ttl", cache.DefaultTTL, "Tiempo durante el cual un resultado en caché se considera fresco")
+	refresh := flag.Bool("refresh", false, "Ignorar la caché local y consultar siempre a SSL Labs")
+	offline := flag.Bool("offline", false, "Usar solo resultados de la caché local, sin conexión")
+	noCache := flag.Bool("no-cache", false, "No leer ni escribir la caché local")
+	policyFile := flag.String("policy", "", "Archivo YAML o JSON con la política de aprobación")
+	record := flag.String("record", "", "Grabar las solicitudes y respuestas de la API en este archivo")
+	replay := flag.String("replay", "", "Reproducir sin red una ejecución grabada con --record")
+	noHistory := flag.Bool("no-history", false, "No guardar el análisis en el historial")
+	quiet := flag.Bool("quiet", false, "No mostrar el progreso de los análisis; solo reintentos, advertencias y errores")
+	historyConfig := addHistoryFlags(flag.CommandLine)
+	engine := addEngineFlags(flag.CommandLine)
 	flag.Usage = printUsage
 	flag.Parse()
 
@@ -33,32 +83,159 @@ func run() int {
 
 	setupSignalHandler(cancel)
 
-	scanner := service.NewScanner()
-	formatter := output.NewFormatter(!*noColor)
+	if !isValidFormat(*format) {
+		fmt.Fprintf(os.Stderr, "Error: formato de salida inválido: %s\n", *format)
+		return exitCodeInvalidArgs
+	}
+
+	if !model.IsValidGrade(*minGrade) {
+		fmt.Fprintf(os.Stderr, "Error: calificación mínima inválida: %s\n", *minGrade)
+		return exitCodeInvalidArgs
+	}
+
+	config := reportConfig{
+		format: *format,
+		junit:  output.JUnitOptions{MinGrade: *minGrade, CertDays: *certDays},
+	}
+
+	if *policyFile != "" {
+		rules, err := policy.Load(*policyFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCodeInvalidArgs
+		}
+		config.rules = rules
+	}
+
+	scanOpts := service.ScanOptions{
+		ForceNew:       *forceNew,
+		MaxAgeHours:    *maxAge,
+		Publish:        *publish,
+		IgnoreMismatch: *ignoreMismatch,
+	}
+	if err := scanOpts.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+	if *forceNew && *offline {
+		fmt.Fprintln(os.Stderr, "Error: --new y --offline no se pueden usar juntos")
+		return exitCodeInvalidArgs
+	}
+
+	// en formatos de documento stdout queda reservado para el documento
+	var status io.Writer = os.Stdout
+	if config.format != formatText {
+		status = os.Stderr
+	}
+	if *quiet {
+		status = io.Discard
+	}
+
+	recordingOpts, err := configureRecording(*record, *replay, engine.connection.headerNames())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+
+	retryPolicy := engine.retryPolicy()
+	if *replay != "" {
+		// una ejecución reproducida no necesita esperar a SSL Labs
+		retryPolicy.BaseDelay = time.Millisecond
+		retryPolicy.MaxDelay = time.Millisecond
+	}
+
+	scanner, err := engine.newScanner(retryPolicy, recordingOpts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+	// el progreso va a stderr para no mezclarse con el reporte
+	progress := newProgressRenderer(os.Stderr, !*quiet)
+	defer progress.stop()
+	scanner.SetObserver(progress.observe)
+	formatter := output.NewFormatter(os.Stdout, !*noColor)
+
+	if *replay != "" {
+		if *offline || *refresh {
+			fmt.Fprintln(os.Stderr, "Error: --replay no usa la caché local; no se puede combinar con --offline ni --refresh")
+			return exitCodeInvalidArgs
+		}
+		// la grabación es la única fuente de datos: sin caché ni esperas reales
+		*noCache = true
+		scanner.SetPolling(service.Polling{Initial: time.Millisecond, Running: time.Millisecond})
+	}
+	if *record != "" {
+		if *offline {
+			fmt.Fprintln(os.Stderr, "Error: --record y --offline no se pueden usar juntos")
+			return exitCodeInvalidArgs
+		}
+		// un resultado de la caché local no pasaría por la API y no quedaría grabado
+		*refresh = true
+	}
+
+	if err := configureCache(scanner, *engine.engine, *cacheDir, *cacheTTL, *refresh, *offline, *noCache); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+
+	// una ejecución reproducida no es un análisis real y no entra al historial
+	if !*noHistory && *replay == "" {
+		store, err := historyConfig.open(*engine.engine)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCodeInvalidArgs
+		}
+		scanner.SetHistory(store)
+	}
 
 	if *showInfo {
 		return showServiceInfo(ctx, scanner)
 	}
 
-	args := flag.Args()
-	if len(args) != 1 {
+	domains, err := collectDomains(flag.Args(), *domainsFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+
+	if len(domains) == 0 {
 		printUsage()
 		return exitCodeInvalidArgs
 	}
 
-	domain := args[0]
+	if len(domains) > 1 || *domainsFile != "" {
+		return runBatch(ctx, scanner, progress, formatter, status, config, domains, scanOpts, *concurrency)
+	}
+
+	domain := domains[0]
 
-	fmt.Printf("Iniciando análisis TLS para: %s\n", domain)
-	fmt.Println("Este proceso puede demorar...")
-	fmt.Println()
+	fmt.Fprintf(status, "Iniciando análisis TLS para: %s\n", domain)
+	fmt.Fprintln(status, "Este proceso puede demorar...")
+	fmt.Fprintln(status)
 
-	result, err := scanner.RunAnalysis(ctx, domain)
+	result, err := scanner.RunAnalysis(ctx, domain, scanOpts)
+	progress.stop()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+
+	if config.format != formatText {
+		results := []service.BatchResult{{Domain: domain, Host: result, Err: err}}
+		if writeErr := writeDocument(os.Stdout, config, results); writeErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", writeErr)
+			return exitCodeAnalysisError
+		}
+	} else if err == nil {
+		formatter.PrintReport(result)
+	}
+
+	if err != nil {
 		return exitCodeAnalysisError
 	}
 
-	formatter.PrintReport(result)
+	if !checkPolicy(formatter, config, result) {
+		return exitCodePolicyFailure
+	}
 
 	return exitCodeSuccess
 }
@@ -67,11 +244,24 @@ func printUsage() {
 	fmt.Fprintf(os.Stderr, `SSL Labs TLS Scanner
 
 Uso:
+  sslscanner register [opciones]
+  sslscanner mockserver [opciones]
+  sslscanner grade [opciones] <archivo.json>...
+  sslscanner diff [opciones] <anterior.json> <actual.json>
+  sslscanner history <list|timeline|export|prune> [opciones] [dominio]
+  sslscanner expiry [opciones] <dominio>...
+  sslscanner watch --config <archivo.yaml> [opciones]
+  sslscanner exporter [opciones] <dominio>...
+  sslscanner serve [opciones]
   sslscanner [opciones] <dominio>
+  sslscanner [opciones] <dominio> <dominio>...
+  sslscanner [opciones] --file <archivo|->
 
 Descripción:
   Analiza la configuración TLS/SSL de un dominio usando la API de SSL Labs.
   El análisis incluye calificación, protocolos, cifrados y vulnerabilidades.
+  Con --engine local el análisis se hace directamente desde esta máquina,
+  lo que permite analizar IPs privadas y puertos no estándar (host:puerto).
 
 Opciones:
 `)
@@ -80,17 +270,121 @@ Opciones:
 Ejemplos:
   sslscanner example.com
   sslscanner --no-color example.com
+  sslscanner --concurrency 2 example.com example.org
+  sslscanner --format json example.com
+  sslscanner --format sarif --file dominios.txt > tls.sarif
+  sslscanner --format junit --min-grade A --cert-days 21 example.com
+  sslscanner --policy politica.yaml example.com
+  sslscanner --refresh example.com
+  sslscanner --new example.com
+  sslscanner --max-age 6 example.com
+  sslscanner register --first-name Ana --last-name Pérez --email ana@empresa.com --organization Empresa
+  SSLLABS_EMAIL=ana@empresa.com sslscanner --api-version 4 example.com
+  sslscanner --offline --cache-ttl 168h example.com
+  sslscanner --record reporte.cassette.json example.com
+  sslscanner --replay reporte.cassette.json example.com
+  cat dominios.txt | sslscanner --file -
   sslscanner --info
+  sslscanner --base-url https://ssllabs-mirror.interno/api/v2 --ca-cert ca-corp.pem example.com
+  sslscanner --proxy http://proxy.interno:3128 --header "X-Equipo: seguridad" example.com
+  sslscanner --engine local intranet.empresa.local:8443
+  sslscanner --engine local --ca-cert ca-corp.pem 10.0.0.12
+  sslscanner history timeline example.com
+  sslscanner expiry --warn-days 45 --critical-days 10 --file dominios.txt
+  sslscanner watch --config watch.yaml
+  sslscanner exporter --listen :9219 --interval 12h --file dominios.txt
+  SSLSCANNER_API_TOKEN=secreto sslscanner serve --listen :8089
+  sslscanner history export --format csv --output historial.csv example.com
+
+Códigos de salida:
+  0  análisis exitoso (y política cumplida)
+  1  argumentos inválidos
+  2  error en el análisis de algún dominio
+  3  algún dominio no cumple la política
 `)
 }
 
+// envOrDefault devuelve la variable de entorno o el valor por defecto si no está definida
+func envOrDefault(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// configureCache habilita la caché local según los flags de la línea de comandos.
+// Los resultados del motor local se guardan aparte de los de SSL Labs.
+func configureCache(scanner *service.Scanner, engine, dir string, ttl time.Duration, refresh, offline, disabled bool) error {
+	if refresh && offline {
+		return fmt.Errorf("--refresh y --offline no se pueden usar juntos")
+	}
+
+	if disabled {
+		if offline {
+			return fmt.Errorf("--offline requiere la caché local")
+		}
+		return nil
+	}
+
+	if ttl <= 0 {
+		return fmt.Errorf("--cache-ttl debe ser mayor que cero")
+	}
+
+	if dir == "" {
+		defaultDir, err := cache.DefaultDir()
+		if err != nil {
+			return err
+		}
+		dir = defaultDir
+	}
+	if engine == engineLocal {
+		dir = filepath.Join(dir, engineLocal)
+	}
+
+	mode := service.CacheDefault
+	switch {
+	case refresh:
+		mode = service.CacheRefresh
+	case offline:
+		mode = service.CacheOnly
+	}
+
+	scanner.SetCache(cache.New(dir, ttl), mode)
+
+	return nil
+}
+
+// configureRecording devuelve las opciones del cliente para grabar o reproducir
+// el tráfico con la API; las cabeceras redact no se escriben en la grabación
+func configureRecording(record, replay string, redact []string) ([]client.Option, error) {
+	switch {
+	case record != "" && replay != "":
+		return nil, fmt.Errorf("--record y --replay no se pueden usar juntos")
+	case record != "":
+		return []client.Option{client.WithTransportWrapper(func(transport http.RoundTripper) http.RoundTripper {
+			return cassette.NewRecorder(record, transport, redact...)
+		})}, nil
+	case replay != "":
+		recorded, err := cassette.Load(replay)
+		if err != nil {
+			return nil, err
+		}
+		replayer := cassette.NewReplayer(recorded)
+		return []client.Option{client.WithTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+			return replayer
+		})}, nil
+	default:
+		return nil, nil
+	}
+}
+
 func setupSignalHandler(cancel context.CancelFunc) {
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
 		<-signalChan
-		fmt.Println("\nRecibida señal de interrupción, cancelando análisis...")
+		fmt.Fprintln(os.Stderr, "\nRecibida señal de interrupción, cancelando análisis...")
 		cancel()
 	}()
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"sslscanner/mockserver"
+)
+
+// runMockServer levanta el emulador local de la API de SSL Labs
+func runMockServer(args []string) int {
+	fs := flag.NewFlagSet("mockserver", flag.ContinueOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "Dirección en la que escuchar")
+	fixtures := fs.String("fixtures", "examples/fixtures", "Directorio con un JSON de model.Host por dominio")
+	dnsTime := fs.Duration("dns-time", mockserver.DefaultDNSTime, "Duración simulada de la resolución DNS")
+	scanTime := fs.Duration("scan-time", mockserver.DefaultScanTime, "Duración simulada del análisis de cada endpoint")
+	maxAssessments := fs.Int("max-assessments", mockserver.DefaultMaxAssessments, "Análisis simultáneos permitidos")
+	coolOff := fs.Duration("cool-off", 0, "Espera entre análisis nuevos publicada en /info")
+	rateLimitEvery := fs.Int("rate-limit-every", 0, "Responder 429 a una de cada N solicitudes a /analyze (0 = nunca)")
+	overloadEvery := fs.Int("overload-every", 0, "Responder 529 a una de cada N solicitudes a /analyze (0 = nunca)")
+	maintenance := fs.Bool("maintenance", false, "Responder 503 a todas las solicitudes")
+	retryAfter := fs.Duration("retry-after", 0, "Valor de la cabecera Retry-After en los 429 y 503")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Emula la API de SSL Labs con fixtures locales, para pruebas sin conexión.
+
+Uso:
+  sslscanner mockserver [opciones]
+
+Opciones:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Luego analice contra el emulador con:
+  sslscanner --base-url http://127.0.0.1:8080/api/v2 example.com
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitCodeInvalidArgs
+	}
+
+	server, err := mockserver.New(mockserver.Config{
+		FixturesDir:    *fixtures,
+		DNSTime:        *dnsTime,
+		ScanTime:       *scanTime,
+		MaxAssessments: *maxAssessments,
+		CoolOff:        *coolOff,
+		Faults: mockserver.Faults{
+			RateLimitEvery: *rateLimitEvery,
+			OverloadEvery:  *overloadEvery,
+			Maintenance:    *maintenance,
+			RetryAfter:     *retryAfter,
+		},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	setupSignalHandler(cancel)
+
+	httpServer := &http.Server{Addr: *addr, Handler: server}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Emulador de SSL Labs escuchando en http://%s/api/v2\n", *addr)
+	if domains := server.Domains(); len(domains) > 0 {
+		fmt.Fprintf(os.Stderr, "Dominios disponibles: %s\n", strings.Join(domains, ", "))
+	} else {
+		fmt.Fprintf(os.Stderr, "Advertencia: no hay fixtures en %s; todos los análisis terminarán con error\n", *fixtures)
+	}
+
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeAnalysisError
+	}
+
+	return exitCodeSuccess
+}
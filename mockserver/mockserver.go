@@ -0,0 +1,404 @@
+// Package mockserver emula la API de SSL Labs (/info, /analyze y
+// /getEndpointData) a partir de fixtures locales, para ejecutar el scanner
+// de punta a punta sin acceso a internet.
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sslscanner/model"
+)
+
+const (
+	DefaultDNSTime        = 2 * time.Second
+	DefaultScanTime       = 20 * time.Second
+	DefaultMaxAssessments = 25
+)
+
+// Config define los fixtures, los tiempos simulados y las fallas a inyectar
+type Config struct {
+	// FixturesDir contiene un archivo JSON de model.Host por dominio
+	FixturesDir string
+	// DNSTime es lo que dura el estado DNS de un análisis nuevo
+	DNSTime time.Duration
+	// ScanTime es lo que tarda en analizarse cada endpoint
+	ScanTime time.Duration
+	// MaxAssessments y CoolOff se publican en /info y en las cabeceras
+	MaxAssessments int
+	CoolOff        time.Duration
+	// Faults son las fallas que se inyectan en las respuestas
+	Faults Faults
+}
+
+// Faults inyecta respuestas de error de forma determinista, contando las
+// solicitudes a /analyze
+type Faults struct {
+	// RateLimitEvery responde 429 a una de cada N solicitudes (0 = nunca)
+	RateLimitEvery int
+	// OverloadEvery responde 529 a una de cada N solicitudes (0 = nunca)
+	OverloadEvery int
+	// Maintenance responde 503 a todas las solicitudes
+	Maintenance bool
+	// RetryAfter se envía en la cabecera Retry-After de los 429 y 503
+	RetryAfter time.Duration
+}
+
+// Server implementa http.Handler. Las rutas se reconocen por su último
+// segmento, así que sirve igual bajo /api/v2, /api/v3 o /api/v4.
+type Server struct {
+	config   Config
+	fixtures map[string]*model.Host
+
+	mu          sync.Mutex
+	assessments map[string]*assessment
+	requests    int
+}
+
+// assessment es un análisis simulado; su estado se deriva del tiempo transcurrido
+type assessment struct {
+	host    string
+	started time.Time
+	fixture *model.Host
+}
+
+// New carga los fixtures de config.FixturesDir
+func New(config Config) (*Server, error) {
+	if config.DNSTime <= 0 {
+		config.DNSTime = DefaultDNSTime
+	}
+	if config.ScanTime <= 0 {
+		config.ScanTime = DefaultScanTime
+	}
+	if config.MaxAssessments <= 0 {
+		config.MaxAssessments = DefaultMaxAssessments
+	}
+
+	fixtures, err := LoadFixtures(config.FixturesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		config:      config,
+		fixtures:    fixtures,
+		assessments: make(map[string]*assessment),
+	}, nil
+}
+
+// LoadFixtures lee los *.json de dir. El dominio es el campo host del archivo
+// o, si está vacío, el nombre del archivo sin extensión.
+func LoadFixtures(dir string) (map[string]*model.Host, error) {
+	fixtures := make(map[string]*model.Host)
+	if dir == "" {
+		return fixtures, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("falló al listar fixtures: %w", err)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("falló al leer fixture: %w", err)
+		}
+
+		var host model.Host
+		if err := json.Unmarshal(data, &host); err != nil {
+			return nil, fmt.Errorf("fixture inválido %s: %w", file, err)
+		}
+
+		if host.Host == "" {
+			host.Host = strings.TrimSuffix(filepath.Base(file), ".json")
+		}
+		fixtures[strings.ToLower(host.Host)] = &host
+	}
+
+	return fixtures, nil
+}
+
+// Domains devuelve los dominios con fixture en orden alfabético
+func (s *Server) Domains() []string {
+	domains := make([]string, 0, len(s.fixtures))
+	for domain := range s.fixtures {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.expireAssessments(now)
+
+	w.Header().Set("X-Max-Assessments", strconv.Itoa(s.config.MaxAssessments))
+	w.Header().Set("X-Current-Assessments", strconv.Itoa(s.running(now)))
+
+	if s.config.Faults.Maintenance {
+		s.writeFault(w, http.StatusServiceUnavailable)
+		return
+	}
+
+	switch path.Base(r.URL.Path) {
+	case "info":
+		s.handleInfo(w, now)
+	case "analyze":
+		s.handleAnalyze(w, r, now)
+	case "getEndpointData":
+		s.handleEndpointData(w, r, now)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) handleInfo(w http.ResponseWriter, now time.Time) {
+	writeJSON(w, http.StatusOK, model.Info{
+		EngineVersion:        "mockserver",
+		CriteriaVersion:      "2009q",
+		MaxAssessments:       s.config.MaxAssessments,
+		CurrentAssessments:   s.running(now),
+		NewAssessmentCoolOff: s.config.CoolOff.Milliseconds(),
+		Messages:             []string{"Servidor simulado de SSL Labs: los resultados provienen de fixtures locales"},
+	})
+}
+
+func (s *Server) handleAnalyze(w http.ResponseWriter, r *http.Request, now time.Time) {
+	s.requests++
+	if every := s.config.Faults.RateLimitEvery; every > 0 && s.requests%every == 0 {
+		s.writeFault(w, http.StatusTooManyRequests)
+		return
+	}
+	if every := s.config.Faults.OverloadEvery; every > 0 && s.requests%every == 0 {
+		s.writeFault(w, 529)
+		return
+	}
+
+	query := r.URL.Query()
+	domain := strings.ToLower(query.Get("host"))
+	if domain == "" {
+		writeInvalid(w, "host", "parámetro obligatorio")
+		return
+	}
+	if query.Get("startNew") == "on" && query.Get("fromCache") == "on" {
+		writeInvalid(w, "startNew", "no se puede combinar con fromCache")
+		return
+	}
+
+	current, ok := s.assessments[domain]
+	switch {
+	case query.Get("startNew") == "on":
+		ok = false
+	case ok && query.Get("fromCache") == "on" && !s.ready(current, now):
+		// fromCache solo entrega reportes terminados; uno en curso se sigue consultando
+	case ok && query.Get("fromCache") == "on":
+		if maxAge, err := strconv.Atoi(query.Get("maxAge")); err == nil && maxAge > 0 &&
+			now.Sub(current.started) > time.Duration(maxAge)*time.Hour {
+			ok = false
+		}
+	}
+
+	if !ok {
+		if s.running(now) >= s.config.MaxAssessments {
+			s.writeFault(w, http.StatusTooManyRequests)
+			return
+		}
+		current = &assessment{host: domain, started: now, fixture: s.fixtures[domain]}
+		s.assessments[domain] = current
+	}
+
+	host := s.snapshot(current, now)
+	if query.Get("all") == "" {
+		for i := range host.Endpoints {
+			host.Endpoints[i].Details = nil
+		}
+	}
+
+	writeJSON(w, http.StatusOK, host)
+}
+
+func (s *Server) handleEndpointData(w http.ResponseWriter, r *http.Request, now time.Time) {
+	query := r.URL.Query()
+	domain := strings.ToLower(query.Get("host"))
+	ipAddress := query.Get("s")
+
+	current, ok := s.assessments[domain]
+	if !ok || current.fixture == nil || !s.ready(current, now) {
+		writeInvalid(w, "host", "no hay un análisis terminado para este dominio")
+		return
+	}
+
+	for _, endpoint := range current.fixture.Endpoints {
+		if endpoint.IPAddress == ipAddress {
+			writeJSON(w, http.StatusOK, endpoint)
+			return
+		}
+	}
+
+	writeInvalid(w, "s", "endpoint desconocido")
+}
+
+// snapshot arma la respuesta de /analyze según el tiempo transcurrido: primero
+// DNS y luego un endpoint a la vez, como hace SSL Labs
+func (s *Server) snapshot(a *assessment, now time.Time) *model.Host {
+	elapsed := now.Sub(a.started)
+	startTime := a.started.UnixMilli()
+
+	if a.fixture == nil {
+		host := &model.Host{Host: a.host, Port: 443, Protocol: "http", StartTime: startTime}
+		if elapsed < s.config.DNSTime {
+			host.Status, host.StatusMessage = "DNS", "Resolving domain names"
+		} else {
+			host.Status, host.StatusMessage = "ERROR", "Unable to resolve domain name"
+		}
+		return host
+	}
+
+	host := copyHost(a.fixture)
+	host.StartTime = startTime
+	host.EngineVersion = "mockserver"
+	host.CriteriaVersion = "2009q"
+
+	if elapsed < s.config.DNSTime {
+		host.Status, host.StatusMessage = "DNS", "Resolving domain names"
+		host.Endpoints = nil
+		return host
+	}
+
+	if s.ready(a, now) {
+		host.Status, host.StatusMessage = "READY", "Ready"
+		host.TestTime = a.started.Add(s.totalTime(a)).UnixMilli()
+		for i := range host.Endpoints {
+			host.Endpoints[i].Progress = 100
+			host.Endpoints[i].Duration = s.config.ScanTime.Milliseconds()
+		}
+		return host
+	}
+
+	host.Status, host.StatusMessage = "IN_PROGRESS", "In progress"
+	scanning := elapsed - s.config.DNSTime
+
+	for i := range host.Endpoints {
+		endpoint := &host.Endpoints[i]
+		endpointStart := time.Duration(i) * s.config.ScanTime
+
+		switch {
+		case scanning >= endpointStart+s.config.ScanTime:
+			endpoint.Progress = 100
+			endpoint.Duration = s.config.ScanTime.Milliseconds()
+			endpoint.Details = nil
+		case scanning >= endpointStart:
+			done := scanning - endpointStart
+			endpoint.StatusMessage = "In progress"
+			endpoint.Progress = int(math.Min(99, float64(done)/float64(s.config.ScanTime)*100))
+			endpoint.StatusDetails, endpoint.StatusDetailsMessage = testAt(endpoint.Progress)
+			endpoint.ETA = int((s.config.ScanTime - done).Seconds())
+			endpoint.Grade = ""
+			endpoint.Details = nil
+		default:
+			endpoint.StatusMessage = "Pending"
+			endpoint.Progress = -1
+			endpoint.ETA = -1
+			endpoint.Grade = ""
+			endpoint.Details = nil
+		}
+	}
+
+	return host
+}
+
+// expireAssessments descarta los análisis simulados de más de un día
+func (s *Server) expireAssessments(now time.Time) {
+	for domain, a := range s.assessments {
+		if now.Sub(a.started) > 24*time.Hour {
+			delete(s.assessments, domain)
+		}
+	}
+}
+
+func (s *Server) running(now time.Time) int {
+	count := 0
+	for _, a := range s.assessments {
+		if !s.ready(a, now) {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *Server) ready(a *assessment, now time.Time) bool {
+	return now.Sub(a.started) >= s.totalTime(a)
+}
+
+func (s *Server) totalTime(a *assessment) time.Duration {
+	if a.fixture == nil {
+		return s.config.DNSTime
+	}
+	return s.config.DNSTime + time.Duration(len(a.fixture.Endpoints))*s.config.ScanTime
+}
+
+func (s *Server) writeFault(w http.ResponseWriter, status int) {
+	if s.config.Faults.RetryAfter > 0 && status != 529 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(s.config.Faults.RetryAfter.Seconds()))))
+	}
+	w.WriteHeader(status)
+}
+
+// progressTests son los mensajes que SSL Labs muestra durante un análisis
+var progressTests = []struct {
+	from    int
+	code    string
+	message string
+}{
+	{0, "TESTING_PROTOCOL_INTOLERANCE_399", "Testing TLS version intolerance"},
+	{15, "TESTING_PROTOCOLS", "Testing protocols"},
+	{30, "TESTING_SUITES", "Determining available cipher suites"},
+	{55, "TESTING_HANDSHAKE_SIMULATION", "Simulating handshakes"},
+	{70, "TESTING_HEARTBLEED", "Testing Heartbleed"},
+	{80, "TESTING_STRICT_RI", "Testing Strict Renegotiation"},
+	{90, "TESTING_HTTPS", "Sending one complete HTTPS request"},
+}
+
+func testAt(progress int) (string, string) {
+	code, message := progressTests[0].code, progressTests[0].message
+	for _, test := range progressTests {
+		if progress >= test.from {
+			code, message = test.code, test.message
+		}
+	}
+	return code, message
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeInvalid(w http.ResponseWriter, field, message string) {
+	writeJSON(w, http.StatusBadRequest, model.APIError{
+		Errors: []model.ErrorDetail{{Field: field, Message: message}},
+	})
+}
+
+func copyHost(host *model.Host) *model.Host {
+	data, _ := json.Marshal(host)
+
+	var clone model.Host
+	json.Unmarshal(data, &clone)
+	return &clone
+}
@@ -0,0 +1,283 @@
+package mockserver_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sslscanner/client"
+	"sslscanner/mockserver"
+	"sslscanner/service"
+)
+
+const fixturesDir = "../examples/fixtures"
+
+// testRetry reintenta con esperas de milisegundos para no alargar las pruebas
+var testRetry = client.RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+func startServer(t *testing.T, config mockserver.Config) string {
+	t.Helper()
+
+	server, err := mockserver.New(config)
+	if err != nil {
+		t.Fatalf("mockserver.New: %v", err)
+	}
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	return httpServer.URL + "/api/v2"
+}
+
+func newClient(t *testing.T, baseURL string, retry client.RetryPolicy) *client.Client {
+	t.Helper()
+
+	c, err := client.New(client.WithBaseURL(baseURL), client.WithRetryPolicy(retry))
+	if err != nil {
+		t.Fatalf("client.New: %v", err)
+	}
+	return c
+}
+
+// events guarda los eventos del Scanner que interesan a estas pruebas
+type events struct {
+	mu       sync.Mutex
+	statuses []string
+	progress int
+	retries  []service.Retry
+}
+
+func (e *events) observe(event service.Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch ev := event.(type) {
+	case service.StatusChanged:
+		e.statuses = append(e.statuses, ev.Status)
+	case service.EndpointProgress:
+		e.progress++
+	case service.Retry:
+		e.retries = append(e.retries, ev)
+	}
+}
+
+func newScanner(t *testing.T, baseURL string, retry client.RetryPolicy) (*service.Scanner, *events) {
+	t.Helper()
+
+	scanner := service.NewScannerWithClient(newClient(t, baseURL, retry))
+	scanner.SetPolling(service.Polling{Initial: 5 * time.Millisecond, Running: 5 * time.Millisecond})
+
+	recorded := &events{}
+	scanner.SetObserver(recorded.observe)
+	return scanner, recorded
+}
+
+func TestDomains(t *testing.T) {
+	server, err := mockserver.New(mockserver.Config{FixturesDir: fixturesDir})
+	if err != nil {
+		t.Fatalf("mockserver.New: %v", err)
+	}
+
+	if domains := server.Domains(); len(domains) != 1 || domains[0] != "example.com" {
+		t.Errorf("Domains() = %v", domains)
+	}
+}
+
+func TestScanTransitions(t *testing.T) {
+	baseURL := startServer(t, mockserver.Config{
+		FixturesDir: fixturesDir,
+		DNSTime:     30 * time.Millisecond,
+		ScanTime:    50 * time.Millisecond,
+	})
+	scanner, recorded := newScanner(t, baseURL, testRetry)
+
+	host, err := scanner.RunAnalysis(context.Background(), "example.com", service.DefaultScanOptions())
+	if err != nil {
+		t.Fatalf("RunAnalysis: %v", err)
+	}
+
+	if host.Status != service.StatusReady || len(host.Endpoints) != 2 {
+		t.Fatalf("host = %s con %d endpoints", host.Status, len(host.Endpoints))
+	}
+	for _, endpoint := range host.Endpoints {
+		if endpoint.Grade != "A" || endpoint.Progress != 100 || endpoint.Details == nil {
+			t.Errorf("endpoint %s: calificación %q, progreso %d", endpoint.IPAddress, endpoint.Grade, endpoint.Progress)
+		}
+	}
+
+	if got := strings.Join(recorded.statuses, " → "); got != "DNS → IN_PROGRESS → READY" {
+		t.Errorf("estados = %s", got)
+	}
+	if recorded.progress == 0 {
+		t.Error("no se informó el progreso de los endpoints")
+	}
+}
+
+func TestScanUnresolvableDomain(t *testing.T) {
+	baseURL := startServer(t, mockserver.Config{DNSTime: 20 * time.Millisecond})
+	scanner, recorded := newScanner(t, baseURL, testRetry)
+
+	_, err := scanner.RunAnalysis(context.Background(), "no-existe.example.org", service.DefaultScanOptions())
+	if err == nil || !strings.Contains(err.Error(), "Unable to resolve domain name") {
+		t.Fatalf("err = %v", err)
+	}
+	if got := strings.Join(recorded.statuses, " → "); got != "DNS → ERROR" {
+		t.Errorf("estados = %s", got)
+	}
+}
+
+func TestScanRetriesInjectedFaults(t *testing.T) {
+	baseURL := startServer(t, mockserver.Config{
+		FixturesDir: fixturesDir,
+		DNSTime:     10 * time.Millisecond,
+		ScanTime:    20 * time.Millisecond,
+		Faults:      mockserver.Faults{RateLimitEvery: 3, OverloadEvery: 4},
+	})
+	scanner, recorded := newScanner(t, baseURL, testRetry)
+
+	host, err := scanner.RunAnalysis(context.Background(), "example.com", service.DefaultScanOptions())
+	if err != nil {
+		t.Fatalf("RunAnalysis: %v", err)
+	}
+	if host.Status != service.StatusReady {
+		t.Errorf("estado = %s", host.Status)
+	}
+
+	if len(recorded.retries) == 0 {
+		t.Fatal("no se informaron reintentos")
+	}
+	var rateLimited, overloaded bool
+	for _, retry := range recorded.retries {
+		rateLimited = rateLimited || errors.Is(retry.Err, client.ErrRateLimited)
+		overloaded = overloaded || errors.Is(retry.Err, client.ErrOverloaded)
+		if retry.MaxRetries != testRetry.MaxRetries || retry.Delay > testRetry.MaxDelay {
+			t.Errorf("reintento = %+v", retry)
+		}
+	}
+	if !rateLimited || !overloaded {
+		t.Errorf("se esperaban reintentos por 429 y por 529: %+v", recorded.retries)
+	}
+}
+
+func TestScanMaintenance(t *testing.T) {
+	baseURL := startServer(t, mockserver.Config{
+		FixturesDir: fixturesDir,
+		Faults:      mockserver.Faults{Maintenance: true, RetryAfter: time.Minute},
+	})
+	scanner, recorded := newScanner(t, baseURL, testRetry)
+
+	_, err := scanner.RunAnalysis(context.Background(), "example.com", service.DefaultScanOptions())
+	if !errors.Is(err, client.ErrMaintenance) {
+		t.Fatalf("err = %v, se esperaba ErrMaintenance", err)
+	}
+
+	if len(recorded.retries) != testRetry.MaxRetries {
+		t.Errorf("%d reintentos, se esperaban %d", len(recorded.retries), testRetry.MaxRetries)
+	}
+	// el Retry-After de un minuto se acota a MaxDelay
+	for _, retry := range recorded.retries {
+		if retry.Delay != testRetry.MaxDelay {
+			t.Errorf("espera = %v, se esperaba %v", retry.Delay, testRetry.MaxDelay)
+		}
+	}
+}
+
+func TestGovernorWaitsForFreeSlot(t *testing.T) {
+	baseURL := startServer(t, mockserver.Config{DNSTime: 100 * time.Millisecond, MaxAssessments: 1})
+	// sin reintentos: un 429 por falta de cupo haría fallar la prueba
+	c := newClient(t, baseURL, client.RetryPolicy{})
+	ctx := context.Background()
+
+	if _, err := c.StartAnalysis(ctx, "a.example.org", client.AnalyzeOptions{}); err != nil {
+		t.Fatalf("StartAnalysis(a): %v", err)
+	}
+	if _, err := c.CheckAnalysisStatus(ctx, "a.example.org", client.AnalyzeOptions{}); err != nil {
+		t.Fatalf("CheckAnalysisStatus(a): %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.StartAnalysis(ctx, "b.example.org", client.AnalyzeOptions{})
+		done <- err
+	}()
+
+	for {
+		select {
+		case err := <-done:
+			t.Fatalf("el segundo análisis empezó con el cupo ocupado: %v", err)
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		host, err := c.CheckAnalysisStatus(ctx, "a.example.org", client.AnalyzeOptions{})
+		if err != nil {
+			t.Fatalf("CheckAnalysisStatus(a): %v", err)
+		}
+		if host.Status != service.StatusDNS {
+			break
+		}
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("StartAnalysis(b): %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("el segundo análisis no empezó al liberarse el cupo")
+	}
+}
+
+func TestGovernorRespectsCoolOff(t *testing.T) {
+	const coolOff = 50 * time.Millisecond
+	baseURL := startServer(t, mockserver.Config{CoolOff: coolOff})
+	c := newClient(t, baseURL, client.RetryPolicy{})
+	ctx := context.Background()
+
+	if _, err := c.GetInfo(ctx); err != nil {
+		t.Fatalf("GetInfo: %v", err)
+	}
+
+	start := time.Now()
+	for _, domain := range []string{"a.example.org", "b.example.org"} {
+		if _, err := c.StartAnalysis(ctx, domain, client.AnalyzeOptions{}); err != nil {
+			t.Fatalf("StartAnalysis(%s): %v", domain, err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < coolOff {
+		t.Errorf("dos análisis nuevos en %v, el cool-off es de %v", elapsed, coolOff)
+	}
+}
+
+func TestScannersShareClient(t *testing.T) {
+	baseURL := startServer(t, mockserver.Config{
+		FixturesDir: fixturesDir,
+		Faults:      mockserver.Faults{Maintenance: true},
+	})
+	c := newClient(t, baseURL, testRetry)
+
+	first := service.NewScannerWithClient(c)
+	firstEvents := &events{}
+	first.SetObserver(firstEvents.observe)
+
+	second := service.NewScannerWithClient(c)
+	secondEvents := &events{}
+	second.SetObserver(secondEvents.observe)
+
+	if _, err := first.RunAnalysis(context.Background(), "example.com", service.DefaultScanOptions()); !errors.Is(err, client.ErrMaintenance) {
+		t.Fatalf("err = %v, se esperaba ErrMaintenance", err)
+	}
+
+	if len(firstEvents.retries) != testRetry.MaxRetries {
+		t.Errorf("%d reintentos, se esperaban %d", len(firstEvents.retries), testRetry.MaxRetries)
+	}
+	if len(secondEvents.retries) != 0 {
+		t.Errorf("el otro Scanner recibió %d reintentos ajenos", len(secondEvents.retries))
+	}
+	if c.RetryPolicy().OnRetry != nil {
+		t.Error("NewScannerWithClient no debería modificar la política del cliente")
+	}
+}
@@ -0,0 +1,50 @@
+package model
+
+// gradeOrder lista las calificaciones de SSL Labs de peor a mejor.
+// T (sin confianza) y M (nombre no coincide) se consideran las peores.
+var gradeOrder = []string{"T", "M", "F", "E", "D", "C", "B", "A-", "A", "A+"}
+
+// GradeRank devuelve la posición de la calificación (mayor es mejor) o -1 si no se reconoce
+func GradeRank(grade string) int {
+	for i, g := range gradeOrder {
+		if g == grade {
+			return i
+		}
+	}
+	return -1
+}
+
+// GradeAtLeast indica si grade es igual o mejor que minimum.
+// Una calificación desconocida o vacía nunca cumple el mínimo.
+func GradeAtLeast(grade, minimum string) bool {
+	rank := GradeRank(grade)
+	return rank >= 0 && rank >= GradeRank(minimum)
+}
+
+// IsValidGrade indica si grade es una calificación conocida de SSL Labs
+func IsValidGrade(grade string) bool {
+	return GradeRank(grade) >= 0
+}
+
+// WorstGrade es la peor calificación reconocida entre los endpoints del host,
+// o "" si ninguno tiene una
+func WorstGrade(host *Host) string {
+	worst := ""
+	for _, endpoint := range host.Endpoints {
+		if GradeRank(endpoint.Grade) < 0 {
+			continue
+		}
+		if worst == "" || GradeRank(endpoint.Grade) < GradeRank(worst) {
+			worst = endpoint.Grade
+		}
+	}
+	return worst
+}
+
+// DisplayGrade muestra "-" en lugar de una calificación vacía
+func DisplayGrade(grade string) string {
+	if grade == "" {
+		return "-"
+	}
+	return grade
+}
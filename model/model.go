@@ -22,6 +22,8 @@ type Host struct {
 	CriteriaVersion string     `json:"criteriaVersion"`
 	Endpoints       []Endpoint `json:"endpoints"`
 	CertHostnames   []string   `json:"certHostnames"`
+	// Certs solo existe en las API v3 y v4, donde los certificados están a nivel de host
+	Certs []HostCert `json:"certs,omitempty"`
 }
 
 type Endpoint struct {
@@ -55,6 +57,8 @@ type EndpointDetails struct {
 	CompressionMethods int         `json:"compressionMethods"`
 	SupportsNpn        bool        `json:"supportsNpn"`
 	NpnProtocols       string      `json:"npnProtocols"`
+	SupportsAlpn       bool        `json:"supportsAlpn"`
+	AlpnProtocols      string      `json:"alpnProtocols"`
 	SessionTickets     int         `json:"sessionTickets"`
 	OcspStapling       bool        `json:"ocspStapling"`
 	SniRequired        bool        `json:"sniRequired"`
@@ -78,6 +82,8 @@ type EndpointDetails struct {
 	Logjam             bool        `json:"logjam"`
 	ChaCha20Preference bool        `json:"chaCha20Preference"`
 	HstsPolicy         *HstsPolicy `json:"hstsPolicy,omitempty"`
+	// CertChains solo existe en las API v3 y v4 y referencia a Host.Certs
+	CertChains []CertChain `json:"certChains,omitempty"`
 }
 
 type Key struct {
@@ -105,6 +111,8 @@ type Cert struct {
 	ValidationType   string   `json:"validationType"`
 	Issues           int      `json:"issues"`
 	SCT              bool     `json:"sct"`
+	SerialNumber     string   `json:"serialNumber,omitempty"`
+	Sha256Hash       string   `json:"sha256Hash,omitempty"`
 }
 
 type Chain struct {
@@ -130,6 +138,60 @@ type ChainCert struct {
 	Raw                  string `json:"raw"`
 }
 
+// HostCert es el certificado a nivel de host de las API v3 y v4
+type HostCert struct {
+	ID                     string   `json:"id"`
+	Subject                string   `json:"subject"`
+	SerialNumber           string   `json:"serialNumber"`
+	CommonNames            []string `json:"commonNames"`
+	AltNames               []string `json:"altNames"`
+	NotBefore              int64    `json:"notBefore"`
+	NotAfter               int64    `json:"notAfter"`
+	IssuerSubject          string   `json:"issuerSubject"`
+	SigAlg                 string   `json:"sigAlg"`
+	RevocationInfo         int      `json:"revocationInfo"`
+	CrlURIs                []string `json:"crlURIs"`
+	OcspURIs               []string `json:"ocspURIs"`
+	RevocationStatus       int      `json:"revocationStatus"`
+	CrlRevocationStatus    int      `json:"crlRevocationStatus"`
+	OcspRevocationStatus   int      `json:"ocspRevocationStatus"`
+	DNSCaa                 bool     `json:"dnsCaa"`
+	MustStaple             bool     `json:"mustStaple"`
+	SGC                    int      `json:"sgc"`
+	ValidationType         string   `json:"validationType"`
+	Issues                 int      `json:"issues"`
+	SCT                    bool     `json:"sct"`
+	Sha1Hash               string   `json:"sha1Hash"`
+	Sha256Hash             string   `json:"sha256Hash"`
+	PinSha256              string   `json:"pinSha256"`
+	KeyAlg                 string   `json:"keyAlg"`
+	KeySize                int      `json:"keySize"`
+	KeyStrength            int      `json:"keyStrength"`
+	KeyKnownDebianInsecure bool     `json:"keyKnownDebianInsecure"`
+	Raw                    string   `json:"raw"`
+}
+
+// CertChain es una cadena de certificados de las API v3 y v4 (ids de Host.Certs, del leaf a la raíz)
+type CertChain struct {
+	ID         string      `json:"id"`
+	CertIDs    []string    `json:"certIds"`
+	TrustPaths []TrustPath `json:"trustPaths"`
+	Issues     int         `json:"issues"`
+	NoSni      bool        `json:"noSni"`
+}
+
+type TrustPath struct {
+	CertIDs  []string     `json:"certIds"`
+	Trust    []TrustStore `json:"trust"`
+	IsPinned bool         `json:"isPinned"`
+}
+
+type TrustStore struct {
+	RootStore         string `json:"rootStore"`
+	IsTrusted         bool   `json:"isTrusted"`
+	TrustErrorMessage string `json:"trustErrorMessage"`
+}
+
 type Protocol struct {
 	ID               int    `json:"id"`
 	Name             string `json:"name"`
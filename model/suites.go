@@ -0,0 +1,44 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// protocolSuites es la forma de las suites en las API v3 y v4: una lista por protocolo
+type protocolSuites struct {
+	Protocol   int     `json:"protocol"`
+	List       []Suite `json:"list"`
+	Preference bool    `json:"preference"`
+}
+
+// UnmarshalJSON acepta tanto el objeto de la API v2 como el arreglo por protocolo
+// de las API v3 y v4, que se combina en una sola lista sin duplicados
+func (s *Suites) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		type plain Suites
+		return json.Unmarshal(data, (*plain)(s))
+	}
+
+	var perProtocol []protocolSuites
+	if err := json.Unmarshal(data, &perProtocol); err != nil {
+		return err
+	}
+
+	seen := make(map[int]bool)
+	s.List = nil
+	s.Preference = false
+	for _, group := range perProtocol {
+		s.Preference = s.Preference || group.Preference
+		for _, suite := range group.List {
+			if seen[suite.ID] {
+				continue
+			}
+			seen[suite.ID] = true
+			s.List = append(s.List, suite)
+		}
+	}
+
+	return nil
+}
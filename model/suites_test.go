@@ -0,0 +1,69 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func suiteIDs(suites *Suites) []int {
+	ids := []int{}
+	for _, suite := range suites.List {
+		ids = append(ids, suite.ID)
+	}
+	return ids
+}
+
+func TestSuitesUnmarshalV2(t *testing.T) {
+	var suites Suites
+	data := `{"list":[{"id":49199,"name":"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256","cipherStrength":128}],"preference":true}`
+	if err := json.Unmarshal([]byte(data), &suites); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if !suites.Preference || len(suites.List) != 1 || suites.List[0].CipherStrength != 128 {
+		t.Errorf("suites = %+v", suites)
+	}
+}
+
+func TestSuitesUnmarshalPerProtocol(t *testing.T) {
+	var details EndpointDetails
+	data := ` {"suites": [
+		{"protocol":771,"list":[{"id":49199,"name":"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},{"id":10,"name":"TLS_RSA_WITH_3DES_EDE_CBC_SHA"}]},
+		{"protocol":770,"list":[{"id":10,"name":"TLS_RSA_WITH_3DES_EDE_CBC_SHA"}],"preference":true},
+		{"protocol":772,"list":[{"id":4865,"name":"TLS_AES_128_GCM_SHA256"}]}
+	]}`
+	if err := json.Unmarshal([]byte(data), &details); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	suites := details.Suites
+	if suites == nil {
+		t.Fatal("no se decodificaron las suites")
+	}
+	if got := suiteIDs(suites); len(got) != 3 || got[0] != 49199 || got[1] != 10 || got[2] != 4865 {
+		t.Errorf("suites = %v, se esperaba la unión sin duplicados en orden", got)
+	}
+	if !suites.Preference {
+		t.Error("la preferencia de cualquier protocolo debería conservarse")
+	}
+}
+
+func TestSuitesUnmarshalReplacesPrevious(t *testing.T) {
+	suites := Suites{List: []Suite{{ID: 1}}, Preference: true}
+	if err := json.Unmarshal([]byte(`[{"protocol":771,"list":[{"id":2}]}]`), &suites); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if got := suiteIDs(&suites); len(got) != 1 || got[0] != 2 || suites.Preference {
+		t.Errorf("suites = %+v", suites)
+	}
+}
+
+func TestSuitesUnmarshalInvalid(t *testing.T) {
+	var suites Suites
+	for _, data := range []string{`[{"protocol":"771"}]`, `{"list":3}`} {
+		if err := json.Unmarshal([]byte(data), &suites); err == nil {
+			t.Errorf("Unmarshal(%s) no falló", data)
+		}
+	}
+}
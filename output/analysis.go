@@ -0,0 +1,236 @@
+package output
+
+import (
+	"fmt"
+	"time"
+
+	"sslscanner/model"
+)
+
+// Report es el análisis de un host ya interpretado: qué protocolos son
+// inseguros, qué suites son débiles, qué vulnerabilidades tiene y qué le pasa
+// al certificado. Los renderizadores solo deciden cómo mostrarlo.
+type Report struct {
+	Host            string
+	Port            int
+	Protocol        string
+	Status          string
+	StatusMessage   string
+	TestTime        time.Time
+	EngineVersion   string
+	CriteriaVersion string
+	Endpoints       []EndpointReport
+}
+
+// EndpointReport es el análisis de un endpoint
+type EndpointReport struct {
+	IPAddress         string
+	ServerName        string
+	Grade             string
+	GradeTrustIgnored string
+	HasWarnings       bool
+	IsExceptional     bool
+	Duration          time.Duration
+	// Ready es falso si SSL Labs no pudo terminar el análisis del endpoint;
+	// Status y StatusDetail dicen por qué
+	Ready        bool
+	Status       string
+	StatusDetail string
+	// Findings es nil si SSL Labs no devolvió los detalles del endpoint
+	Findings *Findings
+}
+
+// Findings son los hallazgos de un endpoint
+type Findings struct {
+	Protocols       []ProtocolFinding
+	Suites          SuiteFindings
+	Vulnerabilities []Vulnerability
+	ForwardSecrecy  string
+	// HSTS es nil si SSL Labs no informó la política
+	HSTS         *HSTSFinding
+	OCSPStapling bool
+	FallbackSCSV bool
+	// Certificate es nil si SSL Labs no informó el certificado
+	Certificate *CertificateFinding
+}
+
+// ProtocolFinding es un protocolo aceptado con su estado (ProtocolOK, ProtocolDeprecated, ...)
+type ProtocolFinding struct {
+	Name    string
+	Version string
+	Status  string
+}
+
+// SuiteFindings clasifica las cipher suites. Las suites que no son débiles
+// pero tienen menos de 128 bits no están en Weak ni en Strong.
+type SuiteFindings struct {
+	Total            int
+	ServerPreference bool
+	Weak             []SuiteFinding
+	Strong           []SuiteFinding
+}
+
+type SuiteFinding struct {
+	Name     string
+	Strength int
+}
+
+type HSTSFinding struct {
+	Enabled           bool
+	MaxAge            int64
+	IncludeSubDomains bool
+	Preload           bool
+}
+
+type CertificateFinding struct {
+	Subject            string
+	Issuer             string
+	SignatureAlgorithm string
+	NotBefore          time.Time
+	NotAfter           time.Time
+	// DaysRemaining y Expired solo tienen sentido si NotAfter no es cero
+	DaysRemaining int
+	Expired       bool
+	AltNames      []string
+	Issues        []CertIssue
+}
+
+// Analyze interpreta el resultado de SSL Labs de un host
+func Analyze(host *model.Host) *Report {
+	report := &Report{
+		Host:            host.Host,
+		Port:            host.Port,
+		Protocol:        host.Protocol,
+		Status:          host.Status,
+		StatusMessage:   host.StatusMessage,
+		EngineVersion:   host.EngineVersion,
+		CriteriaVersion: host.CriteriaVersion,
+		Endpoints:       make([]EndpointReport, 0, len(host.Endpoints)),
+	}
+
+	if host.TestTime > 0 {
+		report.TestTime = time.UnixMilli(host.TestTime)
+	}
+
+	for _, endpoint := range host.Endpoints {
+		report.Endpoints = append(report.Endpoints, analyzeEndpoint(endpoint))
+	}
+
+	return report
+}
+
+// unfinishedHost indica si el host no terminó el análisis o no tiene endpoints,
+// con el mensaje que lo explica
+func unfinishedHost(host *model.Host) (string, bool) {
+	if host.Status == "READY" && len(host.Endpoints) > 0 {
+		return "", false
+	}
+	if host.StatusMessage != "" {
+		return host.StatusMessage, true
+	}
+	return fmt.Sprintf("análisis sin endpoints (estado %s)", host.Status), true
+}
+
+func analyzeEndpoint(endpoint model.Endpoint) EndpointReport {
+	result := EndpointReport{
+		IPAddress:         endpoint.IPAddress,
+		ServerName:        endpoint.ServerName,
+		Grade:             endpoint.Grade,
+		GradeTrustIgnored: endpoint.GradeTrustIgnored,
+		HasWarnings:       endpoint.HasWarnings,
+		IsExceptional:     endpoint.IsExceptional,
+		Duration:          time.Duration(endpoint.Duration) * time.Millisecond,
+		Ready:             endpoint.StatusMessage == "Ready",
+		Status:            endpoint.StatusMessage,
+		StatusDetail:      endpoint.StatusDetailsMessage,
+	}
+
+	if endpoint.Details != nil {
+		result.Findings = analyzeDetails(endpoint.Details)
+	}
+
+	return result
+}
+
+func analyzeDetails(details *model.EndpointDetails) *Findings {
+	findings := &Findings{
+		Protocols:       make([]ProtocolFinding, 0, len(details.Protocols)),
+		Vulnerabilities: detectVulnerabilities(details),
+		ForwardSecrecy:  forwardSecrecyLevel(details.ForwardSecrecy),
+		OCSPStapling:    details.OcspStapling,
+		FallbackSCSV:    details.FallbackScsv,
+	}
+
+	for _, proto := range details.Protocols {
+		findings.Protocols = append(findings.Protocols, ProtocolFinding{
+			Name:    proto.Name,
+			Version: proto.Version,
+			Status:  classifyProtocol(proto),
+		})
+	}
+
+	if details.Suites != nil {
+		findings.Suites = analyzeSuites(details.Suites)
+	}
+
+	if details.HstsPolicy != nil {
+		findings.HSTS = &HSTSFinding{
+			Enabled:           details.HstsPolicy.Status == "present",
+			MaxAge:            details.HstsPolicy.MaxAge,
+			IncludeSubDomains: details.HstsPolicy.IncludeSubDomains,
+			Preload:           details.HstsPolicy.Preload,
+		}
+	}
+
+	if details.Cert != nil {
+		findings.Certificate = analyzeCertificate(details.Cert)
+	}
+
+	return findings
+}
+
+func analyzeSuites(suites *model.Suites) SuiteFindings {
+	result := SuiteFindings{
+		Total:            len(suites.List),
+		ServerPreference: suites.Preference,
+		Weak:             []SuiteFinding{},
+		Strong:           []SuiteFinding{},
+	}
+
+	for _, suite := range suites.List {
+		finding := SuiteFinding{Name: suite.Name, Strength: suite.CipherStrength}
+		if isWeakSuite(suite) {
+			result.Weak = append(result.Weak, finding)
+		} else if suite.CipherStrength >= 128 {
+			result.Strong = append(result.Strong, finding)
+		}
+	}
+
+	return result
+}
+
+func analyzeCertificate(cert *model.Cert) *CertificateFinding {
+	result := &CertificateFinding{
+		Subject:            cert.Subject,
+		Issuer:             cert.IssuerLabel,
+		SignatureAlgorithm: cert.SigAlg,
+		AltNames:           cert.AltNames,
+		Issues:             decodeCertIssues(cert.Issues),
+	}
+
+	if result.AltNames == nil {
+		result.AltNames = []string{}
+	}
+
+	if cert.NotBefore > 0 {
+		result.NotBefore = time.UnixMilli(cert.NotBefore)
+	}
+
+	if cert.NotAfter > 0 {
+		result.NotAfter = time.UnixMilli(cert.NotAfter)
+		result.DaysRemaining = int(time.Until(result.NotAfter).Hours() / 24)
+		result.Expired = time.Now().After(result.NotAfter)
+	}
+
+	return result
+}
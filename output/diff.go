@@ -0,0 +1,98 @@
+package output
+
+import (
+	"fmt"
+
+	"sslscanner/diff"
+)
+
+// diffCategoryLabels son los nombres de las categorías en el reporte
+var diffCategoryLabels = map[string]string{
+	diff.CategoryStatus:        "Estado",
+	diff.CategoryGrade:         "Calificación",
+	diff.CategoryProtocol:      "Protocolo",
+	diff.CategorySuite:         "Suite",
+	diff.CategoryVulnerability: "Vulnerabilidad",
+	diff.CategoryCertificate:   "Certificado",
+	diff.CategoryHSTS:          "HSTS",
+}
+
+// PrintDiff muestra los cambios entre dos análisis; las regresiones en rojo y
+// las mejoras en verde
+func (f *Formatter) PrintDiff(report *diff.Report) {
+	fmt.Fprintln(f.w, f.separator())
+	fmt.Fprintf(f.w, "%s CAMBIOS EN %s %s\n", f.bold(""), report.Host, f.reset())
+	if !report.BeforeTime.IsZero() && !report.AfterTime.IsZero() {
+		fmt.Fprintf(f.w, "Análisis anterior: %s\n", report.BeforeTime.Local().Format("2006-01-02 15:04:05"))
+		fmt.Fprintf(f.w, "Análisis actual:   %s\n", report.AfterTime.Local().Format("2006-01-02 15:04:05"))
+	}
+	fmt.Fprintln(f.w, f.separator())
+
+	if !report.HasChanges() {
+		fmt.Fprintf(f.w, "  %s\n", f.colorize("✓ Sin cambios", ColorGreen))
+		fmt.Fprintln(f.w, f.separator())
+		return
+	}
+
+	for _, endpoint := range report.Endpoints {
+		switch endpoint.Kind {
+		case diff.Added:
+			fmt.Fprintf(f.w, "\n%s\n", f.colorize("+ Endpoint nuevo: "+endpointLabel(endpoint), ColorYellow))
+			continue
+		case diff.Removed:
+			fmt.Fprintf(f.w, "\n%s\n", f.colorize("- Endpoint eliminado: "+endpointLabel(endpoint), ColorYellow))
+			continue
+		}
+
+		if len(endpoint.Changes) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(f.w, "\n%s %s %s\n", f.bold(""), endpointLabel(endpoint), f.reset())
+		if endpoint.PreviousIP != "" {
+			fmt.Fprintf(f.w, "  (antes %s)\n", endpoint.PreviousIP)
+		}
+
+		for _, change := range endpoint.Changes {
+			color := ColorGreen
+			if change.Regression() {
+				color = ColorRed
+			}
+			fmt.Fprintf(f.w, "  %s\n", f.colorize(describeChange(change), color))
+		}
+	}
+
+	fmt.Fprintln(f.w, f.separator())
+}
+
+func endpointLabel(endpoint diff.EndpointDiff) string {
+	if endpoint.ServerName != "" && endpoint.ServerName != endpoint.IPAddress {
+		return fmt.Sprintf("%s (%s)", endpoint.IPAddress, endpoint.ServerName)
+	}
+	return endpoint.IPAddress
+}
+
+func describeChange(change diff.Change) string {
+	label := diffCategoryLabels[change.Category]
+
+	switch change.Kind {
+	case diff.Added:
+		return fmt.Sprintf("+ %s %s", label, change.Item)
+	case diff.Removed:
+		return fmt.Sprintf("- %s %s", label, change.Item)
+	}
+
+	before, after := change.Before, change.After
+	if before == "" {
+		before = "-"
+	}
+	if after == "" {
+		after = "-"
+	}
+
+	// «Calificación: A → B» se entiende sin el nombre del campo
+	if change.Category == diff.CategoryGrade && change.Item == "grade" {
+		return fmt.Sprintf("~ %s: %s → %s", label, before, after)
+	}
+	return fmt.Sprintf("~ %s %s: %s → %s", label, change.Item, before, after)
+}
@@ -0,0 +1,79 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"sslscanner/expiry"
+)
+
+// expiryStatusLabels son los estados de vencimiento en la tabla
+var expiryStatusLabels = map[string]string{
+	expiry.StatusOK:       "OK",
+	expiry.StatusWarning:  "AVISO",
+	expiry.StatusCritical: "CRÍTICO",
+	expiry.StatusExpired:  "VENCIDO",
+}
+
+var expiryStatusColors = map[string]string{
+	expiry.StatusOK:       ColorGreen,
+	expiry.StatusWarning:  ColorYellow,
+	expiry.StatusCritical: ColorRed,
+	expiry.StatusExpired:  ColorRed,
+}
+
+// PrintExpiry muestra los certificados ordenados por días restantes
+func (f *Formatter) PrintExpiry(report *expiry.Report) {
+	domainWidth, subjectWidth := len("Dominio"), len("Certificado")
+	for _, cert := range report.Certificates {
+		domainWidth = max(domainWidth, utf8.RuneCountInString(cert.Domain))
+		subjectWidth = max(subjectWidth, utf8.RuneCountInString(cert.Subject))
+	}
+	subjectWidth = min(subjectWidth, 40)
+
+	fmt.Fprintln(f.w, f.separator())
+	fmt.Fprintf(f.w, "%s VENCIMIENTO DE CERTIFICADOS %s\n", f.bold(""), f.reset())
+	fmt.Fprintf(f.w, "Aviso: menos de %d días  Crítico: menos de %d días\n",
+		report.Thresholds.Warning, report.Thresholds.Critical)
+	fmt.Fprintln(f.w, f.separator())
+
+	if len(report.Certificates) > 0 {
+		fmt.Fprintf(f.w, "%s  %s  %s  %s  %s  %s  %s\n", padRight("Estado", 8), padRight("Días", 5),
+			padRight("Vence", 10), padRight("Dominio", domainWidth), padRight("Tipo", 6),
+			padRight("Certificado", subjectWidth), "IPs")
+	}
+
+	for _, cert := range report.Certificates {
+		// el padding se aplica antes de colorear para no contar los códigos ANSI
+		status := f.colorize(padRight(expiryStatusLabels[cert.Status], 8), expiryStatusColors[cert.Status])
+		position := "leaf"
+		if cert.Position == expiry.PositionChain {
+			position = "cadena"
+		}
+
+		fmt.Fprintf(f.w, "%s  %s  %s  %s  %s  %s  %s\n", status, padRight(fmt.Sprint(cert.DaysRemaining), 5),
+			cert.NotAfter.Format("2006-01-02"), padRight(cert.Domain, domainWidth), padRight(position, 6),
+			padRight(truncate(cert.Subject, subjectWidth), subjectWidth), strings.Join(cert.IPAddresses, ", "))
+	}
+
+	fmt.Fprintln(f.w, f.separator())
+	fmt.Fprintf(f.w, "Certificados: %d  Vencidos: %d  Críticos: %d  Avisos: %d\n", len(report.Certificates),
+		report.Count(expiry.StatusExpired), report.Count(expiry.StatusCritical), report.Count(expiry.StatusWarning))
+
+	if len(report.Errors) > 0 {
+		fmt.Fprintf(f.w, "\n%s\n", f.colorize("Errores:", ColorRed))
+		for _, hostErr := range report.Errors {
+			fmt.Fprintf(f.w, "  ✗ %s: %s\n", hostErr.Domain, hostErr.Error)
+		}
+	}
+}
+
+// truncate acorta el texto a width caracteres visibles
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,141 @@
+package output
+
+import (
+	"sslscanner/model"
+)
+
+// Severidades normalizadas de las vulnerabilidades
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+)
+
+// Estados normalizados de un protocolo
+const (
+	ProtocolOK         = "ok"
+	ProtocolDeprecated = "deprecated"
+	ProtocolObsolete   = "obsolete"
+	ProtocolInsecure   = "insecure"
+)
+
+// Niveles normalizados de forward secrecy
+const (
+	ForwardSecrecyFull    = "full"
+	ForwardSecrecyModern  = "modern"
+	ForwardSecrecyLimited = "limited"
+	ForwardSecrecyNone    = "none"
+)
+
+// Vulnerability es una vulnerabilidad conocida con su severidad normalizada
+type Vulnerability struct {
+	ID       string
+	Name     string
+	Severity string
+}
+
+// CertIssue es un problema del certificado según el campo issues de SSL Labs
+type CertIssue struct {
+	Flag        string
+	Description string
+}
+
+// certIssueFlags decodifica el campo issues de model.Cert, en orden de bit
+var certIssueFlags = []struct {
+	bit int
+	CertIssue
+}{
+	{1, CertIssue{"noChainOfTrust", "Sin cadena de confianza"}},
+	{2, CertIssue{"notYetValid", "Certificado aún no válido"}},
+	{4, CertIssue{"expired", "Certificado expirado"}},
+	{8, CertIssue{"hostnameMismatch", "Nombre de host no coincide"}},
+	{16, CertIssue{"revoked", "Certificado revocado"}},
+	{32, CertIssue{"badCommonName", "Common name incorrecto"}},
+	{64, CertIssue{"selfSigned", "Certificado autofirmado"}},
+	{128, CertIssue{"blacklisted", "Certificado en lista negra"}},
+	{256, CertIssue{"insecureSignature", "Firma insegura"}},
+}
+
+// vulnerabilityCheck es una vulnerabilidad y si el endpoint la tiene
+type vulnerabilityCheck struct {
+	Vulnerability
+	vulnerable bool
+}
+
+// checkVulnerabilities evalúa heartbleed, poodle, beast, freak, logjam, rc4,
+// openssl ccs y poodle tls en el orden en que se muestran en el reporte
+func checkVulnerabilities(details *model.EndpointDetails) []vulnerabilityCheck {
+	return []vulnerabilityCheck{
+		{Vulnerability{"heartbleed", "Heartbleed (CVE-2014-0160)", SeverityCritical}, details.Heartbleed},
+		{Vulnerability{"poodle", "POODLE (SSLv3)", SeverityHigh}, details.Poodle},
+		{Vulnerability{"beast", "BEAST", SeverityMedium}, details.VulnBeast},
+		{Vulnerability{"freak", "FREAK", SeverityHigh}, details.Freak},
+		{Vulnerability{"logjam", "Logjam", SeverityHigh}, details.Logjam},
+		{Vulnerability{"rc4", "Soporta RC4", SeverityMedium}, details.SupportsRC4},
+		// OpenSSL CCS (CVE-2014-0224): 2 y 3 indican servidor vulnerable
+		{Vulnerability{"opensslCcs", "OpenSSL CCS (CVE-2014-0224)", SeverityCritical}, details.OpenSSLCcs >= 2},
+		{Vulnerability{"poodleTls", "POODLE TLS", SeverityHigh}, details.PoodleTLS == 2},
+	}
+}
+
+// detectVulnerabilities devuelve solo las vulnerabilidades presentes
+func detectVulnerabilities(details *model.EndpointDetails) []Vulnerability {
+	found := []Vulnerability{}
+	for _, check := range checkVulnerabilities(details) {
+		if check.vulnerable {
+			found = append(found, check.Vulnerability)
+		}
+	}
+
+	return found
+}
+
+// classifyProtocol evalúa si un protocolo es seguro, deprecado u obsoleto
+func classifyProtocol(proto model.Protocol) string {
+	// Los protocolos con Q=0 son inseguros
+	if proto.Q != nil && *proto.Q == 0 {
+		return ProtocolInsecure
+	}
+
+	// SSLv2 y SSLv3 siempre son inseguros
+	if proto.Name == "SSL" {
+		return ProtocolObsolete
+	}
+
+	// TLS 1.0 y 1.1 están deprecados
+	if proto.Name == "TLS" && (proto.Version == "1.0" || proto.Version == "1.1") {
+		return ProtocolDeprecated
+	}
+
+	return ProtocolOK
+}
+
+// isWeakSuite indica si SSL Labs marcó la suite como débil (Q=0)
+func isWeakSuite(suite model.Suite) bool {
+	return suite.Q != nil && *suite.Q == 0
+}
+
+// decodeCertIssues traduce la máscara de bits de problemas del certificado
+func decodeCertIssues(issues int) []CertIssue {
+	found := []CertIssue{}
+	for _, issue := range certIssueFlags {
+		if issues&issue.bit != 0 {
+			found = append(found, issue.CertIssue)
+		}
+	}
+	return found
+}
+
+// forwardSecrecyLevel traduce el campo forwardSecrecy a un nivel normalizado
+func forwardSecrecyLevel(forwardSecrecy int) string {
+	switch {
+	case forwardSecrecy >= 4:
+		return ForwardSecrecyFull
+	case forwardSecrecy >= 2:
+		return ForwardSecrecyModern
+	case forwardSecrecy >= 1:
+		return ForwardSecrecyLimited
+	default:
+		return ForwardSecrecyNone
+	}
+}
@@ -2,8 +2,9 @@ package output
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
-	"time"
 
 	"sslscanner/model"
 )
@@ -17,331 +18,278 @@ const (
 	ColorBold   = "\033[1m"
 )
 
+// Formatter es el renderizador de texto: escribe los reportes en w
 type Formatter struct {
+	w         io.Writer
 	useColors bool
 }
 
-func NewFormatter(useColors bool) *Formatter {
+// NewFormatter crea un Formatter que escribe en w. Si w es un archivo, los
+// colores se desactivan solos cuando no es una terminal o cuando NO_COLOR tiene
+// un valor.
+func NewFormatter(w io.Writer, useColors bool) *Formatter {
+	if file, ok := w.(*os.File); ok {
+		useColors = useColors && ColorsEnabled(file)
+	}
 	return &Formatter{
+		w:         w,
 		useColors: useColors,
 	}
 }
 
 // PrintReport imprime el reporte completo de todos los endpoints
 func (f *Formatter) PrintReport(host *model.Host) {
-	f.printHeader(host)
+	f.RenderReport(Analyze(host))
+}
+
+// RenderReport imprime un reporte ya analizado
+func (f *Formatter) RenderReport(report *Report) {
+	f.printHeader(report)
 
-	for i, endpoint := range host.Endpoints {
+	for i, endpoint := range report.Endpoints {
 		f.printEndpointSummary(endpoint, i+1)
 
 		// si el endpoint no completó el análisis, mostrar el error
-		if endpoint.StatusMessage != "Ready" {
+		if !endpoint.Ready {
 			f.printEndpointError(endpoint)
-			fmt.Println(f.separator())
+			fmt.Fprintln(f.w, f.separator())
 			continue
 		}
 
-		if endpoint.Details != nil {
-			f.printProtocols(endpoint.Details.Protocols)
-			f.printCipherSuites(endpoint.Details.Suites)
-			f.printVulnerabilities(endpoint.Details)
-			f.printCertificateInfo(endpoint.Details.Cert)
+		if findings := endpoint.Findings; findings != nil {
+			f.printProtocols(findings.Protocols)
+			f.printCipherSuites(findings.Suites)
+			f.printVulnerabilities(findings)
+			f.printCertificateInfo(findings.Certificate)
 		}
 
-		fmt.Println(f.separator())
+		fmt.Fprintln(f.w, f.separator())
 	}
 }
 
-func (f *Formatter) printEndpointError(endpoint model.Endpoint) {
-	fmt.Printf("\n%sEstado: %s%s\n", ColorRed, endpoint.StatusMessage, ColorReset)
-	if endpoint.StatusDetailsMessage != "" {
-		fmt.Printf("Detalle: %s\n", endpoint.StatusDetailsMessage)
+func (f *Formatter) printEndpointError(endpoint EndpointReport) {
+	fmt.Fprintf(f.w, "\n%s\n", f.colorize("Estado: "+endpoint.Status, ColorRed))
+	if endpoint.StatusDetail != "" {
+		fmt.Fprintf(f.w, "Detalle: %s\n", endpoint.StatusDetail)
 	}
 }
 
-func (f *Formatter) printHeader(host *model.Host) {
-	fmt.Println(f.separator())
-	fmt.Printf("%s REPORTE DE ANÁLISIS TLS - SSL Labs %s\n", f.bold(""), f.reset())
-	fmt.Println(f.separator())
-	fmt.Printf("Dominio: %s\n", f.colorize(host.Host, ColorBlue))
-	fmt.Printf("Puerto: %d\n", host.Port)
-	fmt.Printf("Protocolo: %s\n", host.Protocol)
+func (f *Formatter) printHeader(report *Report) {
+	fmt.Fprintln(f.w, f.separator())
+	fmt.Fprintf(f.w, "%s REPORTE DE ANÁLISIS TLS - SSL Labs %s\n", f.bold(""), f.reset())
+	fmt.Fprintln(f.w, f.separator())
+	fmt.Fprintf(f.w, "Dominio: %s\n", f.colorize(report.Host, ColorBlue))
+	fmt.Fprintf(f.w, "Puerto: %d\n", report.Port)
+	fmt.Fprintf(f.w, "Protocolo: %s\n", report.Protocol)
 
-	if host.TestTime > 0 {
-		testTime := time.UnixMilli(host.TestTime)
-		fmt.Printf("Fecha del análisis: %s\n", testTime.Format("2006-01-02 15:04:05"))
+	if !report.TestTime.IsZero() {
+		fmt.Fprintf(f.w, "Fecha del análisis: %s\n", report.TestTime.Local().Format("2006-01-02 15:04:05"))
 	}
 
-	fmt.Printf("Motor SSL Labs: %s\n", host.EngineVersion)
-	fmt.Printf("Criterios de evaluación: %s\n", host.CriteriaVersion)
-	fmt.Println(f.separator())
+	fmt.Fprintf(f.w, "Motor SSL Labs: %s\n", report.EngineVersion)
+	fmt.Fprintf(f.w, "Criterios de evaluación: %s\n", report.CriteriaVersion)
+	fmt.Fprintln(f.w, f.separator())
 }
 
-func (f *Formatter) printEndpointSummary(endpoint model.Endpoint, index int) {
-	fmt.Printf("\n%s ENDPOINT #%d %s\n", f.bold(""), index, f.reset())
-	fmt.Printf("IP: %s\n", endpoint.IPAddress)
+func (f *Formatter) printEndpointSummary(endpoint EndpointReport, index int) {
+	fmt.Fprintf(f.w, "\n%s ENDPOINT #%d %s\n", f.bold(""), index, f.reset())
+	fmt.Fprintf(f.w, "IP: %s\n", endpoint.IPAddress)
 
 	if endpoint.ServerName != "" {
-		fmt.Printf("Nombre del servidor: %s\n", endpoint.ServerName)
+		fmt.Fprintf(f.w, "Nombre del servidor: %s\n", endpoint.ServerName)
 	}
 
 	gradeColor := f.getGradeColor(endpoint.Grade)
-	fmt.Printf("Calificación: %s\n", f.colorize(endpoint.Grade, gradeColor))
+	fmt.Fprintf(f.w, "Calificación: %s\n", f.colorize(endpoint.Grade, gradeColor))
 
 	if endpoint.GradeTrustIgnored != "" && endpoint.GradeTrustIgnored != endpoint.Grade {
-		fmt.Printf("Calificación (ignorando confianza): %s\n", endpoint.GradeTrustIgnored)
+		fmt.Fprintf(f.w, "Calificación (ignorando confianza): %s\n", endpoint.GradeTrustIgnored)
 	}
 
 	if endpoint.HasWarnings {
-		fmt.Printf("%s⚠ Este endpoint tiene advertencias que pueden afectar la calificación%s\n",
-			ColorYellow, ColorReset)
+		fmt.Fprintln(f.w, f.colorize("⚠ Este endpoint tiene advertencias que pueden afectar la calificación", ColorYellow))
 	}
 
 	if endpoint.IsExceptional {
-		fmt.Printf("%s★ Configuración excepcional detectada%s\n", ColorGreen, ColorReset)
+		fmt.Fprintln(f.w, f.colorize("★ Configuración excepcional detectada", ColorGreen))
 	}
 
-	fmt.Printf("Duración del análisis: %dms\n", endpoint.Duration)
+	fmt.Fprintf(f.w, "Duración del análisis: %dms\n", endpoint.Duration.Milliseconds())
 }
 
-func (f *Formatter) printProtocols(protocols []model.Protocol) {
-	fmt.Printf("\n%s Protocolos Soportados %s\n", f.bold(""), f.reset())
+func (f *Formatter) printProtocols(protocols []ProtocolFinding) {
+	fmt.Fprintf(f.w, "\n%s Protocolos Soportados %s\n", f.bold(""), f.reset())
 
 	if len(protocols) == 0 {
-		fmt.Println("  No se encontraron protocolos.")
+		fmt.Fprintln(f.w, "  No se encontraron protocolos.")
 		return
 	}
 
 	for _, proto := range protocols {
-		status := f.getProtocolStatus(proto)
-		fmt.Printf("  • %s %s: %s\n", proto.Name, proto.Version, status)
+		fmt.Fprintf(f.w, "  • %s %s: %s\n", proto.Name, proto.Version, f.protocolStatus(proto.Status))
 	}
 }
 
-func (f *Formatter) getProtocolStatus(proto model.Protocol) string {
-	// Los protocolos con Q=0 son inseguros
-	if proto.Q != nil && *proto.Q == 0 {
+func (f *Formatter) protocolStatus(status string) string {
+	switch status {
+	case ProtocolInsecure:
 		return f.colorize("INSEGURO", ColorRed)
-	}
-
-	// SSLv2 y SSLv3 siempre son inseguros
-	if proto.Name == "SSL" {
+	case ProtocolObsolete:
 		return f.colorize("INSEGURO (obsoleto)", ColorRed)
-	}
-
-	// TLS 1.0 y 1.1 están deprecados
-	if proto.Name == "TLS" && (proto.Version == "1.0" || proto.Version == "1.1") {
+	case ProtocolDeprecated:
 		return f.colorize("DEPRECADO", ColorYellow)
+	default:
+		return f.colorize("OK", ColorGreen)
 	}
-
-	return f.colorize("OK", ColorGreen)
 }
 
-func (f *Formatter) printCipherSuites(suites *model.Suites) {
-	fmt.Printf("\n%s Cipher Suites %s\n", f.bold(""), f.reset())
+func (f *Formatter) printCipherSuites(suites SuiteFindings) {
+	fmt.Fprintf(f.w, "\n%s Cipher Suites %s\n", f.bold(""), f.reset())
 
-	if suites == nil || len(suites.List) == 0 {
-		fmt.Println("  No se encontraron cipher suites.")
+	if suites.Total == 0 {
+		fmt.Fprintln(f.w, "  No se encontraron cipher suites.")
 		return
 	}
 
-	if suites.Preference {
-		fmt.Println("  El servidor selecciona activamente las cipher suites.")
+	if suites.ServerPreference {
+		fmt.Fprintln(f.w, "  El servidor selecciona activamente las cipher suites.")
 	}
 
-	weakSuites := []string{}
-	strongSuites := []string{}
+	fmt.Fprintf(f.w, "  Total de suites: %d\n", suites.Total)
 
-	for _, suite := range suites.List {
-		// Las suites con Q=0 son débiles
-		if suite.Q != nil && *suite.Q == 0 {
-			weakSuites = append(weakSuites, fmt.Sprintf("%s (fuerza: %d bits)",
-				suite.Name, suite.CipherStrength))
-		} else if suite.CipherStrength >= 128 {
-			strongSuites = append(strongSuites, fmt.Sprintf("%s (fuerza: %d bits)",
-				suite.Name, suite.CipherStrength))
-		}
-	}
-
-	fmt.Printf("  Total de suites: %d\n", len(suites.List))
-
-	if len(weakSuites) > 0 {
-		fmt.Printf("\n  %sCifrados Débiles Detectados:%s\n", ColorRed, ColorReset)
-		for _, suite := range weakSuites {
-			fmt.Printf("    ✗ %s\n", suite)
+	if len(suites.Weak) > 0 {
+		fmt.Fprintf(f.w, "\n  %s\n", f.colorize("Cifrados Débiles Detectados:", ColorRed))
+		for _, suite := range suites.Weak {
+			fmt.Fprintf(f.w, "    ✗ %s (fuerza: %d bits)\n", suite.Name, suite.Strength)
 		}
 	}
 
 	// Mostrar solo las primeras 5 suites fuertes para no saturar la salida
-	if len(strongSuites) > 0 {
-		fmt.Printf("\n  Cifrados Fuertes (mostrando hasta 5):\n")
-		limit := 5
-		if len(strongSuites) < limit {
-			limit = len(strongSuites)
-		}
-		for i := 0; i < limit; i++ {
-			fmt.Printf("    ✓ %s\n", strongSuites[i])
+	if len(suites.Strong) > 0 {
+		fmt.Fprintf(f.w, "\n  Cifrados Fuertes (mostrando hasta 5):\n")
+		for _, suite := range suites.Strong[:min(5, len(suites.Strong))] {
+			fmt.Fprintf(f.w, "    ✓ %s (fuerza: %d bits)\n", suite.Name, suite.Strength)
 		}
-		if len(strongSuites) > 5 {
-			fmt.Printf("    ... y %d más\n", len(strongSuites)-5)
+		if len(suites.Strong) > 5 {
+			fmt.Fprintf(f.w, "    ... y %d más\n", len(suites.Strong)-5)
 		}
 	}
 }
 
-// printVulnerabilities muestra heartbleed, poodle, beast, freak, logjam, rc4
-func (f *Formatter) printVulnerabilities(details *model.EndpointDetails) {
-	fmt.Printf("\n%s Vulnerabilidades Conocidas %s\n", f.bold(""), f.reset())
-
-	vulnerabilities := []struct {
-		name       string
-		vulnerable bool
-		severity   string
-	}{
-		{"Heartbleed (CVE-2014-0160)", details.Heartbleed, "CRÍTICA"},
-		{"POODLE (SSLv3)", details.Poodle, "ALTA"},
-		{"BEAST", details.VulnBeast, "MEDIA"},
-		{"FREAK", details.Freak, "ALTA"},
-		{"Logjam", details.Logjam, "ALTA"},
-		{"Soporta RC4", details.SupportsRC4, "MEDIA"},
-	}
-
-	foundVulnerabilities := false
-	for _, vuln := range vulnerabilities {
-		if vuln.vulnerable {
-			foundVulnerabilities = true
-			fmt.Printf("  %s✗ %s - Severidad: %s%s\n",
-				ColorRed, vuln.name, vuln.severity, ColorReset)
-		}
-	}
-
-	// Verificar OpenSSL CCS (CVE-2014-0224)
-	if details.OpenSSLCcs >= 2 {
-		foundVulnerabilities = true
-		fmt.Printf("  %s✗ OpenSSL CCS (CVE-2014-0224) - Severidad: CRÍTICA%s\n",
-			ColorRed, ColorReset)
-	}
+// printVulnerabilities muestra las vulnerabilidades detectadas y las
+// características de seguridad del endpoint
+func (f *Formatter) printVulnerabilities(findings *Findings) {
+	fmt.Fprintf(f.w, "\n%s Vulnerabilidades Conocidas %s\n", f.bold(""), f.reset())
 
-	// Verificar POODLE TLS
-	if details.PoodleTLS == 2 {
-		foundVulnerabilities = true
-		fmt.Printf("  %s✗ POODLE TLS - Severidad: ALTA%s\n", ColorRed, ColorReset)
+	for _, vuln := range findings.Vulnerabilities {
+		fmt.Fprintf(f.w, "  %s\n", f.colorize(fmt.Sprintf("✗ %s - Severidad: %s", vuln.Name, severityLabel(vuln.Severity)), ColorRed))
 	}
 
-	if !foundVulnerabilities {
-		fmt.Printf("  %s✓ No se detectaron vulnerabilidades conocidas%s\n",
-			ColorGreen, ColorReset)
+	if len(findings.Vulnerabilities) == 0 {
+		fmt.Fprintf(f.w, "  %s\n", f.colorize("✓ No se detectaron vulnerabilidades conocidas", ColorGreen))
 	}
 
 	// Información adicional de seguridad
-	fmt.Printf("\n%s Características de Seguridad %s\n", f.bold(""), f.reset())
+	fmt.Fprintf(f.w, "\n%s Características de Seguridad %s\n", f.bold(""), f.reset())
 
 	// Forward Secrecy
 	fsStatus := "No soportado"
-	if details.ForwardSecrecy >= 4 {
+	switch findings.ForwardSecrecy {
+	case ForwardSecrecyFull:
 		fsStatus = f.colorize("Completo (todos los clientes)", ColorGreen)
-	} else if details.ForwardSecrecy >= 2 {
+	case ForwardSecrecyModern:
 		fsStatus = f.colorize("Parcial (clientes modernos)", ColorYellow)
-	} else if details.ForwardSecrecy >= 1 {
+	case ForwardSecrecyLimited:
 		fsStatus = f.colorize("Limitado", ColorYellow)
 	}
-	fmt.Printf("  Forward Secrecy: %s\n", fsStatus)
+	fmt.Fprintf(f.w, "  Forward Secrecy: %s\n", fsStatus)
 
 	// HSTS
-	if details.HstsPolicy != nil {
+	if findings.HSTS != nil {
 		hstsStatus := "No configurado"
-		if details.HstsPolicy.Status == "present" {
+		if findings.HSTS.Enabled {
 			hstsStatus = f.colorize("Habilitado", ColorGreen)
-			if details.HstsPolicy.Preload {
+			if findings.HSTS.Preload {
 				hstsStatus += " (con preload)"
 			}
 		}
-		fmt.Printf("  HSTS: %s\n", hstsStatus)
+		fmt.Fprintf(f.w, "  HSTS: %s\n", hstsStatus)
 	}
 
 	// OCSP Stapling
 	ocspStatus := f.colorize("No", ColorYellow)
-	if details.OcspStapling {
+	if findings.OCSPStapling {
 		ocspStatus = f.colorize("Sí", ColorGreen)
 	}
-	fmt.Printf("  OCSP Stapling: %s\n", ocspStatus)
+	fmt.Fprintf(f.w, "  OCSP Stapling: %s\n", ocspStatus)
 
 	// TLS Fallback SCSV
-	if details.FallbackScsv {
-		fmt.Printf("  TLS Fallback SCSV: %s\n", f.colorize("Soportado", ColorGreen))
+	if findings.FallbackSCSV {
+		fmt.Fprintf(f.w, "  TLS Fallback SCSV: %s\n", f.colorize("Soportado", ColorGreen))
 	}
 }
 
-func (f *Formatter) printCertificateInfo(cert *model.Cert) {
+func (f *Formatter) printCertificateInfo(cert *CertificateFinding) {
 	if cert == nil {
 		return
 	}
 
 	// verificar si hay datos útiles
-	if cert.Subject == "" && cert.IssuerLabel == "" && cert.SigAlg == "" {
+	if cert.Subject == "" && cert.Issuer == "" && cert.SignatureAlgorithm == "" {
 		return
 	}
 
-	fmt.Printf("\n%s Información del Certificado %s\n", f.bold(""), f.reset())
+	fmt.Fprintf(f.w, "\n%s Información del Certificado %s\n", f.bold(""), f.reset())
 
 	if cert.Subject != "" {
-		fmt.Printf("  Sujeto: %s\n", cert.Subject)
+		fmt.Fprintf(f.w, "  Sujeto: %s\n", cert.Subject)
 	}
-	if cert.IssuerLabel != "" {
-		fmt.Printf("  Emisor: %s\n", cert.IssuerLabel)
+	if cert.Issuer != "" {
+		fmt.Fprintf(f.w, "  Emisor: %s\n", cert.Issuer)
 	}
-	if cert.SigAlg != "" {
-		fmt.Printf("  Algoritmo de firma: %s\n", cert.SigAlg)
+	if cert.SignatureAlgorithm != "" {
+		fmt.Fprintf(f.w, "  Algoritmo de firma: %s\n", cert.SignatureAlgorithm)
 	}
 
-	if cert.NotBefore > 0 {
-		notBefore := time.UnixMilli(cert.NotBefore)
-		fmt.Printf("  Válido desde: %s\n", notBefore.Format("2006-01-02"))
+	if !cert.NotBefore.IsZero() {
+		fmt.Fprintf(f.w, "  Válido desde: %s\n", cert.NotBefore.Local().Format("2006-01-02"))
 	}
 
-	if cert.NotAfter > 0 {
-		notAfter := time.UnixMilli(cert.NotAfter)
-		fmt.Printf("  Válido hasta: %s\n", notAfter.Format("2006-01-02"))
+	if !cert.NotAfter.IsZero() {
+		fmt.Fprintf(f.w, "  Válido hasta: %s\n", cert.NotAfter.Local().Format("2006-01-02"))
 
-		daysRemaining := int(time.Until(notAfter).Hours() / 24)
-		if daysRemaining < 0 {
-			fmt.Printf("  %s⚠ CERTIFICADO EXPIRADO%s\n", ColorRed, ColorReset)
-		} else if daysRemaining < 30 {
-			fmt.Printf("  %s⚠ El certificado expira en %d días%s\n",
-				ColorYellow, daysRemaining, ColorReset)
+		if cert.DaysRemaining < 0 {
+			fmt.Fprintf(f.w, "  %s\n", f.colorize("⚠ CERTIFICADO EXPIRADO", ColorRed))
+		} else if cert.DaysRemaining < 30 {
+			fmt.Fprintf(f.w, "  %s\n", f.colorize(fmt.Sprintf("⚠ El certificado expira en %d días", cert.DaysRemaining), ColorYellow))
 		}
 	}
 
 	if len(cert.AltNames) > 0 {
-		fmt.Printf("  Nombres alternativos: %s\n", strings.Join(cert.AltNames[:min(5, len(cert.AltNames))], ", "))
+		fmt.Fprintf(f.w, "  Nombres alternativos: %s\n", strings.Join(cert.AltNames[:min(5, len(cert.AltNames))], ", "))
 		if len(cert.AltNames) > 5 {
-			fmt.Printf("    ... y %d más\n", len(cert.AltNames)-5)
+			fmt.Fprintf(f.w, "    ... y %d más\n", len(cert.AltNames)-5)
 		}
 	}
 
 	// Verificar problemas del certificado
-	if cert.Issues > 0 {
-		fmt.Printf("\n  %sProblemas detectados en el certificado:%s\n", ColorRed, ColorReset)
-		f.printCertIssues(cert.Issues)
+	if len(cert.Issues) > 0 {
+		fmt.Fprintf(f.w, "\n  %s\n", f.colorize("Problemas detectados en el certificado:", ColorRed))
+		for _, issue := range cert.Issues {
+			fmt.Fprintf(f.w, "    ✗ %s\n", issue.Description)
+		}
 	}
 }
 
-func (f *Formatter) printCertIssues(issues int) {
-	issueMap := map[int]string{
-		1:   "Sin cadena de confianza",
-		2:   "Certificado aún no válido",
-		4:   "Certificado expirado",
-		8:   "Nombre de host no coincide",
-		16:  "Certificado revocado",
-		32:  "Common name incorrecto",
-		64:  "Certificado autofirmado",
-		128: "Certificado en lista negra",
-		256: "Firma insegura",
-	}
-
-	for bit, desc := range issueMap {
-		if issues&bit != 0 {
-			fmt.Printf("    ✗ %s\n", desc)
-		}
+// severityLabel traduce la severidad normalizada a la etiqueta del reporte
+func severityLabel(severity string) string {
+	switch severity {
+	case SeverityCritical:
+		return "CRÍTICA"
+	case SeverityHigh:
+		return "ALTA"
+	default:
+		return "MEDIA"
 	}
 }
 
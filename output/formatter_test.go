@@ -0,0 +1,136 @@
+package output
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "reescribe los archivos golden de testdata")
+
+// goldenReport tiene todas las secciones del reporte de texto: un endpoint
+// completo con hallazgos en cada categoría y otro que no terminó el análisis
+func goldenReport() *Report {
+	return &Report{
+		Host:            "example.com",
+		Port:            443,
+		Protocol:        "http",
+		Status:          "READY",
+		TestTime:        time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC),
+		EngineVersion:   "2.3.0",
+		CriteriaVersion: "2009q",
+		Endpoints: []EndpointReport{
+			{
+				IPAddress:         "192.0.2.10",
+				ServerName:        "edge.example.com",
+				Grade:             "B",
+				GradeTrustIgnored: "A",
+				HasWarnings:       true,
+				Duration:          95123 * time.Millisecond,
+				Ready:             true,
+				Status:            "Ready",
+				Findings: &Findings{
+					Protocols: []ProtocolFinding{
+						{Name: "TLS", Version: "1.0", Status: ProtocolDeprecated},
+						{Name: "TLS", Version: "1.2", Status: ProtocolOK},
+						{Name: "TLS", Version: "1.3", Status: ProtocolOK},
+					},
+					Suites: SuiteFindings{
+						Total:            4,
+						ServerPreference: true,
+						Weak: []SuiteFinding{
+							{Name: "TLS_RSA_WITH_3DES_EDE_CBC_SHA", Strength: 112},
+						},
+						Strong: []SuiteFinding{
+							{Name: "TLS_AES_128_GCM_SHA256", Strength: 128},
+							{Name: "TLS_AES_256_GCM_SHA384", Strength: 256},
+						},
+					},
+					Vulnerabilities: []Vulnerability{
+						{ID: "poodle-tls", Name: "POODLE (TLS)", Severity: SeverityHigh},
+					},
+					ForwardSecrecy: ForwardSecrecyModern,
+					HSTS:           &HSTSFinding{Enabled: true, MaxAge: 31536000, IncludeSubDomains: true},
+					OCSPStapling:   true,
+					FallbackSCSV:   true,
+					Certificate: &CertificateFinding{
+						Subject:            "CN=example.com",
+						Issuer:             "Example CA",
+						SignatureAlgorithm: "SHA256withRSA",
+						NotBefore:          time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+						NotAfter:           time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC),
+						DaysRemaining:      18,
+						AltNames: []string{
+							"example.com", "www.example.com", "api.example.com",
+							"cdn.example.com", "mail.example.com", "static.example.com",
+						},
+						Issues: []CertIssue{{Flag: "hostnameMismatch", Description: "Nombre de host no coincide"}},
+					},
+				},
+			},
+			{
+				IPAddress:    "2001:db8::10",
+				Grade:        "",
+				Duration:     2 * time.Second,
+				Ready:        false,
+				Status:       "Unable to connect to the server",
+				StatusDetail: "connection refused",
+			},
+		},
+	}
+}
+
+func TestRenderReportGolden(t *testing.T) {
+	// las fechas se muestran en la zona local
+	local := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = local })
+
+	for _, tc := range []struct {
+		name   string
+		colors bool
+	}{
+		{name: "report", colors: false},
+		{name: "report_color", colors: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			NewFormatter(&buf, tc.colors).RenderReport(goldenReport())
+			assertGolden(t, filepath.Join("testdata", tc.name+".golden"), buf.Bytes())
+		})
+	}
+}
+
+func TestPrintPolicyFailures(t *testing.T) {
+	var buf bytes.Buffer
+	formatter := NewFormatter(&buf, false)
+	formatter.PrintPolicyFailures(0)
+	formatter.PrintPolicyFailures(2)
+
+	want := "Dominios que no cumplen la política: 0\nDominios que no cumplen la política: 2\n"
+	if got := buf.String(); got != want {
+		t.Errorf("salida = %q, se esperaba %q", got, want)
+	}
+}
+
+func assertGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("no se pudo escribir %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("no se pudo leer %s (usar -update para crearlo): %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("la salida no coincide con %s\n--- obtenida ---\n%s\n--- esperada ---\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,229 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"sslscanner/model"
+)
+
+// JSONSchemaVersion se incrementa ante cualquier cambio incompatible del documento
+const JSONSchemaVersion = "1.0"
+
+// JSONReport es la vista normalizada y versionada de uno o varios análisis.
+// No es un volcado de la respuesta de SSL Labs: solo contiene campos propios
+// para que el documento se mantenga estable aunque cambie la API.
+type JSONReport struct {
+	SchemaVersion string     `json:"schemaVersion"`
+	GeneratedAt   time.Time  `json:"generatedAt"`
+	Hosts         []JSONHost `json:"hosts"`
+}
+
+type JSONHost struct {
+	Host            string         `json:"host"`
+	Port            int            `json:"port,omitempty"`
+	Status          string         `json:"status"`
+	Error           string         `json:"error,omitempty"`
+	TestTime        *time.Time     `json:"testTime,omitempty"`
+	EngineVersion   string         `json:"engineVersion,omitempty"`
+	CriteriaVersion string         `json:"criteriaVersion,omitempty"`
+	Endpoints       []JSONEndpoint `json:"endpoints"`
+}
+
+type JSONEndpoint struct {
+	IPAddress         string              `json:"ipAddress"`
+	ServerName        string              `json:"serverName,omitempty"`
+	Status            string              `json:"status"`
+	StatusDetail      string              `json:"statusDetail,omitempty"`
+	Grade             string              `json:"grade"`
+	GradeTrustIgnored string              `json:"gradeTrustIgnored,omitempty"`
+	HasWarnings       bool                `json:"hasWarnings"`
+	IsExceptional     bool                `json:"isExceptional"`
+	Protocols         []JSONProtocol      `json:"protocols"`
+	WeakSuites        []JSONSuite         `json:"weakSuites"`
+	Vulnerabilities   []JSONVulnerability `json:"vulnerabilities"`
+	ForwardSecrecy    string              `json:"forwardSecrecy,omitempty"`
+	HSTS              *JSONHSTS           `json:"hsts,omitempty"`
+	OCSPStapling      bool                `json:"ocspStapling"`
+	FallbackSCSV      bool                `json:"fallbackScsv"`
+	Certificate       *JSONCertificate    `json:"certificate,omitempty"`
+}
+
+type JSONProtocol struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Status  string `json:"status"`
+}
+
+type JSONSuite struct {
+	Name     string `json:"name"`
+	Strength int    `json:"strength"`
+}
+
+type JSONVulnerability struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Severity string `json:"severity"`
+}
+
+type JSONHSTS struct {
+	Enabled           bool  `json:"enabled"`
+	MaxAge            int64 `json:"maxAge"`
+	IncludeSubDomains bool  `json:"includeSubDomains"`
+	Preload           bool  `json:"preload"`
+}
+
+type JSONCertificate struct {
+	Subject            string     `json:"subject,omitempty"`
+	Issuer             string     `json:"issuer,omitempty"`
+	SignatureAlgorithm string     `json:"signatureAlgorithm,omitempty"`
+	NotBefore          *time.Time `json:"notBefore,omitempty"`
+	NotAfter           *time.Time `json:"notAfter,omitempty"`
+	DaysRemaining      *int       `json:"daysRemaining,omitempty"`
+	Expired            bool       `json:"expired"`
+	AltNames           []string   `json:"altNames"`
+	Issues             []string   `json:"issues"`
+}
+
+func NewJSONReport() *JSONReport {
+	return &JSONReport{
+		SchemaVersion: JSONSchemaVersion,
+		GeneratedAt:   time.Now().UTC(),
+		Hosts:         []JSONHost{},
+	}
+}
+
+// AddHost agrega la vista normalizada de un análisis terminado
+func (r *JSONReport) AddHost(host *model.Host) {
+	r.Hosts = append(r.Hosts, buildJSONHost(host))
+}
+
+// AddError registra un dominio cuyo análisis falló
+func (r *JSONReport) AddError(domain string, err error) {
+	r.Hosts = append(r.Hosts, JSONHost{
+		Host:      domain,
+		Status:    "ERROR",
+		Error:     err.Error(),
+		Endpoints: []JSONEndpoint{},
+	})
+}
+
+// Write escribe el documento indentado en w
+func (r *JSONReport) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r); err != nil {
+		return fmt.Errorf("falló al codificar reporte JSON: %w", err)
+	}
+	return nil
+}
+
+func buildJSONHost(host *model.Host) JSONHost {
+	report := Analyze(host)
+
+	result := JSONHost{
+		Host:            report.Host,
+		Port:            report.Port,
+		Status:          report.Status,
+		EngineVersion:   report.EngineVersion,
+		CriteriaVersion: report.CriteriaVersion,
+		Endpoints:       make([]JSONEndpoint, 0, len(report.Endpoints)),
+	}
+
+	if report.Status == "ERROR" {
+		result.Error = report.StatusMessage
+	}
+
+	if !report.TestTime.IsZero() {
+		testTime := report.TestTime.UTC()
+		result.TestTime = &testTime
+	}
+
+	for _, endpoint := range report.Endpoints {
+		result.Endpoints = append(result.Endpoints, buildJSONEndpoint(endpoint))
+	}
+
+	return result
+}
+
+func buildJSONEndpoint(endpoint EndpointReport) JSONEndpoint {
+	result := JSONEndpoint{
+		IPAddress:         endpoint.IPAddress,
+		ServerName:        endpoint.ServerName,
+		Status:            endpoint.Status,
+		Grade:             endpoint.Grade,
+		GradeTrustIgnored: endpoint.GradeTrustIgnored,
+		HasWarnings:       endpoint.HasWarnings,
+		IsExceptional:     endpoint.IsExceptional,
+		Protocols:         []JSONProtocol{},
+		WeakSuites:        []JSONSuite{},
+		Vulnerabilities:   []JSONVulnerability{},
+	}
+
+	if !endpoint.Ready {
+		result.StatusDetail = endpoint.StatusDetail
+	}
+
+	findings := endpoint.Findings
+	if findings == nil {
+		return result
+	}
+
+	for _, proto := range findings.Protocols {
+		result.Protocols = append(result.Protocols, JSONProtocol(proto))
+	}
+
+	for _, suite := range findings.Suites.Weak {
+		result.WeakSuites = append(result.WeakSuites, JSONSuite(suite))
+	}
+
+	for _, vuln := range findings.Vulnerabilities {
+		result.Vulnerabilities = append(result.Vulnerabilities, JSONVulnerability(vuln))
+	}
+
+	result.ForwardSecrecy = findings.ForwardSecrecy
+	result.OCSPStapling = findings.OCSPStapling
+	result.FallbackSCSV = findings.FallbackSCSV
+
+	if findings.HSTS != nil {
+		hsts := JSONHSTS(*findings.HSTS)
+		result.HSTS = &hsts
+	}
+
+	if findings.Certificate != nil {
+		result.Certificate = buildJSONCertificate(findings.Certificate)
+	}
+
+	return result
+}
+
+func buildJSONCertificate(cert *CertificateFinding) *JSONCertificate {
+	result := &JSONCertificate{
+		Subject:            cert.Subject,
+		Issuer:             cert.Issuer,
+		SignatureAlgorithm: cert.SignatureAlgorithm,
+		AltNames:           cert.AltNames,
+		Issues:             []string{},
+		Expired:            cert.Expired,
+	}
+
+	if !cert.NotBefore.IsZero() {
+		notBefore := cert.NotBefore.UTC()
+		result.NotBefore = &notBefore
+	}
+
+	if !cert.NotAfter.IsZero() {
+		notAfter := cert.NotAfter.UTC()
+		daysRemaining := cert.DaysRemaining
+		result.NotAfter = &notAfter
+		result.DaysRemaining = &daysRemaining
+	}
+
+	for _, issue := range cert.Issues {
+		result.Issues = append(result.Issues, issue.Flag)
+	}
+
+	return result
+}
@@ -0,0 +1,294 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"sslscanner/model"
+)
+
+const (
+	DefaultJUnitMinGrade = "B"
+	DefaultJUnitCertDays = 30
+)
+
+// JUnitOptions define los umbrales de los chequeos del reporte JUnit
+type JUnitOptions struct {
+	MinGrade string
+	CertDays int
+}
+
+// JUnitReport convierte cada endpoint en un testsuite y cada chequeo en un testcase
+type JUnitReport struct {
+	options JUnitOptions
+	suites  []junitTestSuite
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string           `xml:"name,attr"`
+	Hostname   string           `xml:"hostname,attr,omitempty"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Errors     int              `xml:"errors,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	Time       string           `xml:"time,attr"`
+	Timestamp  string           `xml:"timestamp,attr,omitempty"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Cases      []junitTestCase  `xml:"testcase"`
+}
+
+type junitProperties struct {
+	Property []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+func NewJUnitReport(options JUnitOptions) *JUnitReport {
+	if options.MinGrade == "" {
+		options.MinGrade = DefaultJUnitMinGrade
+	}
+	if options.CertDays <= 0 {
+		options.CertDays = DefaultJUnitCertDays
+	}
+
+	return &JUnitReport{options: options}
+}
+
+// AddHost agrega un testsuite por cada endpoint del host. Un host que no
+// terminó el análisis o no tiene endpoints queda como un testsuite con error.
+func (r *JUnitReport) AddHost(host *model.Host) {
+	timestamp := ""
+	if host.TestTime > 0 {
+		timestamp = time.UnixMilli(host.TestTime).UTC().Format("2006-01-02T15:04:05")
+	}
+
+	if message, ok := unfinishedHost(host); ok {
+		suite := junitTestSuite{
+			Name:      host.Host,
+			Hostname:  host.Host,
+			Time:      junitSeconds(0),
+			Timestamp: timestamp,
+			Cases: []junitTestCase{{
+				Name:      "assessment",
+				ClassName: junitClassName(host.Host, ""),
+				Time:      junitSeconds(0),
+				Error:     &junitFailure{Message: message, Type: "assessment"},
+			}},
+		}
+		r.suites = append(r.suites, suite.withCounts())
+		return
+	}
+
+	for _, endpoint := range host.Endpoints {
+		suite := junitTestSuite{
+			Name:      fmt.Sprintf("%s (%s)", host.Host, endpoint.IPAddress),
+			Hostname:  host.Host,
+			Time:      junitSeconds(time.Duration(endpoint.Duration) * time.Millisecond),
+			Timestamp: timestamp,
+			Properties: &junitProperties{Property: []junitProperty{
+				{Name: "ipAddress", Value: endpoint.IPAddress},
+				{Name: "grade", Value: endpoint.Grade},
+			}},
+		}
+
+		className := junitClassName(host.Host, endpoint.IPAddress)
+
+		if endpoint.StatusMessage != "Ready" || endpoint.Details == nil {
+			message := endpoint.StatusMessage
+			if endpoint.StatusDetailsMessage != "" {
+				message += ": " + endpoint.StatusDetailsMessage
+			}
+			suite.Cases = []junitTestCase{{
+				Name:      "assessment",
+				ClassName: className,
+				Time:      junitSeconds(0),
+				Error:     &junitFailure{Message: message, Type: "assessment"},
+			}}
+		} else {
+			suite.Cases = r.endpointChecks(endpoint, className)
+		}
+
+		r.suites = append(r.suites, suite.withCounts())
+	}
+}
+
+// AddError registra un dominio cuyo análisis falló como un testsuite con error
+func (r *JUnitReport) AddError(domain string, err error) {
+	suite := junitTestSuite{
+		Name:     domain,
+		Hostname: domain,
+		Time:     junitSeconds(0),
+		Cases: []junitTestCase{{
+			Name:      "analysis",
+			ClassName: junitClassName(domain, ""),
+			Time:      junitSeconds(0),
+			Error:     &junitFailure{Message: err.Error(), Type: "analysis"},
+		}},
+	}
+
+	r.suites = append(r.suites, suite.withCounts())
+}
+
+// Write escribe el documento XML en w
+func (r *JUnitReport) Write(w io.Writer) error {
+	doc := junitTestSuites{
+		Name:   toolName,
+		Suites: r.suites,
+	}
+
+	for _, suite := range r.suites {
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+		doc.Errors += suite.Errors
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("falló al escribir reporte JUnit: %w", err)
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("falló al codificar reporte JUnit: %w", err)
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// endpointChecks evalúa calificación, protocolos, suites, vulnerabilidades y expiración del certificado
+func (r *JUnitReport) endpointChecks(endpoint model.Endpoint, className string) []junitTestCase {
+	details := endpoint.Details
+	cases := []junitTestCase{}
+
+	check := func(checkType, name string, problems []string) {
+		testCase := junitTestCase{Name: name, ClassName: className, Time: junitSeconds(0)}
+		if len(problems) > 0 {
+			testCase.Failure = &junitFailure{
+				Message: problems[0],
+				Type:    checkType,
+				Text:    strings.Join(problems, "\n"),
+			}
+		}
+		cases = append(cases, testCase)
+	}
+
+	gradeProblems := []string{}
+	if !model.GradeAtLeast(endpoint.Grade, r.options.MinGrade) {
+		gradeProblems = append(gradeProblems, fmt.Sprintf("calificación %q inferior al mínimo %s",
+			endpoint.Grade, r.options.MinGrade))
+	}
+	check("grade", "minimum grade "+r.options.MinGrade, gradeProblems)
+
+	protocolProblems := []string{}
+	for _, proto := range details.Protocols {
+		status := classifyProtocol(proto)
+		legacyTLS := proto.Name == "TLS" && proto.Version == "1.0"
+		if status == ProtocolInsecure || status == ProtocolObsolete || legacyTLS {
+			protocolProblems = append(protocolProblems, fmt.Sprintf("soporta %s %s", proto.Name, proto.Version))
+		}
+	}
+	check("protocols", "no SSL or TLS 1.0 protocols", protocolProblems)
+
+	suiteProblems := []string{}
+	if details.Suites != nil {
+		for _, suite := range details.Suites.List {
+			if isWeakSuite(suite) {
+				suiteProblems = append(suiteProblems, fmt.Sprintf("suite débil %s (fuerza: %d bits)",
+					suite.Name, suite.CipherStrength))
+			}
+		}
+	}
+	check("cipher-suites", "no weak cipher suites", suiteProblems)
+
+	vulnProblems := []string{}
+	for _, vuln := range detectVulnerabilities(details) {
+		vulnProblems = append(vulnProblems, fmt.Sprintf("%s (severidad: %s)", vuln.Name, vuln.Severity))
+	}
+	check("vulnerabilities", "no known vulnerabilities", vulnProblems)
+
+	certName := fmt.Sprintf("certificate valid for %d days", r.options.CertDays)
+	if details.Cert == nil || details.Cert.NotAfter <= 0 {
+		cases = append(cases, junitTestCase{
+			Name:      certName,
+			ClassName: className,
+			Time:      junitSeconds(0),
+			Skipped:   &junitSkipped{Message: "sin información del certificado"},
+		})
+	} else {
+		certProblems := []string{}
+		notAfter := time.UnixMilli(details.Cert.NotAfter)
+		daysRemaining := int(time.Until(notAfter).Hours() / 24)
+		if daysRemaining < 0 {
+			certProblems = append(certProblems, fmt.Sprintf("el certificado expiró el %s", notAfter.Format("2006-01-02")))
+		} else if daysRemaining < r.options.CertDays {
+			certProblems = append(certProblems, fmt.Sprintf("el certificado expira en %d días (%s)",
+				daysRemaining, notAfter.Format("2006-01-02")))
+		}
+		check("certificate-expiry", certName, certProblems)
+	}
+
+	return cases
+}
+
+func (s junitTestSuite) withCounts() junitTestSuite {
+	s.Tests = len(s.Cases)
+	for _, testCase := range s.Cases {
+		switch {
+		case testCase.Failure != nil:
+			s.Failures++
+		case testCase.Error != nil:
+			s.Errors++
+		case testCase.Skipped != nil:
+			s.Skipped++
+		}
+	}
+	return s
+}
+
+// junitClassName arma un classname con puntos, como esperan los visores de CI
+func junitClassName(host, ipAddress string) string {
+	className := toolName + "." + host
+	if ipAddress != "" {
+		className += "." + strings.ReplaceAll(ipAddress, ":", "_")
+	}
+	return className
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
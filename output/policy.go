@@ -0,0 +1,33 @@
+package output
+
+import (
+	"fmt"
+
+	"sslscanner/policy"
+)
+
+// PrintPolicyViolations muestra el resultado de evaluar la política sobre un host
+func (f *Formatter) PrintPolicyViolations(violations []policy.Violation) {
+	fmt.Fprintf(f.w, "\n%s Política de Seguridad %s\n", f.bold(""), f.reset())
+
+	if len(violations) == 0 {
+		fmt.Fprintf(f.w, "  %s\n", f.colorize("✓ Cumple todas las reglas de la política", ColorGreen))
+		fmt.Fprintln(f.w, f.separator())
+		return
+	}
+
+	for _, violation := range violations {
+		fmt.Fprintf(f.w, "  %s\n", f.colorize(fmt.Sprintf("✗ %s", violation), ColorRed))
+	}
+	fmt.Fprintln(f.w, f.separator())
+}
+
+// PrintPolicyFailures cierra el resumen de un lote con la cantidad de dominios
+// que no cumplen la política
+func (f *Formatter) PrintPolicyFailures(count int) {
+	text := fmt.Sprintf("Dominios que no cumplen la política: %d", count)
+	if count > 0 {
+		text = f.colorize(text, ColorRed)
+	}
+	fmt.Fprintln(f.w, text)
+}
@@ -0,0 +1,186 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sslscanner/model"
+)
+
+// MetricsTarget es el último estado conocido de un dominio para /metrics
+type MetricsTarget struct {
+	Domain string
+	// Host es el último análisis exitoso; se sigue publicando aunque fallen
+	// las actualizaciones siguientes
+	Host        *model.Host
+	LastAttempt time.Time
+	LastError   error
+	// Failures cuenta las actualizaciones fallidas desde que arrancó el proceso
+	Failures int
+}
+
+// metricsProtocols son los protocolos que se publican siempre, con 0 si no se soportan
+var metricsProtocols = []string{"SSL 2.0", "SSL 3.0", "TLS 1.0", "TLS 1.1", "TLS 1.2", "TLS 1.3"}
+
+// metricFamily agrupa las muestras de una métrica, que en el formato de
+// Prometheus deben ir juntas bajo su HELP y TYPE
+type metricFamily struct {
+	name    string
+	help    string
+	kind    string
+	samples []string
+}
+
+func (m *metricFamily) add(value float64, labels ...string) {
+	var b strings.Builder
+	b.WriteString(m.name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(formatMetricValue(value))
+	m.samples = append(m.samples, b.String())
+}
+
+// WritePrometheus escribe las métricas en el formato de exposición de texto de
+// Prometheus. La calificación se publica como número (GradeRank: T=0 … A+=9).
+func WritePrometheus(w io.Writer, targets []MetricsTarget, now time.Time) error {
+	gauge := func(name, help string) *metricFamily {
+		return &metricFamily{name: name, help: help, kind: "gauge"}
+	}
+
+	success := gauge("sslscanner_scan_success", "1 si la última actualización del dominio terminó bien")
+	failures := &metricFamily{name: "sslscanner_scan_failures_total", help: "Actualizaciones fallidas del dominio", kind: "counter"}
+	scanTime := gauge("sslscanner_scan_timestamp_seconds", "Fecha del análisis publicado (epoch)")
+	scanAge := gauge("sslscanner_scan_age_seconds", "Antigüedad del análisis publicado")
+	grade := gauge("sslscanner_grade_score", "Calificación del endpoint: T=0, M=1, F=2, E=3, D=4, C=5, B=6, A-=7, A=8, A+=9")
+	certDays := gauge("sslscanner_cert_expiry_days", "Días hasta el vencimiento del certificado leaf")
+	certNotAfter := gauge("sslscanner_cert_not_after_timestamp_seconds", "Vencimiento del certificado leaf (epoch)")
+	protocols := gauge("sslscanner_protocol_supported", "1 si el endpoint acepta el protocolo")
+	weakSuites := gauge("sslscanner_weak_suites", "Cipher suites marcadas como débiles")
+	vulnerabilities := gauge("sslscanner_vulnerability", "1 si el endpoint es vulnerable")
+	forwardSecrecy := gauge("sslscanner_forward_secrecy", "Campo forwardSecrecy de SSL Labs (4 o más = todas las suites)")
+	hsts := gauge("sslscanner_hsts_max_age_seconds", "max-age de HSTS (0 sin HSTS)")
+
+	sorted := append([]MetricsTarget{}, targets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Domain < sorted[j].Domain })
+
+	for _, target := range sorted {
+		if !target.LastAttempt.IsZero() {
+			success.add(boolValue(target.LastError == nil), "host", target.Domain)
+		}
+		failures.add(float64(target.Failures), "host", target.Domain)
+
+		host := target.Host
+		if host == nil {
+			continue
+		}
+
+		if host.TestTime > 0 {
+			tested := time.UnixMilli(host.TestTime)
+			scanTime.add(float64(tested.Unix()), "host", target.Domain)
+			scanAge.add(now.Sub(tested).Seconds(), "host", target.Domain)
+		}
+
+		for _, endpoint := range host.Endpoints {
+			labels := []string{"host", target.Domain, "ip", endpoint.IPAddress}
+
+			if rank := model.GradeRank(endpoint.Grade); rank >= 0 {
+				grade.add(float64(rank), labels...)
+			}
+
+			details := endpoint.Details
+			if details == nil {
+				continue
+			}
+
+			if details.Cert != nil && details.Cert.NotAfter > 0 {
+				notAfter := time.UnixMilli(details.Cert.NotAfter)
+				certDays.add(math.Floor(notAfter.Sub(now).Hours()/24), labels...)
+				certNotAfter.add(float64(notAfter.Unix()), labels...)
+			}
+
+			supported := make(map[string]bool, len(details.Protocols))
+			for _, proto := range details.Protocols {
+				supported[proto.Name+" "+proto.Version] = true
+			}
+			for _, name := range metricsProtocols {
+				protocols.add(boolValue(supported[name]), append(labels, "protocol", name)...)
+			}
+
+			weak := 0
+			if details.Suites != nil {
+				for _, suite := range details.Suites.List {
+					if isWeakSuite(suite) {
+						weak++
+					}
+				}
+			}
+			weakSuites.add(float64(weak), labels...)
+
+			for _, check := range checkVulnerabilities(details) {
+				vulnerabilities.add(boolValue(check.vulnerable), append(labels, "vulnerability", check.ID)...)
+			}
+
+			forwardSecrecy.add(float64(details.ForwardSecrecy), labels...)
+
+			maxAge := int64(0)
+			if details.HstsPolicy != nil && details.HstsPolicy.Status == "present" {
+				maxAge = details.HstsPolicy.MaxAge
+			}
+			hsts.add(float64(maxAge), labels...)
+		}
+	}
+
+	out := bufio.NewWriter(w)
+	for _, family := range []*metricFamily{success, failures, scanTime, scanAge, grade, certDays, certNotAfter,
+		protocols, weakSuites, vulnerabilities, forwardSecrecy, hsts} {
+		if len(family.samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(out, "# HELP %s %s\n", family.name, escapeHelp(family.help))
+		fmt.Fprintf(out, "# TYPE %s %s\n", family.name, family.kind)
+		for _, sample := range family.samples {
+			out.WriteString(sample)
+			out.WriteByte('\n')
+		}
+	}
+
+	return out.Flush()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func formatMetricValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,72 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sslscanner/model"
+)
+
+// metricsTargets cubre un dominio analizado, uno cuya última actualización
+// falló sobre un resultado anterior y otro que todavía no se intentó
+func metricsTargets(now time.Time) []MetricsTarget {
+	weak := 0
+	details := &model.EndpointDetails{
+		Protocols: []model.Protocol{{Name: "TLS", Version: "1.2"}, {Name: "TLS", Version: "1.3"}},
+		Suites: &model.Suites{List: []model.Suite{
+			{Name: "TLS_AES_128_GCM_SHA256", CipherStrength: 128},
+			{Name: "TLS_RSA_WITH_3DES_EDE_CBC_SHA", CipherStrength: 112, Q: &weak},
+		}},
+		Cert:           &model.Cert{NotAfter: now.Add(45*24*time.Hour + time.Hour).UnixMilli()},
+		ForwardSecrecy: 4,
+		PoodleTLS:      2,
+		HstsPolicy:     &model.HstsPolicy{Status: "present", MaxAge: 31536000},
+	}
+
+	return []MetricsTarget{
+		{
+			Domain:      "z.example.com",
+			LastAttempt: now,
+			LastError:   errors.New("servicio sobrecargado"),
+			Failures:    2,
+			Host: &model.Host{
+				TestTime:  now.Add(-30 * time.Hour).UnixMilli(),
+				Endpoints: []model.Endpoint{{IPAddress: "198.51.100.7", Grade: "T"}},
+			},
+		},
+		{Domain: "pending.example.com"},
+		{
+			Domain:      "example.com",
+			LastAttempt: now,
+			Host: &model.Host{
+				TestTime: now.Add(-time.Hour).UnixMilli(),
+				Endpoints: []model.Endpoint{
+					{IPAddress: "192.0.2.10", Grade: "A+", Details: details},
+					{IPAddress: "2001:db8::10", Grade: ""},
+				},
+			},
+		},
+	}
+}
+
+func TestWritePrometheusGolden(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, metricsTargets(now), now); err != nil {
+		t.Fatalf("WritePrometheus: %v", err)
+	}
+	assertGolden(t, filepath.Join("testdata", "metrics.golden"), buf.Bytes())
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Errorf("escapeLabel = %s", got)
+	}
+	if got := escapeHelp("a\\b\nc\"d"); got != `a\\b\nc"d` {
+		t.Errorf("escapeHelp = %s", got)
+	}
+}
@@ -0,0 +1,372 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"sslscanner/model"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	toolName           = "sslscanner"
+	toolVersion        = "1.0"
+	toolInformationURI = "https://github.com/paelsam/ssl-scanner"
+
+	// certExpiryWarningDays replica el aviso de printCertificateInfo
+	certExpiryWarningDays = 30
+)
+
+// Niveles de SARIF usados en los resultados
+const (
+	sarifError   = "error"
+	sarifWarning = "warning"
+)
+
+// SARIFReport convierte los hallazgos de uno o varios hosts en un log SARIF 2.1.0
+// con una regla por tipo de hallazgo y el host/IP como ubicación
+type SARIFReport struct {
+	rules     []sarifRule
+	ruleIndex map[string]int
+	results   []sarifResult
+	failures  []sarifNotification
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string              `json:"id"`
+	Name                 string              `json:"name"`
+	ShortDescription     sarifMessage        `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration  `json:"defaultConfiguration"`
+	Properties           sarifRuleProperties `json:"properties"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifRuleProperties struct {
+	Tags             []string `json:"tags"`
+	SecuritySeverity string   `json:"security-severity"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	EndTimeUTC                 time.Time           `json:"endTimeUtc"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level   string       `json:"level"`
+	Message sarifMessage `json:"message"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifFinding es un hallazgo antes de asignarle regla y ubicación
+type sarifFinding struct {
+	ruleID      string
+	ruleName    string
+	description string
+	severity    string
+	tag         string
+	message     string
+	detail      string
+}
+
+func NewSARIFReport() *SARIFReport {
+	return &SARIFReport{
+		ruleIndex: make(map[string]int),
+	}
+}
+
+// AddHost agrega como resultados los hallazgos de todos los endpoints del host.
+// Un host que no terminó el análisis o no tiene endpoints se registra como AddError.
+func (r *SARIFReport) AddHost(host *model.Host) {
+	if message, ok := unfinishedHost(host); ok {
+		r.AddError(host.Host, errors.New(message))
+		return
+	}
+
+	for _, endpoint := range host.Endpoints {
+		if endpoint.StatusMessage != "Ready" || endpoint.Details == nil {
+			continue
+		}
+
+		for _, finding := range sarifFindings(endpoint) {
+			r.addResult(host, endpoint, finding)
+		}
+	}
+}
+
+// AddError registra un dominio cuyo análisis falló como notificación de la ejecución
+func (r *SARIFReport) AddError(domain string, err error) {
+	r.failures = append(r.failures, sarifNotification{
+		Level:   sarifError,
+		Message: sarifMessage{Text: fmt.Sprintf("%s: %v", domain, err)},
+	})
+}
+
+// Write escribe el log SARIF indentado en w
+func (r *SARIFReport) Write(w io.Writer) error {
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           toolName,
+				Version:        toolVersion,
+				InformationURI: toolInformationURI,
+				Rules:          r.rules,
+			}},
+			Invocations: []sarifInvocation{{
+				ExecutionSuccessful:        len(r.failures) == 0,
+				EndTimeUTC:                 time.Now().UTC(),
+				ToolExecutionNotifications: r.failures,
+			}},
+			Results: r.results,
+		}},
+	}
+
+	if log.Runs[0].Tool.Driver.Rules == nil {
+		log.Runs[0].Tool.Driver.Rules = []sarifRule{}
+	}
+	if log.Runs[0].Results == nil {
+		log.Runs[0].Results = []sarifResult{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(log); err != nil {
+		return fmt.Errorf("falló al codificar reporte SARIF: %w", err)
+	}
+	return nil
+}
+
+func (r *SARIFReport) addResult(host *model.Host, endpoint model.Endpoint, finding sarifFinding) {
+	index, ok := r.ruleIndex[finding.ruleID]
+	if !ok {
+		index = len(r.rules)
+		r.ruleIndex[finding.ruleID] = index
+		r.rules = append(r.rules, sarifRule{
+			ID:                   finding.ruleID,
+			Name:                 finding.ruleName,
+			ShortDescription:     sarifMessage{Text: finding.description},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(finding.severity)},
+			Properties: sarifRuleProperties{
+				Tags:             []string{"security", "tls", finding.tag},
+				SecuritySeverity: securitySeverity(finding.severity),
+			},
+		})
+	}
+
+	port := host.Port
+	if port == 0 {
+		port = 443
+	}
+
+	r.results = append(r.results, sarifResult{
+		RuleID:    finding.ruleID,
+		RuleIndex: index,
+		Level:     sarifLevel(finding.severity),
+		Message:   sarifMessage{Text: fmt.Sprintf("%s (%s): %s", host.Host, endpoint.IPAddress, finding.message)},
+		Locations: []sarifLocation{{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: fmt.Sprintf("https://%s:%d", host.Host, port)},
+			},
+			LogicalLocations: []sarifLogicalLocation{{
+				Name:               endpoint.IPAddress,
+				FullyQualifiedName: fmt.Sprintf("%s/%s", host.Host, endpoint.IPAddress),
+				Kind:               "resource",
+			}},
+		}},
+		// permite a los visores agrupar el mismo hallazgo entre ejecuciones
+		PartialFingerprints: map[string]string{
+			"sslscanner/v1": fmt.Sprintf("%s/%s/%s/%s", host.Host, endpoint.IPAddress, finding.ruleID, finding.detail),
+		},
+	})
+}
+
+// sarifFindings reúne los hallazgos del endpoint que muestra el reporte de texto:
+// vulnerabilidades, protocolos inseguros o deprecados, suites débiles y problemas del certificado
+func sarifFindings(endpoint model.Endpoint) []sarifFinding {
+	details := endpoint.Details
+	findings := []sarifFinding{}
+
+	for _, vuln := range detectVulnerabilities(details) {
+		findings = append(findings, sarifFinding{
+			ruleID:      "vulnerability/" + vuln.ID,
+			ruleName:    vuln.ID,
+			description: vuln.Name,
+			severity:    vuln.Severity,
+			tag:         "vulnerability",
+			message:     fmt.Sprintf("vulnerable a %s (severidad: %s)", vuln.Name, vuln.Severity),
+			detail:      vuln.ID,
+		})
+	}
+
+	for _, proto := range details.Protocols {
+		status := classifyProtocol(proto)
+		if status == ProtocolOK {
+			continue
+		}
+
+		severity := SeverityHigh
+		description := "Protocolo inseguro habilitado"
+		switch status {
+		case ProtocolObsolete:
+			description = "Protocolo obsoleto habilitado"
+		case ProtocolDeprecated:
+			severity = SeverityMedium
+			description = "Protocolo deprecado habilitado"
+		}
+
+		name := fmt.Sprintf("%s %s", proto.Name, proto.Version)
+		findings = append(findings, sarifFinding{
+			ruleID:      "protocol/" + status,
+			ruleName:    status + "Protocol",
+			description: description,
+			severity:    severity,
+			tag:         "protocol",
+			message:     fmt.Sprintf("soporta %s (%s)", name, status),
+			detail:      name,
+		})
+	}
+
+	if details.Suites != nil {
+		for _, suite := range details.Suites.List {
+			if !isWeakSuite(suite) {
+				continue
+			}
+			findings = append(findings, sarifFinding{
+				ruleID:      "cipher/weak-suite",
+				ruleName:    "weakCipherSuite",
+				description: "Cipher suite débil habilitada",
+				severity:    SeverityMedium,
+				tag:         "cipher",
+				message:     fmt.Sprintf("soporta la suite débil %s (fuerza: %d bits)", suite.Name, suite.CipherStrength),
+				detail:      suite.Name,
+			})
+		}
+	}
+
+	if details.Cert != nil {
+		findings = append(findings, certificateFindings(details.Cert)...)
+	}
+
+	return findings
+}
+
+func certificateFindings(cert *model.Cert) []sarifFinding {
+	findings := []sarifFinding{}
+
+	for _, issue := range decodeCertIssues(cert.Issues) {
+		severity := SeverityHigh
+		if issue.Flag == "badCommonName" || issue.Flag == "notYetValid" {
+			severity = SeverityMedium
+		}
+		findings = append(findings, sarifFinding{
+			ruleID:      "certificate/" + issue.Flag,
+			ruleName:    issue.Flag,
+			description: issue.Description,
+			severity:    severity,
+			tag:         "certificate",
+			message:     fmt.Sprintf("problema en el certificado: %s", issue.Description),
+			detail:      cert.Subject,
+		})
+	}
+
+	// el vencimiento ya se reporta como issue "expired", aquí solo el aviso previo
+	if cert.NotAfter > 0 {
+		daysRemaining := int(time.Until(time.UnixMilli(cert.NotAfter)).Hours() / 24)
+		if daysRemaining >= 0 && daysRemaining < certExpiryWarningDays {
+			findings = append(findings, sarifFinding{
+				ruleID:      "certificate/expiring-soon",
+				ruleName:    "certificateExpiringSoon",
+				description: fmt.Sprintf("El certificado expira en menos de %d días", certExpiryWarningDays),
+				severity:    SeverityMedium,
+				tag:         "certificate",
+				message:     fmt.Sprintf("el certificado expira en %d días", daysRemaining),
+				detail:      cert.Subject,
+			})
+		}
+	}
+
+	return findings
+}
+
+func sarifLevel(severity string) string {
+	if severity == SeverityCritical || severity == SeverityHigh {
+		return sarifError
+	}
+	return sarifWarning
+}
+
+// securitySeverity es la puntuación numérica que usan los paneles de code scanning
+func securitySeverity(severity string) string {
+	switch severity {
+	case SeverityCritical:
+		return "9.5"
+	case SeverityHigh:
+		return "7.5"
+	default:
+		return "5.0"
+	}
+}
@@ -0,0 +1,88 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"sslscanner/model"
+)
+
+// BatchEntry resume el resultado de un dominio para la tabla final de un lote
+type BatchEntry struct {
+	Domain   string
+	Host     *model.Host
+	Err      error
+	Duration time.Duration
+}
+
+// PrintDomainHeader separa los reportes de cada dominio en un análisis por lotes
+func (f *Formatter) PrintDomainHeader(domain string, index, total int) {
+	fmt.Fprintf(f.w, "\n%s [%d/%d] %s %s\n", f.bold(""), index, total, domain, f.reset())
+}
+
+// PrintBatchSummary imprime la tabla final de un análisis por lotes
+func (f *Formatter) PrintBatchSummary(entries []BatchEntry) {
+	domainWidth := len("Dominio")
+	for _, entry := range entries {
+		domainWidth = max(domainWidth, utf8.RuneCountInString(entry.Domain))
+	}
+
+	fmt.Fprintln(f.w)
+	fmt.Fprintln(f.w, f.separator())
+	fmt.Fprintf(f.w, "%s RESUMEN DEL LOTE %s\n", f.bold(""), f.reset())
+	fmt.Fprintln(f.w, f.separator())
+	fmt.Fprintf(f.w, "%s  %s  %s  %s\n", padRight("Dominio", domainWidth), padRight("Calificación", 14),
+		padRight("Estado", 8), "Duración")
+
+	failed := 0
+	for _, entry := range entries {
+		// el padding se aplica antes de colorear para no contar los códigos ANSI
+		status := f.colorize(padRight("OK", 8), ColorGreen)
+		grades := batchGrades(entry.Host)
+		if entry.Err != nil {
+			failed++
+			status = f.colorize(padRight("ERROR", 8), ColorRed)
+			grades = "-"
+		}
+
+		fmt.Fprintf(f.w, "%s  %s  %s  %s\n", padRight(entry.Domain, domainWidth), padRight(grades, 14),
+			status, entry.Duration.Round(time.Second))
+	}
+
+	fmt.Fprintln(f.w, f.separator())
+	fmt.Fprintf(f.w, "Total: %d  Exitosos: %d  Fallidos: %d\n", len(entries), len(entries)-failed, failed)
+
+	if failed > 0 {
+		fmt.Fprintf(f.w, "\n%s\n", f.colorize("Errores:", ColorRed))
+		for _, entry := range entries {
+			if entry.Err != nil {
+				fmt.Fprintf(f.w, "  ✗ %s: %v\n", entry.Domain, entry.Err)
+			}
+		}
+	}
+}
+
+// padRight completa con espacios según la cantidad de caracteres visibles
+func padRight(text string, width int) string {
+	return text + strings.Repeat(" ", max(0, width-utf8.RuneCountInString(text)))
+}
+
+// batchGrades junta las calificaciones de todos los endpoints de un host (ej. "A+/A")
+func batchGrades(host *model.Host) string {
+	if host == nil || len(host.Endpoints) == 0 {
+		return "-"
+	}
+
+	grades := make([]string, 0, len(host.Endpoints))
+	for _, endpoint := range host.Endpoints {
+		if endpoint.Grade == "" {
+			grades = append(grades, "?")
+			continue
+		}
+		grades = append(grades, endpoint.Grade)
+	}
+
+	return strings.Join(grades, "/")
+}
@@ -0,0 +1,22 @@
+package output
+
+import "os"
+
+// IsTerminal indica si file es una terminal interactiva
+func IsTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// ColorsEnabled indica si conviene usar colores ANSI en file: no cuando la
+// variable NO_COLOR tiene un valor (https://no-color.org) ni cuando la salida
+// se redirige a un archivo o a otro programa
+func ColorsEnabled(file *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return IsTerminal(file)
+}
@@ -0,0 +1,118 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"sslscanner/model"
+)
+
+// Evaluate aplica la política a todos los endpoints del host y devuelve las violaciones
+func (p *Policy) Evaluate(host *model.Host) []Violation {
+	violations := []Violation{}
+
+	// un patrón inválido hace fallar la política en lugar de omitir la regla
+	patterns, err := p.patterns()
+	if err != nil {
+		violations = append(violations, Violation{RuleID: RuleForbiddenCipher, Message: err.Error()})
+	}
+
+	for _, endpoint := range host.Endpoints {
+		if endpoint.StatusMessage != "Ready" || endpoint.Details == nil {
+			violations = append(violations, Violation{
+				RuleID:    RuleEndpointReady,
+				IPAddress: endpoint.IPAddress,
+				Message:   fmt.Sprintf("el endpoint no completó el análisis: %s", endpoint.StatusMessage),
+			})
+			continue
+		}
+
+		violations = append(violations, p.evaluateEndpoint(endpoint, patterns)...)
+	}
+
+	return violations
+}
+
+func (p *Policy) evaluateEndpoint(endpoint model.Endpoint, patterns []*regexp.Regexp) []Violation {
+	details := endpoint.Details
+	violations := []Violation{}
+
+	add := func(ruleID, format string, args ...interface{}) {
+		violations = append(violations, Violation{
+			RuleID:    ruleID,
+			IPAddress: endpoint.IPAddress,
+			Message:   fmt.Sprintf(format, args...),
+		})
+	}
+
+	if p.MinGrade != "" && !model.GradeAtLeast(endpoint.Grade, p.MinGrade) {
+		add(RuleMinGrade, "calificación %q inferior al mínimo %s", endpoint.Grade, p.MinGrade)
+	}
+
+	for _, forbidden := range p.ForbiddenProtocols {
+		for _, proto := range details.Protocols {
+			if normalizeProtocol(proto.Name+proto.Version) == normalizeProtocol(forbidden) {
+				add(RuleForbiddenProtocol, "soporta el protocolo prohibido %s %s", proto.Name, proto.Version)
+			}
+		}
+	}
+
+	if details.Suites != nil {
+		for _, suite := range details.Suites.List {
+			for _, pattern := range patterns {
+				if pattern.MatchString(suite.Name) {
+					add(RuleForbiddenCipher, "soporta la suite %s (patrón prohibido %q)", suite.Name, pattern.String())
+					break
+				}
+			}
+		}
+	}
+
+	if p.MinKeySize > 0 && details.Key != nil {
+		// las claves EC se comparan por su fuerza equivalente en RSA
+		keySize := details.Key.Size
+		if details.Key.Alg == "EC" && details.Key.Strength > 0 {
+			keySize = details.Key.Strength
+		}
+		if keySize < p.MinKeySize {
+			if keySize != details.Key.Size {
+				add(RuleMinKeySize, "clave %s de %d bits (equivalente a %d bits RSA) inferior al mínimo de %d bits",
+					details.Key.Alg, details.Key.Size, keySize, p.MinKeySize)
+			} else {
+				add(RuleMinKeySize, "clave %s de %d bits inferior al mínimo de %d bits",
+					details.Key.Alg, keySize, p.MinKeySize)
+			}
+		}
+	}
+
+	if p.HSTS != nil {
+		present := details.HstsPolicy != nil && details.HstsPolicy.Status == "present"
+		if p.HSTS.Required && !present {
+			add(RuleHSTSRequired, "HSTS no está habilitado")
+		}
+		if present && p.HSTS.MinMaxAge > 0 && details.HstsPolicy.MaxAge < p.HSTS.MinMaxAge {
+			add(RuleHSTSMaxAge, "max-age de HSTS (%d) inferior al mínimo de %d segundos",
+				details.HstsPolicy.MaxAge, p.HSTS.MinMaxAge)
+		}
+	}
+
+	if p.RequireOCSPStapling && !details.OcspStapling {
+		add(RuleOCSPStapling, "OCSP stapling no está habilitado")
+	}
+
+	if p.MinForwardSecrecy != "" && details.ForwardSecrecy < forwardSecrecyValues[p.MinForwardSecrecy] {
+		add(RuleForwardSecrecy, "forward secrecy inferior al nivel %s", p.MinForwardSecrecy)
+	}
+
+	if p.MinCertDays > 0 && details.Cert != nil && details.Cert.NotAfter > 0 {
+		notAfter := time.UnixMilli(details.Cert.NotAfter)
+		daysRemaining := int(time.Until(notAfter).Hours() / 24)
+		if daysRemaining < p.MinCertDays {
+			add(RuleMinCertDays, "el certificado expira en %d días (%s), mínimo %d",
+				daysRemaining, notAfter.Format("2006-01-02"), p.MinCertDays)
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,159 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"sslscanner/model"
+)
+
+// Identificadores de las reglas, usados en cada violación reportada
+const (
+	RuleEndpointReady     = "endpoint-ready"
+	RuleMinGrade          = "min-grade"
+	RuleForbiddenProtocol = "forbidden-protocol"
+	RuleForbiddenCipher   = "forbidden-cipher"
+	RuleMinKeySize        = "min-key-size"
+	RuleHSTSRequired      = "hsts-required"
+	RuleHSTSMaxAge        = "hsts-max-age"
+	RuleOCSPStapling      = "ocsp-stapling"
+	RuleForwardSecrecy    = "forward-secrecy"
+	RuleMinCertDays       = "min-cert-days"
+)
+
+// Niveles de forward secrecy aceptados en minForwardSecrecy
+const (
+	ForwardSecrecyLimited = "limited"
+	ForwardSecrecyModern  = "modern"
+	ForwardSecrecyFull    = "full"
+)
+
+// forwardSecrecyValues replica los umbrales del campo forwardSecrecy de SSL Labs
+var forwardSecrecyValues = map[string]int{
+	ForwardSecrecyLimited: 1,
+	ForwardSecrecyModern:  2,
+	ForwardSecrecyFull:    4,
+}
+
+// Policy define las reglas de aprobación de un análisis. Las reglas sin valor no se evalúan.
+type Policy struct {
+	MinGrade            string    `json:"minGrade" yaml:"minGrade"`
+	ForbiddenProtocols  []string  `json:"forbiddenProtocols" yaml:"forbiddenProtocols"`
+	ForbiddenCiphers    []string  `json:"forbiddenCiphers" yaml:"forbiddenCiphers"`
+	MinKeySize          int       `json:"minKeySize" yaml:"minKeySize"`
+	HSTS                *HSTSRule `json:"hsts" yaml:"hsts"`
+	RequireOCSPStapling bool      `json:"requireOcspStapling" yaml:"requireOcspStapling"`
+	MinForwardSecrecy   string    `json:"minForwardSecrecy" yaml:"minForwardSecrecy"`
+	MinCertDays         int       `json:"minCertDays" yaml:"minCertDays"`
+
+	cipherPatterns []*regexp.Regexp
+}
+
+type HSTSRule struct {
+	Required  bool  `json:"required" yaml:"required"`
+	MinMaxAge int64 `json:"minMaxAge" yaml:"minMaxAge"`
+}
+
+// Violation es el incumplimiento de una regla en un endpoint
+type Violation struct {
+	RuleID    string `json:"ruleId"`
+	IPAddress string `json:"ipAddress,omitempty"`
+	Message   string `json:"message"`
+}
+
+func (v Violation) String() string {
+	if v.IPAddress == "" {
+		return fmt.Sprintf("[%s] %s", v.RuleID, v.Message)
+	}
+	return fmt.Sprintf("[%s] %s: %s", v.RuleID, v.IPAddress, v.Message)
+}
+
+// Load lee una política desde un archivo YAML o JSON (según la extensión) y la valida
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("falló al leer la política: %w", err)
+	}
+
+	var policy Policy
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&policy); err != nil {
+			return nil, fmt.Errorf("falló al decodificar la política JSON: %w", err)
+		}
+	default:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&policy); err != nil {
+			return nil, fmt.Errorf("falló al decodificar la política YAML: %w", err)
+		}
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// Validate verifica los valores de la política y compila los patrones de cifrados
+func (p *Policy) Validate() error {
+	if p.MinGrade != "" && !model.IsValidGrade(p.MinGrade) {
+		return fmt.Errorf("política inválida: calificación mínima desconocida %q", p.MinGrade)
+	}
+
+	if p.MinForwardSecrecy != "" {
+		if _, ok := forwardSecrecyValues[p.MinForwardSecrecy]; !ok {
+			return fmt.Errorf("política inválida: minForwardSecrecy debe ser limited, modern o full")
+		}
+	}
+
+	if p.MinKeySize < 0 || p.MinCertDays < 0 {
+		return fmt.Errorf("política inválida: minKeySize y minCertDays no pueden ser negativos")
+	}
+
+	patterns, err := compilePatterns(p.ForbiddenCiphers)
+	if err != nil {
+		return fmt.Errorf("política inválida: %w", err)
+	}
+	p.cipherPatterns = patterns
+
+	return nil
+}
+
+// patterns devuelve los patrones compilados por Validate. Una política armada
+// en código puede no haberse validado: entonces se compilan en el momento, para
+// que la regla de cifrados nunca se omita.
+func (p *Policy) patterns() ([]*regexp.Regexp, error) {
+	if len(p.cipherPatterns) == len(p.ForbiddenCiphers) {
+		return p.cipherPatterns, nil
+	}
+	return compilePatterns(p.ForbiddenCiphers)
+}
+
+func compilePatterns(expressions []string) ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(expressions))
+	for _, expression := range expressions {
+		re, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, fmt.Errorf("patrón de cifrado %q: %w", expression, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// normalizeProtocol permite escribir "TLS 1.0", "TLSv1.0" o "tls1.0" indistintamente
+func normalizeProtocol(protocol string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(protocol, " ", ""))
+	return strings.Replace(normalized, "v", "", 1)
+}
@@ -0,0 +1,247 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sslscanner/model"
+)
+
+// strictPolicy tiene todas las reglas activas
+func strictPolicy(t *testing.T) *Policy {
+	t.Helper()
+
+	policy := &Policy{
+		MinGrade:            "A-",
+		ForbiddenProtocols:  []string{"SSL 3.0", "TLSv1.0"},
+		ForbiddenCiphers:    []string{"RC4", "_CBC_SHA$"},
+		MinKeySize:          2048,
+		HSTS:                &HSTSRule{Required: true, MinMaxAge: 15552000},
+		RequireOCSPStapling: true,
+		MinForwardSecrecy:   ForwardSecrecyModern,
+		MinCertDays:         21,
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	return policy
+}
+
+// compliantEndpoint cumple strictPolicy
+func compliantEndpoint() model.Endpoint {
+	return model.Endpoint{
+		IPAddress:     "192.0.2.1",
+		StatusMessage: "Ready",
+		Grade:         "A",
+		Details: &model.EndpointDetails{
+			Protocols: []model.Protocol{{Name: "TLS", Version: "1.2"}, {Name: "TLS", Version: "1.3"}},
+			Suites: &model.Suites{List: []model.Suite{
+				{Name: "TLS_AES_128_GCM_SHA256"},
+				{Name: "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256"},
+			}},
+			Key:            &model.Key{Alg: "RSA", Size: 2048, Strength: 2048},
+			HstsPolicy:     &model.HstsPolicy{Status: "present", MaxAge: 31536000},
+			OcspStapling:   true,
+			ForwardSecrecy: 4,
+			Cert:           &model.Cert{NotAfter: time.Now().Add(90 * 24 * time.Hour).UnixMilli()},
+		},
+	}
+}
+
+func ruleIDs(violations []Violation) []string {
+	ids := []string{}
+	for _, violation := range violations {
+		ids = append(ids, violation.RuleID)
+	}
+	return ids
+}
+
+func TestEvaluateCompliant(t *testing.T) {
+	host := &model.Host{Endpoints: []model.Endpoint{compliantEndpoint()}}
+	if violations := strictPolicy(t).Evaluate(host); len(violations) != 0 {
+		t.Errorf("violaciones inesperadas: %v", violations)
+	}
+}
+
+func TestEvaluateRules(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		modify  func(*model.Endpoint)
+		rule    string
+		message string
+	}{
+		{
+			name:    "endpoint sin terminar",
+			modify:  func(e *model.Endpoint) { e.StatusMessage = "Unable to connect to the server"; e.Details = nil },
+			rule:    RuleEndpointReady,
+			message: "Unable to connect to the server",
+		},
+		{
+			name:    "calificación",
+			modify:  func(e *model.Endpoint) { e.Grade = "B" },
+			rule:    RuleMinGrade,
+			message: `calificación "B" inferior al mínimo A-`,
+		},
+		{
+			name: "protocolo",
+			modify: func(e *model.Endpoint) {
+				e.Details.Protocols = append(e.Details.Protocols, model.Protocol{Name: "TLS", Version: "1.0"})
+			},
+			rule:    RuleForbiddenProtocol,
+			message: "TLS 1.0",
+		},
+		{
+			name: "cifrado",
+			modify: func(e *model.Endpoint) {
+				e.Details.Suites.List = append(e.Details.Suites.List, model.Suite{Name: "TLS_RSA_WITH_RC4_128_SHA"})
+			},
+			rule:    RuleForbiddenCipher,
+			message: "TLS_RSA_WITH_RC4_128_SHA",
+		},
+		{
+			name:    "clave RSA",
+			modify:  func(e *model.Endpoint) { e.Details.Key = &model.Key{Alg: "RSA", Size: 1024, Strength: 1024} },
+			rule:    RuleMinKeySize,
+			message: "clave RSA de 1024 bits inferior al mínimo de 2048 bits",
+		},
+		{
+			name:    "clave EC",
+			modify:  func(e *model.Endpoint) { e.Details.Key = &model.Key{Alg: "EC", Size: 160, Strength: 1024} },
+			rule:    RuleMinKeySize,
+			message: "clave EC de 160 bits (equivalente a 1024 bits RSA) inferior al mínimo de 2048 bits",
+		},
+		{
+			name:    "HSTS ausente",
+			modify:  func(e *model.Endpoint) { e.Details.HstsPolicy = &model.HstsPolicy{Status: "absent"} },
+			rule:    RuleHSTSRequired,
+			message: "HSTS no está habilitado",
+		},
+		{
+			name:    "HSTS corto",
+			modify:  func(e *model.Endpoint) { e.Details.HstsPolicy.MaxAge = 86400 },
+			rule:    RuleHSTSMaxAge,
+			message: "max-age de HSTS (86400)",
+		},
+		{
+			name:    "OCSP stapling",
+			modify:  func(e *model.Endpoint) { e.Details.OcspStapling = false },
+			rule:    RuleOCSPStapling,
+			message: "OCSP stapling",
+		},
+		{
+			name:    "forward secrecy",
+			modify:  func(e *model.Endpoint) { e.Details.ForwardSecrecy = 1 },
+			rule:    RuleForwardSecrecy,
+			message: "nivel modern",
+		},
+		{
+			name: "vencimiento",
+			modify: func(e *model.Endpoint) {
+				e.Details.Cert.NotAfter = time.Now().Add(10*24*time.Hour + time.Hour).UnixMilli()
+			},
+			rule:    RuleMinCertDays,
+			message: "el certificado expira en 10 días",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint := compliantEndpoint()
+			tc.modify(&endpoint)
+
+			violations := strictPolicy(t).Evaluate(&model.Host{Endpoints: []model.Endpoint{endpoint}})
+			if len(violations) != 1 || violations[0].RuleID != tc.rule {
+				t.Fatalf("violaciones = %v, se esperaba solo %s", ruleIDs(violations), tc.rule)
+			}
+			if violations[0].IPAddress != "192.0.2.1" || !strings.Contains(violations[0].Message, tc.message) {
+				t.Errorf("violación = %s, se esperaba el mensaje %q", violations[0], tc.message)
+			}
+		})
+	}
+}
+
+func TestEvaluateUnsetRulesAreSkipped(t *testing.T) {
+	endpoint := compliantEndpoint()
+	endpoint.Grade = "F"
+	endpoint.Details.Key = &model.Key{Alg: "RSA", Size: 512}
+	endpoint.Details.HstsPolicy = nil
+
+	if violations := (&Policy{}).Evaluate(&model.Host{Endpoints: []model.Endpoint{endpoint}}); len(violations) != 0 {
+		t.Errorf("una política vacía no debería reportar violaciones: %v", violations)
+	}
+}
+
+func TestEvaluateWithoutValidate(t *testing.T) {
+	endpoint := compliantEndpoint()
+	endpoint.Details.Suites.List = append(endpoint.Details.Suites.List, model.Suite{Name: "TLS_RSA_WITH_3DES_EDE_CBC_SHA"})
+	host := &model.Host{Endpoints: []model.Endpoint{endpoint}}
+
+	// una política armada en código no pasa por Validate
+	policy := &Policy{ForbiddenCiphers: []string{"3DES"}}
+	if got := ruleIDs(policy.Evaluate(host)); len(got) != 1 || got[0] != RuleForbiddenCipher {
+		t.Errorf("violaciones = %v, la regla de cifrados no debería omitirse", got)
+	}
+
+	invalid := &Policy{ForbiddenCiphers: []string{"(RC4"}}
+	violations := invalid.Evaluate(host)
+	if len(violations) != 1 || violations[0].RuleID != RuleForbiddenCipher || violations[0].IPAddress != "" {
+		t.Errorf("violaciones = %v, un patrón inválido debería hacer fallar la política", violations)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for name, policy := range map[string]Policy{
+		"calificación":    {MinGrade: "Z"},
+		"forward secrecy": {MinForwardSecrecy: "total"},
+		"clave negativa":  {MinKeySize: -1},
+		"días negativos":  {MinCertDays: -1},
+		"patrón":          {ForbiddenCiphers: []string{"(RC4"}},
+	} {
+		if err := policy.Validate(); err == nil || !strings.HasPrefix(err.Error(), "política inválida") {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	policy, err := Load(filepath.Join("..", "examples", "policy.yaml"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if policy.MinGrade != "A-" || len(policy.cipherPatterns) != len(policy.ForbiddenCiphers) || policy.HSTS == nil {
+		t.Errorf("política = %+v", policy)
+	}
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	policy, err = Load(write("policy.json", `{"minGrade":"B","forbiddenCiphers":["RC4"]}`))
+	if err != nil || policy.MinGrade != "B" || len(policy.cipherPatterns) != 1 {
+		t.Errorf("Load JSON = %+v, %v", policy, err)
+	}
+
+	for name, content := range map[string]string{
+		"unknown.yaml": "minGrade: A\nmaxGrade: A+\n",
+		"unknown.json": `{"minGrade":"A","maxGrade":"A+"}`,
+		"invalid.yml":  "minGrade: Z\n",
+	} {
+		if _, err := Load(write(name, content)); err == nil {
+			t.Errorf("Load(%s) no falló", name)
+		}
+	}
+}
+
+func TestNormalizeProtocol(t *testing.T) {
+	for _, protocol := range []string{"TLS 1.0", "TLSv1.0", "tls1.0", "TLS1.0"} {
+		if got := normalizeProtocol(protocol); got != "tls1.0" {
+			t.Errorf("normalizeProtocol(%q) = %q", protocol, got)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package prober
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"sslscanner/client"
+	"sslscanner/model"
+)
+
+// MaxAssessments limita los análisis locales en paralelo: cada uno abre
+// decenas de conexiones contra el servidor
+const MaxAssessments = 4
+
+// Backend adapta el prober a service.Backend. StartAnalysis analiza de forma
+// síncrona y entrega el resultado terminado; CheckAnalysisStatus y
+// GetEndpointDetails responden con el último resultado de cada destino.
+type Backend struct {
+	prober *Prober
+
+	mu      sync.Mutex
+	results map[string]*model.Host
+}
+
+func NewBackend(opts Options) *Backend {
+	return &Backend{
+		prober:  New(opts),
+		results: make(map[string]*model.Host),
+	}
+}
+
+// ValidateTarget acepta dominios, IPs y puertos no estándar (host:puerto)
+func (b *Backend) ValidateTarget(target string) error {
+	_, _, err := SplitTarget(target)
+	return err
+}
+
+func (b *Backend) GetInfo(ctx context.Context) (*model.Info, error) {
+	return &model.Info{
+		EngineVersion:   EngineVersion,
+		CriteriaVersion: CriteriaVersion,
+		MaxAssessments:  MaxAssessments,
+	}, nil
+}
+
+func (b *Backend) StartAnalysis(ctx context.Context, domain string, opts client.AnalyzeOptions) (*model.Host, error) {
+	host, err := b.prober.Probe(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	b.results[domain] = host
+	b.mu.Unlock()
+
+	return host, nil
+}
+
+func (b *Backend) CheckAnalysisStatus(ctx context.Context, domain string, opts client.AnalyzeOptions) (*model.Host, error) {
+	b.mu.Lock()
+	host, ok := b.results[domain]
+	b.mu.Unlock()
+
+	if !ok {
+		return b.StartAnalysis(ctx, domain, opts)
+	}
+	return host, nil
+}
+
+func (b *Backend) GetEndpointDetails(ctx context.Context, domain, ipAddress string) (*model.Endpoint, error) {
+	b.mu.Lock()
+	host, ok := b.results[domain]
+	b.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("no hay un análisis local de %s", domain)
+	}
+
+	for _, endpoint := range host.Endpoints {
+		if endpoint.IPAddress == ipAddress {
+			return &endpoint, nil
+		}
+	}
+
+	return nil, fmt.Errorf("endpoint %s no encontrado en %s", ipAddress, domain)
+}
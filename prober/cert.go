@@ -0,0 +1,272 @@
+package prober
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"time"
+
+	"sslscanner/model"
+)
+
+// Bits del campo issues de model.Cert, como los publica SSL Labs
+const (
+	issueNoChainOfTrust    = 1
+	issueNotYetValid       = 2
+	issueExpired           = 4
+	issueHostnameMismatch  = 8
+	issueSelfSigned        = 64
+	issueInsecureSignature = 256
+)
+
+// Bits de revocationInfo
+const (
+	revocationCRL  = 1
+	revocationOCSP = 2
+)
+
+// oidSCTList es la extensión con los Signed Certificate Timestamps embebidos
+var oidSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// certificateDetails arma Cert, Key y Chain a partir de la cadena que envió el
+// servidor, verificándola contra roots (nil usa las CA del sistema)
+func certificateDetails(certs []*x509.Certificate, serverName string, roots *x509.CertPool, now time.Time) (*model.Cert, *model.Key, *model.Chain) {
+	if len(certs) == 0 {
+		return nil, nil, nil
+	}
+
+	leaf := certs[0]
+
+	cert := &model.Cert{
+		Subject:        leaf.Subject.String(),
+		CommonNames:    commonNames(leaf),
+		AltNames:       altNames(leaf),
+		NotBefore:      leaf.NotBefore.UnixMilli(),
+		NotAfter:       leaf.NotAfter.UnixMilli(),
+		IssuerSubject:  leaf.Issuer.String(),
+		SigAlg:         signatureAlgorithm(leaf.SignatureAlgorithm),
+		IssuerLabel:    leaf.Issuer.CommonName,
+		CrlURIs:        leaf.CRLDistributionPoints,
+		OcspURIs:       leaf.OCSPServer,
+		Issues:         certificateIssues(certs, serverName, roots, now),
+		SCT:            hasExtension(leaf, oidSCTList),
+		SerialNumber:   hex.EncodeToString(leaf.SerialNumber.Bytes()),
+		Sha256Hash:     sha256Hex(leaf.Raw),
+		RevocationInfo: revocationInfo(leaf),
+	}
+
+	keyAlg, keySize, keyStrength := publicKeyInfo(leaf)
+	key := &model.Key{Alg: keyAlg, Size: keySize, Strength: keyStrength}
+
+	chain := &model.Chain{}
+	for _, c := range certs {
+		alg, size, strength := publicKeyInfo(c)
+		chain.Certs = append(chain.Certs, model.ChainCert{
+			Subject:       c.Subject.String(),
+			Label:         certLabel(c),
+			NotBefore:     c.NotBefore.UnixMilli(),
+			NotAfter:      c.NotAfter.UnixMilli(),
+			IssuerSubject: c.Issuer.String(),
+			IssuerLabel:   c.Issuer.CommonName,
+			SigAlg:        signatureAlgorithm(c.SignatureAlgorithm),
+			KeyAlg:        alg,
+			KeySize:       size,
+			KeyStrength:   strength,
+			Raw:           string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})),
+		})
+	}
+
+	return cert, key, chain
+}
+
+// certificateIssues evalúa cada problema por separado: x509.Verify se detiene
+// en el primero y SSL Labs los informa todos
+func certificateIssues(certs []*x509.Certificate, serverName string, roots *x509.CertPool, now time.Time) int {
+	leaf := certs[0]
+	issues := 0
+
+	if now.Before(leaf.NotBefore) {
+		issues |= issueNotYetValid
+	}
+	if now.After(leaf.NotAfter) {
+		issues |= issueExpired
+	}
+
+	if serverName != "" && leaf.VerifyHostname(serverName) != nil {
+		issues |= issueHostnameMismatch
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+
+	// la confianza se verifica dentro del período de validez para no mezclarla con la expiración
+	verifyTime := now
+	if issues&(issueExpired|issueNotYetValid) != 0 {
+		verifyTime = leaf.NotBefore.Add(leaf.NotAfter.Sub(leaf.NotBefore) / 2)
+	}
+
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   verifyTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		issues |= issueNoChainOfTrust
+		if selfSigned(leaf) {
+			issues |= issueSelfSigned
+		}
+	}
+
+	var insecure x509.InsecureAlgorithmError
+	if errors.As(err, &insecure) || weakSignature(leaf.SignatureAlgorithm) {
+		issues |= issueInsecureSignature
+	}
+
+	return issues
+}
+
+// selfSigned verifica la firma con la propia clave. CheckSignatureFrom no sirve:
+// exige que el emisor sea una CA y muchos certificados autofirmados no lo son.
+func selfSigned(cert *x509.Certificate) bool {
+	return cert.Subject.String() == cert.Issuer.String() &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+func weakSignature(alg x509.SignatureAlgorithm) bool {
+	switch alg {
+	case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+		return true
+	}
+	return false
+}
+
+// publicKeyInfo devuelve algoritmo, tamaño y fuerza equivalente a RSA, como SSL Labs
+func publicKeyInfo(cert *x509.Certificate) (string, int, int) {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		size := key.N.BitLen()
+		return "RSA", size, size
+	case *ecdsa.PublicKey:
+		size := key.Curve.Params().BitSize
+		return "EC", size, ecStrength(size)
+	case ed25519.PublicKey:
+		return "EdDSA", 256, 3072
+	default:
+		return cert.PublicKeyAlgorithm.String(), 0, 0
+	}
+}
+
+// ecStrength es la fuerza RSA equivalente de una curva (NIST SP 800-57)
+func ecStrength(bits int) int {
+	switch {
+	case bits >= 512:
+		return 15360
+	case bits >= 384:
+		return 7680
+	case bits >= 253: // x25519 equivale a P-256
+		return 3072
+	case bits >= 224:
+		return 2048
+	default:
+		return 1024
+	}
+}
+
+// signatureAlgorithm usa los nombres de SSL Labs (SHA256withRSA) en lugar de los de Go
+func signatureAlgorithm(alg x509.SignatureAlgorithm) string {
+	switch alg {
+	case x509.MD5WithRSA:
+		return "MD5withRSA"
+	case x509.SHA1WithRSA:
+		return "SHA1withRSA"
+	case x509.SHA256WithRSA:
+		return "SHA256withRSA"
+	case x509.SHA384WithRSA:
+		return "SHA384withRSA"
+	case x509.SHA512WithRSA:
+		return "SHA512withRSA"
+	case x509.SHA256WithRSAPSS:
+		return "SHA256withRSASSA-PSS"
+	case x509.SHA384WithRSAPSS:
+		return "SHA384withRSASSA-PSS"
+	case x509.SHA512WithRSAPSS:
+		return "SHA512withRSASSA-PSS"
+	case x509.ECDSAWithSHA1:
+		return "SHA1withECDSA"
+	case x509.ECDSAWithSHA256:
+		return "SHA256withECDSA"
+	case x509.ECDSAWithSHA384:
+		return "SHA384withECDSA"
+	case x509.ECDSAWithSHA512:
+		return "SHA512withECDSA"
+	default:
+		return alg.String()
+	}
+}
+
+func altNames(cert *x509.Certificate) []string {
+	names := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	return names
+}
+
+func commonNames(cert *x509.Certificate) []string {
+	if cert.Subject.CommonName == "" {
+		return []string{}
+	}
+	return []string{cert.Subject.CommonName}
+}
+
+func certLabel(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	return cert.Subject.String()
+}
+
+func revocationInfo(cert *x509.Certificate) int {
+	info := 0
+	if len(cert.CRLDistributionPoints) > 0 {
+		info |= revocationCRL
+	}
+	if len(cert.OCSPServer) > 0 {
+		info |= revocationOCSP
+	}
+	return info
+}
+
+func hasExtension(cert *x509.Certificate, oid asn1.ObjectIdentifier) bool {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oid) {
+			return true
+		}
+	}
+	return false
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// parseChain decodifica los certificados DER; se descartan los que no se entienden
+func parseChain(raw [][]byte) []*x509.Certificate {
+	certs := []*x509.Certificate{}
+	for _, der := range raw {
+		if cert, err := x509.ParseCertificate(der); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+	return certs
+}
@@ -0,0 +1,449 @@
+package prober
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// Versiones del protocolo tal como viajan en el ClientHello
+const (
+	versionSSL20 uint16 = 0x0002
+	versionSSL30 uint16 = 0x0300
+	versionTLS10 uint16 = 0x0301
+	versionTLS11 uint16 = 0x0302
+	versionTLS12 uint16 = 0x0303
+	versionTLS13 uint16 = 0x0304
+)
+
+const (
+	recordHandshake = 22
+	recordAlert     = 21
+
+	handshakeServerHello       = 2
+	handshakeCertificate       = 11
+	handshakeServerKeyExchange = 12
+	handshakeServerHelloDone   = 14
+
+	alertInappropriateFallback = 86
+
+	extServerName          = 0
+	extSupportedGroups     = 10
+	extECPointFormats      = 11
+	extSignatureAlgorithms = 13
+	extSupportedVersions   = 43
+	extPSKModes            = 45
+	extKeyShare            = 51
+	extRenegotiationInfo   = 0xff01
+
+	groupX25519    = 29
+	groupSecp256r1 = 23
+	groupSecp384r1 = 24
+	groupSecp521r1 = 25
+
+	maxHandshakeSize = 1 << 18
+)
+
+// errNoHandshake indica que el servidor cerró la conexión o respondió algo que
+// no es un handshake TLS: se interpreta como versión o suites no soportadas
+var errNoHandshake = errors.New("el servidor no respondió con un handshake TLS")
+
+// alertError es una alerta TLS enviada por el servidor en lugar del ServerHello
+type alertError struct {
+	description byte
+}
+
+func (e *alertError) Error() string {
+	return fmt.Sprintf("alerta TLS %d", e.description)
+}
+
+// helloRequest es un ClientHello armado a mano. A diferencia de crypto/tls
+// permite ofrecer cualquier versión y suite, incluidas las inseguras.
+type helloRequest struct {
+	version    uint16
+	suites     []uint16
+	serverName string
+	// fallback agrega TLS_FALLBACK_SCSV para probar la protección contra downgrade
+	fallback bool
+	// readAll sigue leyendo hasta ServerHelloDone para obtener certificado y
+	// ServerKeyExchange (solo hasta TLS 1.2, donde viajan sin cifrar)
+	readAll bool
+}
+
+// serverHello es lo que interesa de la respuesta del servidor
+type serverHello struct {
+	version   uint16
+	suite     uint16
+	sessionID []byte
+	// group es el grupo del key_share de TLS 1.3
+	group uint16
+	// certificates son los certificados DER del mensaje Certificate (hasta TLS 1.2)
+	certificates [][]byte
+	// keyExchange es el cuerpo del ServerKeyExchange (hasta TLS 1.2)
+	keyExchange []byte
+}
+
+// sendHello abre una conexión, envía el ClientHello y devuelve el ServerHello
+func sendHello(ctx context.Context, dialer *net.Dialer, address string, timeout time.Duration, req helloRequest) (*serverHello, error) {
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if _, err := conn.Write(buildClientHello(req)); err != nil {
+		return nil, errNoHandshake
+	}
+
+	return readServerHello(conn, req)
+}
+
+func buildClientHello(req helloRequest) []byte {
+	suites := append([]uint16(nil), req.suites...)
+	if req.fallback {
+		suites = append(suites, suiteFallbackSCSV)
+	}
+
+	legacyVersion := req.version
+	if req.version == versionTLS13 {
+		legacyVersion = versionTLS12
+	}
+
+	var body bytes.Buffer
+	writeUint16(&body, legacyVersion)
+	body.Write(randomBytes(32))
+	body.WriteByte(0) // sin session id
+
+	writeUint16(&body, uint16(len(suites)*2))
+	for _, suite := range suites {
+		writeUint16(&body, suite)
+	}
+
+	body.WriteByte(1) // un método de compresión: null
+	body.WriteByte(0)
+
+	// SSLv3 no tiene extensiones y algunos servidores rechazan el hello si las trae
+	if req.version > versionSSL30 {
+		extensions := buildExtensions(req)
+		writeUint16(&body, uint16(len(extensions)))
+		body.Write(extensions)
+	}
+
+	var handshake bytes.Buffer
+	handshake.WriteByte(1) // ClientHello
+	writeUint24(&handshake, body.Len())
+	handshake.Write(body.Bytes())
+
+	recordVersion := versionTLS10
+	if req.version == versionSSL30 {
+		recordVersion = versionSSL30
+	}
+
+	var record bytes.Buffer
+	record.WriteByte(recordHandshake)
+	writeUint16(&record, recordVersion)
+	writeUint16(&record, uint16(handshake.Len()))
+	record.Write(handshake.Bytes())
+
+	return record.Bytes()
+}
+
+func buildExtensions(req helloRequest) []byte {
+	var ext bytes.Buffer
+
+	if req.serverName != "" {
+		var sni bytes.Buffer
+		writeUint16(&sni, uint16(len(req.serverName)+3))
+		sni.WriteByte(0) // host_name
+		writeUint16(&sni, uint16(len(req.serverName)))
+		sni.WriteString(req.serverName)
+		writeExtension(&ext, extServerName, sni.Bytes())
+	}
+
+	groups := []uint16{groupX25519, groupSecp256r1, groupSecp384r1, groupSecp521r1}
+	var groupList bytes.Buffer
+	writeUint16(&groupList, uint16(len(groups)*2))
+	for _, group := range groups {
+		writeUint16(&groupList, group)
+	}
+	writeExtension(&ext, extSupportedGroups, groupList.Bytes())
+
+	writeExtension(&ext, extECPointFormats, []byte{1, 0})
+
+	signatures := []uint16{0x0403, 0x0503, 0x0603, 0x0804, 0x0805, 0x0806, 0x0401, 0x0501, 0x0601, 0x0203, 0x0201, 0x0402, 0x0202}
+	var sigList bytes.Buffer
+	writeUint16(&sigList, uint16(len(signatures)*2))
+	for _, sig := range signatures {
+		writeUint16(&sigList, sig)
+	}
+	writeExtension(&ext, extSignatureAlgorithms, sigList.Bytes())
+
+	writeExtension(&ext, extRenegotiationInfo, []byte{0})
+
+	if req.version == versionTLS13 {
+		var versions bytes.Buffer
+		versions.WriteByte(2)
+		writeUint16(&versions, versionTLS13)
+		writeExtension(&ext, extSupportedVersions, versions.Bytes())
+		writeExtension(&ext, extPSKModes, []byte{1, 1})
+
+		// cualquier valor de 32 bytes es una clave pública X25519 válida; no
+		// hace falta completar el handshake para conocer la suite elegida
+		var share bytes.Buffer
+		writeUint16(&share, 36)
+		writeUint16(&share, groupX25519)
+		writeUint16(&share, 32)
+		share.Write(randomBytes(32))
+		writeExtension(&ext, extKeyShare, share.Bytes())
+	}
+
+	return ext.Bytes()
+}
+
+// readServerHello lee registros hasta obtener el ServerHello (y, con readAll,
+// hasta ServerHelloDone). Una alerta se devuelve como *alertError.
+func readServerHello(conn net.Conn, req helloRequest) (*serverHello, error) {
+	var handshake []byte
+	var hello *serverHello
+
+	for {
+		header := make([]byte, 5)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			if hello != nil {
+				return hello, nil
+			}
+			return nil, errNoHandshake
+		}
+
+		length := int(binary.BigEndian.Uint16(header[3:5]))
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			if hello != nil {
+				return hello, nil
+			}
+			return nil, errNoHandshake
+		}
+
+		switch header[0] {
+		case recordAlert:
+			if hello != nil {
+				return hello, nil
+			}
+			if len(payload) >= 2 {
+				return nil, &alertError{description: payload[1]}
+			}
+			return nil, errNoHandshake
+		case recordHandshake:
+			handshake = append(handshake, payload...)
+			if len(handshake) > maxHandshakeSize {
+				return nil, errNoHandshake
+			}
+		default:
+			// ChangeCipherSpec o datos cifrados: ya no hay más mensajes legibles
+			if hello != nil {
+				return hello, nil
+			}
+			return nil, errNoHandshake
+		}
+
+		for len(handshake) >= 4 {
+			msgLength := int(handshake[1])<<16 | int(handshake[2])<<8 | int(handshake[3])
+			if len(handshake) < 4+msgLength {
+				break
+			}
+			msgType, msg := handshake[0], handshake[4:4+msgLength]
+			handshake = handshake[4+msgLength:]
+
+			switch msgType {
+			case handshakeServerHello:
+				parsed, err := parseServerHello(msg)
+				if err != nil {
+					return nil, err
+				}
+				hello = parsed
+				if !req.readAll || hello.version == versionTLS13 {
+					return hello, nil
+				}
+			case handshakeCertificate:
+				if hello != nil {
+					hello.certificates = parseCertificates(msg)
+				}
+			case handshakeServerKeyExchange:
+				if hello != nil {
+					hello.keyExchange = msg
+				}
+			case handshakeServerHelloDone:
+				if hello != nil {
+					return hello, nil
+				}
+			}
+		}
+	}
+}
+
+func parseServerHello(msg []byte) (*serverHello, error) {
+	r := reader{data: msg}
+
+	hello := &serverHello{version: r.uint16()}
+	r.skip(32)
+	hello.sessionID = r.bytes(int(r.uint8()))
+	hello.suite = r.uint16()
+	r.skip(1)
+
+	if r.remaining() >= 2 {
+		extensions := reader{data: r.bytes(int(r.uint16()))}
+		for extensions.remaining() >= 4 {
+			extType := extensions.uint16()
+			data := reader{data: extensions.bytes(int(extensions.uint16()))}
+			switch extType {
+			case extSupportedVersions:
+				hello.version = data.uint16()
+			case extKeyShare:
+				hello.group = data.uint16()
+			}
+		}
+	}
+
+	if r.err {
+		return nil, errNoHandshake
+	}
+	return hello, nil
+}
+
+func parseCertificates(msg []byte) [][]byte {
+	r := reader{data: msg}
+	list := reader{data: r.bytes(r.uint24())}
+
+	certs := [][]byte{}
+	for list.remaining() > 3 && !list.err {
+		certs = append(certs, list.bytes(list.uint24()))
+	}
+	return certs
+}
+
+// probeSSLv2 envía un CLIENT-HELLO de SSLv2, que tiene un formato propio
+func probeSSLv2(ctx context.Context, dialer *net.Dialer, address string, timeout time.Duration) bool {
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	specs := []byte{
+		0x01, 0x00, 0x80, // RC4_128_WITH_MD5
+		0x02, 0x00, 0x80, // RC4_128_EXPORT40_WITH_MD5
+		0x03, 0x00, 0x80, // RC2_128_CBC_WITH_MD5
+		0x04, 0x00, 0x80, // RC2_128_CBC_EXPORT40_WITH_MD5
+		0x05, 0x00, 0x80, // IDEA_128_CBC_WITH_MD5
+		0x06, 0x00, 0x40, // DES_64_CBC_WITH_MD5
+		0x07, 0x00, 0xc0, // DES_192_EDE3_CBC_WITH_MD5
+	}
+	challenge := randomBytes(16)
+
+	var msg bytes.Buffer
+	msg.WriteByte(1) // CLIENT-HELLO
+	writeUint16(&msg, versionSSL20)
+	writeUint16(&msg, uint16(len(specs)))
+	writeUint16(&msg, 0)
+	writeUint16(&msg, uint16(len(challenge)))
+	msg.Write(specs)
+	msg.Write(challenge)
+
+	var record bytes.Buffer
+	writeUint16(&record, uint16(msg.Len())|0x8000)
+	record.Write(msg.Bytes())
+
+	if _, err := conn.Write(record.Bytes()); err != nil {
+		return false
+	}
+
+	response := make([]byte, 3)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return false
+	}
+
+	// encabezado de 2 bytes con el bit alto y luego SERVER-HELLO (4)
+	return response[0]&0x80 != 0 && response[2] == 4
+}
+
+func writeExtension(buf *bytes.Buffer, extType uint16, data []byte) {
+	writeUint16(buf, extType)
+	writeUint16(buf, uint16(len(data)))
+	buf.Write(data)
+}
+
+func writeUint16(buf *bytes.Buffer, v uint16) {
+	buf.WriteByte(byte(v >> 8))
+	buf.WriteByte(byte(v))
+}
+
+func writeUint24(buf *bytes.Buffer, v int) {
+	buf.WriteByte(byte(v >> 16))
+	buf.WriteByte(byte(v >> 8))
+	buf.WriteByte(byte(v))
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+// reader lee campos de longitud fija sin entrar en pánico ante mensajes truncados
+type reader struct {
+	data []byte
+	err  bool
+}
+
+func (r *reader) remaining() int {
+	return len(r.data)
+}
+
+func (r *reader) bytes(n int) []byte {
+	if n < 0 || n > len(r.data) {
+		r.err = true
+		r.data = nil
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *reader) skip(n int) {
+	r.bytes(n)
+}
+
+func (r *reader) uint8() uint8 {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *reader) uint16() uint16 {
+	b := r.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
+
+func (r *reader) uint24() int {
+	b := r.bytes(3)
+	if b == nil {
+		return 0
+	}
+	return int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+}
@@ -0,0 +1,360 @@
+package prober
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func tlsRecord(recordType byte, payload []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(recordType)
+	writeUint16(&buf, versionTLS12)
+	writeUint16(&buf, uint16(len(payload)))
+	buf.Write(payload)
+	return buf.Bytes()
+}
+
+func handshakeMessage(msgType byte, body []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(msgType)
+	writeUint24(&buf, len(body))
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+func serverHelloBody(version, suite uint16, sessionID []byte, extensions []byte) []byte {
+	var buf bytes.Buffer
+	writeUint16(&buf, version)
+	buf.Write(make([]byte, 32))
+	buf.WriteByte(byte(len(sessionID)))
+	buf.Write(sessionID)
+	writeUint16(&buf, suite)
+	buf.WriteByte(0)
+	if extensions != nil {
+		writeUint16(&buf, uint16(len(extensions)))
+		buf.Write(extensions)
+	}
+	return buf.Bytes()
+}
+
+func certificateBody(certs ...[]byte) []byte {
+	var list bytes.Buffer
+	for _, cert := range certs {
+		writeUint24(&list, len(cert))
+		list.Write(cert)
+	}
+	var buf bytes.Buffer
+	writeUint24(&buf, list.Len())
+	buf.Write(list.Bytes())
+	return buf.Bytes()
+}
+
+// readFrom ejecuta readServerHello sobre una conexión que entrega data y se cierra
+func readFrom(t *testing.T, data []byte, req helloRequest) (*serverHello, error) {
+	t.Helper()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		server.Write(data)
+		server.Close()
+	}()
+
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	return readServerHello(client, req)
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func TestReadServerHello(t *testing.T) {
+	sessionID := []byte{1, 2, 3, 4}
+	hello := handshakeMessage(handshakeServerHello, serverHelloBody(versionTLS12, 0xc02f, sessionID, nil))
+
+	got, err := readFrom(t, tlsRecord(recordHandshake, hello), helloRequest{version: versionTLS12})
+	if err != nil {
+		t.Fatalf("readServerHello: %v", err)
+	}
+	if got.version != versionTLS12 || got.suite != 0xc02f || !bytes.Equal(got.sessionID, sessionID) {
+		t.Errorf("hello = %+v", got)
+	}
+}
+
+func TestReadServerHelloFragmented(t *testing.T) {
+	// el ServerHello puede llegar partido en varios registros
+	hello := handshakeMessage(handshakeServerHello, serverHelloBody(versionTLS11, 0x002f, nil, nil))
+	data := concat(tlsRecord(recordHandshake, hello[:10]), tlsRecord(recordHandshake, hello[10:]))
+
+	got, err := readFrom(t, data, helloRequest{version: versionTLS11})
+	if err != nil {
+		t.Fatalf("readServerHello: %v", err)
+	}
+	if got.version != versionTLS11 || got.suite != 0x002f {
+		t.Errorf("hello = %+v", got)
+	}
+}
+
+func TestReadServerHelloTLS13(t *testing.T) {
+	var ext bytes.Buffer
+	writeExtension(&ext, extSupportedVersions, []byte{0x03, 0x04})
+	writeExtension(&ext, extKeyShare, []byte{0x00, groupX25519, 0x00, 0x02, 0xaa, 0xbb})
+
+	hello := handshakeMessage(handshakeServerHello, serverHelloBody(versionTLS12, 0x1301, nil, ext.Bytes()))
+	// en TLS 1.3 lo que sigue viaja cifrado: no se espera ServerHelloDone
+	data := concat(tlsRecord(recordHandshake, hello), tlsRecord(20, []byte{1}), tlsRecord(23, []byte{0xde, 0xad}))
+
+	got, err := readFrom(t, data, helloRequest{version: versionTLS13, readAll: true})
+	if err != nil {
+		t.Fatalf("readServerHello: %v", err)
+	}
+	if got.version != versionTLS13 || got.suite != 0x1301 || got.group != groupX25519 {
+		t.Errorf("hello = %+v", got)
+	}
+}
+
+func TestReadServerHelloReadAll(t *testing.T) {
+	certs := [][]byte{[]byte("leaf"), []byte("intermediate")}
+	keyExchange := []byte{3, 0, groupSecp256r1, 65}
+
+	// varios mensajes en un mismo registro
+	messages := concat(
+		handshakeMessage(handshakeServerHello, serverHelloBody(versionTLS12, 0xc02b, nil, nil)),
+		handshakeMessage(handshakeCertificate, certificateBody(certs...)),
+		handshakeMessage(handshakeServerKeyExchange, keyExchange),
+		handshakeMessage(handshakeServerHelloDone, nil),
+	)
+
+	got, err := readFrom(t, tlsRecord(recordHandshake, messages), helloRequest{version: versionTLS12, readAll: true})
+	if err != nil {
+		t.Fatalf("readServerHello: %v", err)
+	}
+	if len(got.certificates) != 2 || !bytes.Equal(got.certificates[0], certs[0]) || !bytes.Equal(got.certificates[1], certs[1]) {
+		t.Errorf("certificates = %q", got.certificates)
+	}
+	if !bytes.Equal(got.keyExchange, keyExchange) {
+		t.Errorf("keyExchange = %v", got.keyExchange)
+	}
+	if bits := keyExchangeFor(got); bits.ecdhBits != 256 {
+		t.Errorf("keyExchangeFor = %+v, se esperaba ECDHE de 256 bits", bits)
+	}
+}
+
+func TestReadServerHelloReadAllStopsEarly(t *testing.T) {
+	hello := handshakeMessage(handshakeServerHello, serverHelloBody(versionTLS12, 0xc02f, nil, nil))
+	certificate := handshakeMessage(handshakeCertificate, certificateBody([]byte("leaf")))
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"conexión cerrada", concat(tlsRecord(recordHandshake, hello), tlsRecord(recordHandshake, certificate))},
+		{"alerta", concat(tlsRecord(recordHandshake, hello), tlsRecord(recordHandshake, certificate), tlsRecord(recordAlert, []byte{2, 40}))},
+		{"ChangeCipherSpec", concat(tlsRecord(recordHandshake, hello), tlsRecord(recordHandshake, certificate), tlsRecord(20, []byte{1}))},
+		{"registro truncado", concat(tlsRecord(recordHandshake, hello), tlsRecord(recordHandshake, certificate), []byte{recordHandshake, 3, 3, 0, 50, 1})},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := readFrom(t, tc.data, helloRequest{version: versionTLS12, readAll: true})
+			if err != nil {
+				t.Fatalf("readServerHello: %v", err)
+			}
+			if got.suite != 0xc02f || len(got.certificates) != 1 {
+				t.Errorf("hello = %+v, se esperaba el ServerHello con su certificado", got)
+			}
+		})
+	}
+}
+
+func TestReadServerHelloAlert(t *testing.T) {
+	_, err := readFrom(t, tlsRecord(recordAlert, []byte{2, alertInappropriateFallback}), helloRequest{version: versionTLS12})
+
+	var alert *alertError
+	if !errors.As(err, &alert) || alert.description != alertInappropriateFallback {
+		t.Errorf("err = %v, se esperaba la alerta %d", err, alertInappropriateFallback)
+	}
+}
+
+func TestReadServerHelloMalformed(t *testing.T) {
+	oversized := []byte{handshakeServerHello, 0xff, 0xff, 0xff}
+	for oversizedLen := 0; oversizedLen <= maxHandshakeSize; oversizedLen += 1 << 14 {
+		oversized = append(oversized, make([]byte, 1<<14)...)
+	}
+	var oversizedRecords []byte
+	for len(oversized) > 0 {
+		n := min(len(oversized), 1<<14)
+		oversizedRecords = append(oversizedRecords, tlsRecord(recordHandshake, oversized[:n])...)
+		oversized = oversized[n:]
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"sin respuesta", nil},
+		{"encabezado truncado", []byte{recordHandshake, 3, 3}},
+		{"registro más corto que su longitud", []byte{recordHandshake, 3, 3, 0, 40, 2, 0, 0}},
+		{"respuesta HTTP", []byte("HTTP/1.1 400 Bad Request\r\n\r\n")},
+		{"alerta sin descripción", tlsRecord(recordAlert, []byte{2})},
+		{"ServerHello truncado", tlsRecord(recordHandshake, handshakeMessage(handshakeServerHello, []byte{3, 3, 0, 0}))},
+		{"session id más largo que el mensaje", tlsRecord(recordHandshake, handshakeMessage(handshakeServerHello,
+			concat([]byte{3, 3}, make([]byte, 32), []byte{200, 1, 2})))},
+		{"extensiones truncadas", tlsRecord(recordHandshake, handshakeMessage(handshakeServerHello,
+			concat(serverHelloBody(versionTLS12, 0xc02f, nil, nil), []byte{0, 10, 0, 43})))},
+		{"handshake demasiado grande", oversizedRecords},
+		{"datos cifrados sin ServerHello", tlsRecord(23, []byte{1, 2, 3})},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := readFrom(t, tc.data, helloRequest{version: versionTLS12, readAll: true})
+			if !errors.Is(err, errNoHandshake) {
+				t.Errorf("readServerHello = %+v, %v; se esperaba errNoHandshake", got, err)
+			}
+		})
+	}
+}
+
+func TestParseCertificatesMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  []byte
+	}{
+		{"vacío", nil},
+		{"lista truncada", []byte{0, 0, 10, 0, 0, 3}},
+		{"certificado más largo que la lista", []byte{0, 0, 6, 0, 0, 50, 1, 2, 3}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, cert := range parseCertificates(tc.msg) {
+				if len(cert) > len(tc.msg) {
+					t.Errorf("certificado de %d bytes en un mensaje de %d", len(cert), len(tc.msg))
+				}
+			}
+		})
+	}
+}
+
+func TestReaderBounds(t *testing.T) {
+	r := reader{data: []byte{1, 2, 3}}
+	if r.uint16() != 0x0102 || r.remaining() != 1 {
+		t.Fatalf("uint16 leyó mal: quedan %d bytes", r.remaining())
+	}
+	if r.uint16() != 0 || !r.err {
+		t.Error("uint16 con un solo byte debería marcar el error")
+	}
+	if r.remaining() != 0 || r.uint8() != 0 || r.uint24() != 0 {
+		t.Error("después de un error no debería quedar nada por leer")
+	}
+
+	r = reader{data: []byte{1, 2}}
+	if r.bytes(-1) != nil || !r.err {
+		t.Error("una longitud negativa debería marcar el error")
+	}
+}
+
+// parsedClientHello es lo que verifica el test del ClientHello armado a mano
+type parsedClientHello struct {
+	recordVersion uint16
+	version       uint16
+	suites        []uint16
+	extensions    map[uint16][]byte
+}
+
+func parseClientHello(t *testing.T, data []byte) parsedClientHello {
+	t.Helper()
+
+	r := reader{data: data}
+	if r.uint8() != recordHandshake {
+		t.Fatal("el registro no es de handshake")
+	}
+	hello := parsedClientHello{recordVersion: r.uint16(), extensions: map[uint16][]byte{}}
+	if int(r.uint16()) != r.remaining() {
+		t.Fatal("la longitud del registro no coincide")
+	}
+	if r.uint8() != 1 || r.uint24() != r.remaining() {
+		t.Fatal("el mensaje no es un ClientHello completo")
+	}
+
+	hello.version = r.uint16()
+	r.skip(32)
+	r.skip(int(r.uint8()))
+	suites := reader{data: r.bytes(int(r.uint16()))}
+	for suites.remaining() > 0 {
+		hello.suites = append(hello.suites, suites.uint16())
+	}
+	r.skip(int(r.uint8()))
+
+	if r.remaining() > 0 {
+		extensions := reader{data: r.bytes(int(r.uint16()))}
+		for extensions.remaining() > 0 {
+			extType := extensions.uint16()
+			hello.extensions[extType] = extensions.bytes(int(extensions.uint16()))
+		}
+		if extensions.err {
+			t.Fatal("extensiones mal formadas")
+		}
+	}
+	if r.err || r.remaining() != 0 {
+		t.Fatal("ClientHello mal formado")
+	}
+
+	return hello
+}
+
+func TestBuildClientHello(t *testing.T) {
+	t.Run("TLS 1.2 con SNI y fallback", func(t *testing.T) {
+		hello := parseClientHello(t, buildClientHello(helloRequest{
+			version:    versionTLS12,
+			suites:     []uint16{0xc02f, 0x002f},
+			serverName: "example.com",
+			fallback:   true,
+		}))
+
+		if hello.recordVersion != versionTLS10 || hello.version != versionTLS12 {
+			t.Errorf("versiones = %#x/%#x", hello.recordVersion, hello.version)
+		}
+		if len(hello.suites) != 3 || hello.suites[2] != suiteFallbackSCSV {
+			t.Errorf("suites = %#x, se esperaba TLS_FALLBACK_SCSV al final", hello.suites)
+		}
+		if sni := hello.extensions[extServerName]; !bytes.HasSuffix(sni, []byte("example.com")) {
+			t.Errorf("SNI = %q", sni)
+		}
+		if _, ok := hello.extensions[extSupportedVersions]; ok {
+			t.Error("supported_versions solo se envía en TLS 1.3")
+		}
+	})
+
+	t.Run("TLS 1.3", func(t *testing.T) {
+		hello := parseClientHello(t, buildClientHello(helloRequest{version: versionTLS13, suites: []uint16{0x1301}}))
+
+		if hello.version != versionTLS12 {
+			t.Errorf("legacy_version = %#x, se esperaba TLS 1.2", hello.version)
+		}
+		if versions := hello.extensions[extSupportedVersions]; !bytes.Equal(versions, []byte{2, 3, 4}) {
+			t.Errorf("supported_versions = %v", versions)
+		}
+		if share := hello.extensions[extKeyShare]; len(share) != 38 {
+			t.Errorf("key_share de %d bytes, se esperaba una clave X25519", len(share))
+		}
+		if _, ok := hello.extensions[extServerName]; ok {
+			t.Error("sin serverName no se envía SNI")
+		}
+	})
+
+	t.Run("SSLv3 sin extensiones", func(t *testing.T) {
+		hello := parseClientHello(t, buildClientHello(helloRequest{version: versionSSL30, suites: []uint16{0x000a}}))
+
+		if hello.recordVersion != versionSSL30 || hello.version != versionSSL30 {
+			t.Errorf("versiones = %#x/%#x", hello.recordVersion, hello.version)
+		}
+		if len(hello.extensions) != 0 {
+			t.Errorf("extensiones = %v, SSLv3 no las admite", hello.extensions)
+		}
+	})
+}
@@ -0,0 +1,719 @@
+// Package prober es un motor de análisis local que no depende de SSL Labs:
+// se conecta directamente al servidor para enumerar protocolos, suites,
+// preferencia del servidor, certificados, OCSP stapling, ALPN y reanudación
+// de sesiones, y entrega el resultado con las mismas estructuras de model.
+// Permite analizar IPs privadas, puertos no estándar y entornos internos.
+package prober
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sslscanner/grading"
+	"sslscanner/model"
+)
+
+const (
+	EngineVersion   = "sslscanner-prober/1.0"
+	CriteriaVersion = "local"
+
+	DefaultPort    = 443
+	DefaultTimeout = 10 * time.Second
+)
+
+var hostnameRegex = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9\-]{0,61}[a-zA-Z0-9])?\.)*[a-zA-Z0-9]([a-zA-Z0-9\-]{0,61}[a-zA-Z0-9])?$`)
+
+// Options configura el prober
+type Options struct {
+	// Timeout es el tiempo máximo de cada conexión
+	Timeout time.Duration
+	// RootCAs son las CA contra las que se valida el certificado (nil = sistema)
+	RootCAs *x509.CertPool
+}
+
+type Prober struct {
+	timeout time.Duration
+	roots   *x509.CertPool
+	dialer  *net.Dialer
+}
+
+func New(opts Options) *Prober {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	return &Prober{
+		timeout: opts.Timeout,
+		roots:   opts.RootCAs,
+		dialer:  &net.Dialer{Timeout: opts.Timeout},
+	}
+}
+
+// SplitTarget separa host y puerto de "host", "host:puerto", "IP" o "[IPv6]:puerto"
+func SplitTarget(target string) (string, int, error) {
+	if target == "" {
+		return "", 0, fmt.Errorf("el destino no puede estar vacío")
+	}
+
+	host, portText, err := net.SplitHostPort(target)
+	if err != nil {
+		// sin puerto: un nombre, una IPv4 o una IPv6 sin corchetes
+		host, portText = strings.Trim(target, "[]"), strconv.Itoa(DefaultPort)
+	}
+
+	port, err := strconv.Atoi(portText)
+	if err != nil || port < 1 || port > 65535 {
+		return "", 0, fmt.Errorf("puerto inválido en %s", target)
+	}
+
+	if net.ParseIP(host) == nil && (len(host) > 253 || !hostnameRegex.MatchString(host)) {
+		return "", 0, fmt.Errorf("formato de destino inválido: %s", target)
+	}
+
+	return strings.ToLower(host), port, nil
+}
+
+// Probe analiza todos los endpoints (IPs) del destino
+func (p *Prober) Probe(ctx context.Context, target string) (*model.Host, error) {
+	hostname, port, err := SplitTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+
+	ips, err := p.resolve(ctx, hostname)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo resolver %s: %w", hostname, err)
+	}
+
+	host := &model.Host{
+		Host:            hostname,
+		Port:            port,
+		Protocol:        "http",
+		StartTime:       start.UnixMilli(),
+		EngineVersion:   EngineVersion,
+		CriteriaVersion: CriteriaVersion,
+	}
+
+	for _, ip := range ips {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("análisis cancelado: %w", err)
+		}
+		host.Endpoints = append(host.Endpoints, p.probeEndpoint(ctx, hostname, ip, port))
+	}
+
+	host.Status = "READY"
+	host.StatusMessage = "Ready"
+	host.TestTime = time.Now().UnixMilli()
+	for _, endpoint := range host.Endpoints {
+		if endpoint.Details != nil && endpoint.Details.Cert != nil {
+			host.CertHostnames = endpoint.Details.Cert.AltNames
+			break
+		}
+	}
+
+	return host, nil
+}
+
+func (p *Prober) resolve(ctx context.Context, hostname string) ([]string, error) {
+	if net.ParseIP(hostname) != nil {
+		return []string{hostname}, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP.String())
+	}
+	return sortedIPs(ips), nil
+}
+
+// endpointProbe acumula lo que se descubre de un endpoint
+type endpointProbe struct {
+	prober     *Prober
+	address    string
+	hostname   string
+	serverName string
+
+	// versions son las versiones soportadas, de la más nueva a la más vieja
+	versions []uint16
+	// suites por versión, en el orden en que el servidor las eligió
+	suites map[uint16][]uint16
+	// keyExchange guarda bits del intercambio efímero por suite
+	keyExchange map[uint16]keyExchangeInfo
+	preference  bool
+	sessionID   bool
+	sslv2       bool
+}
+
+type keyExchangeInfo struct {
+	ecdhBits int
+	dhBits   int
+}
+
+func (p *Prober) probeEndpoint(ctx context.Context, hostname, ip string, port int) model.Endpoint {
+	start := time.Now()
+	endpoint := model.Endpoint{
+		IPAddress:  ip,
+		ServerName: hostname,
+		Progress:   100,
+	}
+
+	e := &endpointProbe{
+		prober:      p,
+		address:     net.JoinHostPort(ip, strconv.Itoa(port)),
+		hostname:    hostname,
+		suites:      make(map[uint16][]uint16),
+		keyExchange: make(map[uint16]keyExchangeInfo),
+	}
+	if net.ParseIP(hostname) == nil {
+		e.serverName = hostname
+	}
+
+	conn, err := p.dialer.DialContext(ctx, "tcp", e.address)
+	if err != nil {
+		endpoint.StatusMessage = "Unable to connect to the server"
+		endpoint.Duration = time.Since(start).Milliseconds()
+		return endpoint
+	}
+	conn.Close()
+
+	e.probeProtocols(ctx)
+	if len(e.versions) == 0 && !e.sslv2 {
+		endpoint.StatusMessage = "No secure protocols supported"
+		endpoint.Duration = time.Since(start).Milliseconds()
+		return endpoint
+	}
+
+	for _, version := range e.versions {
+		e.enumerateSuites(ctx, version)
+	}
+	e.detectPreference(ctx)
+
+	endpoint.Details = e.details(ctx)
+	endpoint.StatusMessage = "Ready"
+	// sin clave (p. ej. sin certificado legible) el endpoint queda sin calificación
+	grading.Apply(&endpoint)
+	endpoint.Duration = time.Since(start).Milliseconds()
+
+	return endpoint
+}
+
+// probeProtocols prueba cada versión ofreciendo todas las suites del catálogo
+func (e *endpointProbe) probeProtocols(ctx context.Context) {
+	for _, version := range []uint16{versionTLS13, versionTLS12, versionTLS11, versionTLS10, versionSSL30} {
+		hello, err := e.hello(ctx, helloRequest{version: version, suites: suitesFor(version)})
+		if err == nil && hello.version == version {
+			e.versions = append(e.versions, version)
+			if len(hello.sessionID) > 0 {
+				e.sessionID = true
+			}
+		}
+	}
+
+	e.sslv2 = probeSSLv2(ctx, e.prober.dialer, e.address, e.prober.timeout)
+}
+
+// enumerateSuites ofrece las suites restantes y quita la elegida hasta que el
+// servidor rechaza el handshake
+func (e *endpointProbe) enumerateSuites(ctx context.Context, version uint16) {
+	remaining := suitesFor(version)
+
+	for len(remaining) > 0 {
+		hello, err := e.hello(ctx, helloRequest{
+			version:    version,
+			suites:     remaining,
+			serverName: e.serverName,
+			readAll:    version != versionTLS13,
+		})
+		if err != nil || hello.version != version || !containsSuite(remaining, hello.suite) {
+			return
+		}
+
+		e.suites[version] = append(e.suites[version], hello.suite)
+		if _, ok := e.keyExchange[hello.suite]; !ok {
+			e.keyExchange[hello.suite] = keyExchangeFor(hello)
+		}
+		remaining = removeSuite(remaining, hello.suite)
+	}
+}
+
+// detectPreference ofrece las dos primeras suites elegidas en orden inverso:
+// si el servidor vuelve a elegir la primera, impone su preferencia
+func (e *endpointProbe) detectPreference(ctx context.Context) {
+	for _, version := range e.versions {
+		suites := e.suites[version]
+		if len(suites) < 2 {
+			continue
+		}
+
+		hello, err := e.hello(ctx, helloRequest{
+			version:    version,
+			suites:     []uint16{suites[1], suites[0]},
+			serverName: e.serverName,
+		})
+		if err == nil {
+			e.preference = hello.suite == suites[0]
+		}
+		return
+	}
+}
+
+func (e *endpointProbe) hello(ctx context.Context, req helloRequest) (*serverHello, error) {
+	if req.serverName == "" {
+		req.serverName = e.serverName
+	}
+	return sendHello(ctx, e.prober.dialer, e.address, e.prober.timeout, req)
+}
+
+// details arma model.EndpointDetails con lo enumerado y un handshake completo
+// con crypto/tls para certificados, OCSP, ALPN, reanudación y HSTS
+func (e *endpointProbe) details(ctx context.Context) *model.EndpointDetails {
+	details := &model.EndpointDetails{
+		HostStartTime: time.Now().UnixMilli(),
+		Protocols:     e.protocols(),
+		Suites:        e.suiteList(),
+	}
+
+	e.vulnerabilities(ctx, details)
+	details.ForwardSecrecy = e.forwardSecrecy()
+
+	handshake := e.tlsHandshake(ctx)
+
+	certs := handshake.certs
+	if len(certs) == 0 {
+		// crypto/tls no negocia suites inseguras: el certificado se toma del
+		// mensaje Certificate del handshake manual (hasta TLS 1.2 viaja sin cifrar)
+		certs = e.rawCertificates(ctx)
+	}
+
+	details.Cert, details.Key, details.Chain = certificateDetails(certs, e.hostname, e.prober.roots, time.Now())
+
+	details.OcspStapling = len(handshake.ocsp) > 0
+	details.SupportsAlpn = len(handshake.alpn) > 0
+	details.AlpnProtocols = strings.Join(handshake.alpn, " ")
+	details.HTTPStatusCode = handshake.httpStatus
+	details.HstsPolicy = handshake.hsts
+
+	if details.Cert != nil && details.Cert.SCT {
+		details.HasSct |= 1
+	}
+	if handshake.stapledSCT {
+		details.HasSct |= 4
+	}
+
+	switch {
+	case handshake.resumed:
+		details.SessionResumption = 2
+		details.SessionTickets = 1
+	case e.sessionID:
+		details.SessionResumption = 1
+	}
+
+	return details
+}
+
+func (e *endpointProbe) protocols() []model.Protocol {
+	protocols := []model.Protocol{}
+
+	if e.sslv2 {
+		insecure := 0
+		protocols = append(protocols, model.Protocol{ID: int(versionSSL20) << 8, Name: "SSL", Version: "2.0", Q: &insecure})
+	}
+
+	for i := len(e.versions) - 1; i >= 0; i-- {
+		version := e.versions[i]
+		name, number := versionName(version)
+		protocols = append(protocols, model.Protocol{ID: int(version), Name: name, Version: number})
+	}
+
+	return protocols
+}
+
+// suiteList une las suites de todas las versiones, de la más nueva a la más vieja
+func (e *endpointProbe) suiteList() *model.Suites {
+	suites := &model.Suites{List: []model.Suite{}, Preference: e.preference}
+	seen := make(map[uint16]bool)
+
+	for _, version := range e.versions {
+		for _, id := range e.suites[version] {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			info := suiteInfo(id)
+			kex := e.keyExchange[id]
+			suite := model.Suite{
+				ID:             int(id),
+				Name:           info.name,
+				CipherStrength: info.strength,
+				EcdhBits:       kex.ecdhBits,
+				EcdhStrength:   ecStrength(kex.ecdhBits),
+				DhStrength:     kex.dhBits,
+				DhP:            kex.dhBits / 8,
+			}
+			if kex.ecdhBits == 0 {
+				suite.EcdhStrength = 0
+			}
+			if info.weak() {
+				weak := 0
+				suite.Q = &weak
+			}
+			suites.List = append(suites.List, suite)
+		}
+	}
+
+	return suites
+}
+
+// vulnerabilities deriva de las suites y versiones lo que se puede saber sin
+// explotar nada: POODLE (SSLv3 con CBC), BEAST, FREAK, Logjam, RC4 y la
+// protección TLS_FALLBACK_SCSV. Heartbleed, OpenSSL CCS y POODLE TLS no se prueban.
+func (e *endpointProbe) vulnerabilities(ctx context.Context, details *model.EndpointDetails) {
+	for _, version := range e.versions {
+		for _, id := range e.suites[version] {
+			suite := suiteInfo(id)
+
+			if suite.cbc() && version == versionSSL30 {
+				details.Poodle = true
+			}
+			if suite.cbc() && version <= versionTLS10 {
+				details.VulnBeast = true
+			}
+			if suite.export() && strings.Contains(suite.name, "_RSA_EXPORT") {
+				details.Freak = true
+			}
+			if suite.export() && strings.Contains(suite.name, "_DHE_") {
+				details.Logjam = true
+			}
+			if suite.rc4() {
+				details.SupportsRC4 = true
+				if version >= versionTLS11 {
+					details.RC4WithModern = true
+				}
+			}
+		}
+	}
+
+	if details.SupportsRC4 {
+		details.RC4Only = true
+		for _, suite := range details.Suites.List {
+			if !suiteInfo(uint16(suite.ID)).rc4() {
+				details.RC4Only = false
+				break
+			}
+		}
+	}
+
+	for _, suite := range details.Suites.List {
+		if suite.DhStrength > 0 && suite.DhStrength < 1024 {
+			details.Logjam = true
+		}
+	}
+
+	details.FallbackScsv = e.fallbackSCSV(ctx)
+}
+
+// fallbackSCSV simula un downgrade: ofrece una versión menor a la máxima con
+// TLS_FALLBACK_SCSV y espera la alerta inappropriate_fallback
+func (e *endpointProbe) fallbackSCSV(ctx context.Context) bool {
+	if len(e.versions) < 2 {
+		return false
+	}
+
+	version := e.versions[1]
+	_, err := e.hello(ctx, helloRequest{version: version, suites: suitesFor(version), fallback: true})
+
+	var alert *alertError
+	return errors.As(err, &alert) && alert.description == alertInappropriateFallback
+}
+
+// forwardSecrecy aproxima el campo de SSL Labs sin simular clientes: todos los
+// bits si todas las suites son efímeras, 1|2 si la preferida lo es, 1 si alguna
+func (e *endpointProbe) forwardSecrecy() int {
+	total, forward := 0, 0
+	preferred := false
+
+	for i, version := range e.versions {
+		for j, id := range e.suites[version] {
+			total++
+			if suiteInfo(id).forwardSecret() {
+				forward++
+				if i == 0 && j == 0 {
+					preferred = true
+				}
+			}
+		}
+	}
+
+	switch {
+	case total > 0 && forward == total:
+		return 1 | 2 | 4
+	case preferred:
+		return 1 | 2
+	case forward > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (e *endpointProbe) rawCertificates(ctx context.Context) []*x509.Certificate {
+	for _, version := range e.versions {
+		if version == versionTLS13 {
+			continue
+		}
+		hello, err := e.hello(ctx, helloRequest{version: version, suites: e.suites[version], readAll: true})
+		if err == nil && len(hello.certificates) > 0 {
+			return parseChain(hello.certificates)
+		}
+	}
+	return nil
+}
+
+// handshakeResult es lo que se obtiene con crypto/tls
+type handshakeResult struct {
+	certs      []*x509.Certificate
+	ocsp       []byte
+	stapledSCT bool
+	alpn       []string
+	resumed    bool
+	httpStatus int
+	hsts       *model.HstsPolicy
+}
+
+// tlsHandshake hace dos conexiones con crypto/tls: la primera con una
+// solicitud HTTP (HSTS y tickets de sesión) y la segunda para comprobar la
+// reanudación de sesión y si el servidor acepta h2
+func (e *endpointProbe) tlsHandshake(ctx context.Context) handshakeResult {
+	result := handshakeResult{}
+
+	suites := []uint16{}
+	for _, suite := range tls.CipherSuites() {
+		suites = append(suites, suite.ID)
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		suites = append(suites, suite.ID)
+	}
+
+	config := &tls.Config{
+		ServerName: e.serverName,
+		// la validación se hace aparte para informar cada problema del certificado
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS10,
+		CipherSuites:       suites,
+		NextProtos:         []string{"http/1.1"},
+		ClientSessionCache: tls.NewLRUClientSessionCache(1),
+	}
+
+	conn, err := e.dialTLS(ctx, config)
+	if err != nil {
+		return result
+	}
+
+	state := conn.ConnectionState()
+	result.certs = state.PeerCertificates
+	result.ocsp = state.OCSPResponse
+	result.stapledSCT = len(state.SignedCertificateTimestamps) > 0
+	if state.NegotiatedProtocol != "" {
+		result.alpn = append(result.alpn, state.NegotiatedProtocol)
+	}
+	result.httpStatus, result.hsts = e.fetchHSTS(conn)
+	conn.Close()
+
+	config.NextProtos = []string{"h2", "http/1.1"}
+	conn, err = e.dialTLS(ctx, config)
+	if err != nil {
+		return result
+	}
+	defer conn.Close()
+
+	state = conn.ConnectionState()
+	result.resumed = state.DidResume
+	if state.NegotiatedProtocol == "h2" {
+		result.alpn = append([]string{"h2"}, result.alpn...)
+	}
+
+	return result
+}
+
+func (e *endpointProbe) dialTLS(ctx context.Context, config *tls.Config) (*tls.Conn, error) {
+	dialer := &tls.Dialer{NetDialer: e.prober.dialer, Config: config}
+
+	conn, err := dialer.DialContext(ctx, "tcp", e.address)
+	if err != nil {
+		return nil, err
+	}
+	return conn.(*tls.Conn), nil
+}
+
+// fetchHSTS envía GET / y lee Strict-Transport-Security. La respuesta también
+// hace que el cliente procese los tickets de sesión de TLS 1.3.
+func (e *endpointProbe) fetchHSTS(conn *tls.Conn) (int, *model.HstsPolicy) {
+	conn.SetDeadline(time.Now().Add(e.prober.timeout))
+
+	request := fmt.Sprintf("GET / HTTP/1.1\r\nHost: %s\r\nUser-Agent: %s\r\nConnection: close\r\n\r\n",
+		e.hostname, EngineVersion)
+	if _, err := conn.Write([]byte(request)); err != nil {
+		return 0, nil
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return 0, nil
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode, parseHSTS(resp.Header.Get("Strict-Transport-Security"))
+}
+
+// parseHSTS interpreta la cabecera con los mismos campos que publica SSL Labs
+func parseHSTS(header string) *model.HstsPolicy {
+	if header == "" {
+		return &model.HstsPolicy{Status: "absent"}
+	}
+
+	policy := &model.HstsPolicy{Header: header, Status: "present"}
+	directives := map[string]string{}
+
+	for _, part := range strings.Split(header, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		directives[name] = value
+
+		switch name {
+		case "max-age":
+			maxAge, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				policy.Status = "invalid"
+				policy.Error = "max-age inválido"
+				continue
+			}
+			policy.MaxAge = maxAge
+		case "includesubdomains":
+			policy.IncludeSubDomains = true
+		case "preload":
+			policy.Preload = true
+		}
+	}
+
+	if _, ok := directives["max-age"]; !ok && policy.Status == "present" {
+		policy.Status = "invalid"
+		policy.Error = "falta la directiva max-age"
+	}
+	policy.Directives = directives
+
+	return policy
+}
+
+// keyExchangeFor extrae el tamaño del intercambio efímero del ServerKeyExchange
+// (hasta TLS 1.2) o del key_share (TLS 1.3)
+func keyExchangeFor(hello *serverHello) keyExchangeInfo {
+	if hello.group != 0 {
+		return keyExchangeInfo{ecdhBits: groupBits(hello.group)}
+	}
+
+	suite := suiteInfo(hello.suite)
+	if len(hello.keyExchange) == 0 {
+		return keyExchangeInfo{}
+	}
+
+	r := reader{data: hello.keyExchange}
+	switch {
+	case strings.Contains(suite.name, "_ECDHE_"):
+		if r.uint8() == 3 { // named_curve
+			return keyExchangeInfo{ecdhBits: groupBits(r.uint16())}
+		}
+	case strings.Contains(suite.name, "_DHE_"):
+		p := r.bytes(int(r.uint16()))
+		for len(p) > 0 && p[0] == 0 {
+			p = p[1:]
+		}
+		if !r.err && len(p) > 0 {
+			return keyExchangeInfo{dhBits: len(p) * 8}
+		}
+	}
+
+	return keyExchangeInfo{}
+}
+
+// groupBits devuelve el tamaño en bits de un grupo, como lo informa SSL Labs
+func groupBits(group uint16) int {
+	switch group {
+	case groupX25519:
+		return 253
+	case groupSecp256r1:
+		return 256
+	case groupSecp384r1:
+		return 384
+	case groupSecp521r1:
+		return 521
+	default:
+		return 0
+	}
+}
+
+func versionName(version uint16) (string, string) {
+	switch version {
+	case versionSSL30:
+		return "SSL", "3.0"
+	case versionTLS10:
+		return "TLS", "1.0"
+	case versionTLS11:
+		return "TLS", "1.1"
+	case versionTLS12:
+		return "TLS", "1.2"
+	case versionTLS13:
+		return "TLS", "1.3"
+	default:
+		return "SSL", "2.0"
+	}
+}
+
+func containsSuite(suites []uint16, id uint16) bool {
+	for _, suite := range suites {
+		if suite == id {
+			return true
+		}
+	}
+	return false
+}
+
+func removeSuite(suites []uint16, id uint16) []uint16 {
+	result := make([]uint16, 0, len(suites))
+	for _, suite := range suites {
+		if suite != id {
+			result = append(result, suite)
+		}
+	}
+	return result
+}
+
+// sortedIPs ordena IPv4 antes que IPv6, como SSL Labs
+func sortedIPs(ips []string) []string {
+	sort.SliceStable(ips, func(i, j int) bool {
+		return strings.Contains(ips[j], ":") && !strings.Contains(ips[i], ":")
+	})
+	return ips
+}
@@ -0,0 +1,332 @@
+package prober
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"sslscanner/model"
+)
+
+// testCert es un certificado con su clave, listo para tls.Certificate
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCert firma el certificado con parent, o lo autofirma si parent es nil
+func newTestCert(t *testing.T, template *x509.Certificate, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("no se pudo generar la clave: %v", err)
+	}
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	issuer, signer := template, key
+	if parent != nil {
+		issuer, signer = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatalf("no se pudo crear el certificado: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("no se pudo leer el certificado: %v", err)
+	}
+
+	return &testCert{cert: cert, key: key}
+}
+
+func caTemplate(name string) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+}
+
+func leafTemplate(notBefore, notAfter time.Time) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost"},
+		DNSNames:    []string{"localhost"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:   notBefore,
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+}
+
+// tlsCertificate arma la cadena que envía el servidor: el leaf y sus intermedios
+func tlsCertificate(leaf *testCert, chain ...*testCert) tls.Certificate {
+	certificate := tls.Certificate{Certificate: [][]byte{leaf.cert.Raw}, PrivateKey: leaf.key, Leaf: leaf.cert}
+	for _, c := range chain {
+		certificate.Certificate = append(certificate.Certificate, c.cert.Raw)
+	}
+	return certificate
+}
+
+// startServer atiende HTTPS con config y devuelve la dirección. Las respuestas
+// traen HSTS.
+func startServer(t *testing.T, config *tls.Config) string {
+	t.Helper()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatalf("no se pudo escuchar: %v", err)
+	}
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+			w.WriteHeader(http.StatusNoContent)
+		}),
+		// los ClientHello a mano nunca terminan el handshake
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	return listener.Addr().String()
+}
+
+func probeEndpoint(t *testing.T, address string, roots *x509.CertPool) model.Endpoint {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	host, err := New(Options{Timeout: 5 * time.Second, RootCAs: roots}).Probe(ctx, address)
+	if err != nil {
+		t.Fatalf("Probe: %v", err)
+	}
+	if host.Status != "READY" || len(host.Endpoints) != 1 {
+		t.Fatalf("host = %s con %d endpoints, se esperaba READY con 1", host.Status, len(host.Endpoints))
+	}
+
+	endpoint := host.Endpoints[0]
+	if endpoint.StatusMessage != "Ready" || endpoint.Details == nil {
+		t.Fatalf("endpoint = %q sin detalles", endpoint.StatusMessage)
+	}
+	return endpoint
+}
+
+func protocolVersions(protocols []model.Protocol) []string {
+	versions := []string{}
+	for _, proto := range protocols {
+		versions = append(versions, proto.Name+" "+proto.Version)
+	}
+	return versions
+}
+
+func suiteNames(suites *model.Suites) []string {
+	names := []string{}
+	for _, suite := range suites.List {
+		names = append(names, suite.Name)
+	}
+	return names
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestProbeRestrictedVersionsAndSuites(t *testing.T) {
+	ca := newTestCert(t, caTemplate("Test CA"), nil)
+	leaf := newTestCert(t, leafTemplate(time.Now().Add(-time.Hour), time.Now().Add(24*time.Hour)), ca)
+
+	address := startServer(t, &tls.Config{
+		Certificates: []tls.Certificate{tlsCertificate(leaf)},
+		MinVersion:   tls.VersionTLS12,
+		MaxVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		},
+	})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	details := probeEndpoint(t, address, roots).Details
+
+	if got := protocolVersions(details.Protocols); !equalStrings(got, []string{"TLS 1.2"}) {
+		t.Errorf("protocolos = %v, se esperaba solo TLS 1.2", got)
+	}
+
+	// crypto/tls elige con su propio orden: AES-128 antes que AES-256
+	want := []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"}
+	if got := suiteNames(details.Suites); !equalStrings(got, want) {
+		t.Errorf("suites = %v, se esperaba %v", got, want)
+	}
+	if !details.Suites.Preference {
+		t.Error("Suites.Preference = false, el servidor impone su orden")
+	}
+	for _, suite := range details.Suites.List {
+		if suite.EcdhBits == 0 {
+			t.Errorf("%s sin tamaño del intercambio ECDHE", suite.Name)
+		}
+	}
+
+	if details.Cert == nil || details.Cert.Issues != 0 {
+		t.Errorf("Cert.Issues = %v, se esperaba un certificado sin problemas", details.Cert)
+	}
+	if details.Key == nil || details.Key.Alg != "EC" || details.Key.Size != 256 {
+		t.Errorf("Key = %+v, se esperaba EC de 256 bits", details.Key)
+	}
+	if details.ForwardSecrecy != 1|2|4 {
+		t.Errorf("ForwardSecrecy = %d, todas las suites son efímeras", details.ForwardSecrecy)
+	}
+	// con una sola versión no se puede simular un downgrade
+	if details.FallbackScsv {
+		t.Error("FallbackScsv = true con una sola versión")
+	}
+}
+
+func TestProbeSelfSignedExpiredCertificate(t *testing.T) {
+	expired := newTestCert(t, leafTemplate(time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour)), nil)
+
+	address := startServer(t, &tls.Config{
+		Certificates: []tls.Certificate{tlsCertificate(expired)},
+		MinVersion:   tls.VersionTLS12,
+	})
+
+	// un pool vacío en lugar de las CA del sistema
+	details := probeEndpoint(t, address, x509.NewCertPool()).Details
+
+	if details.Cert == nil {
+		t.Fatal("sin certificado")
+	}
+	want := issueExpired | issueNoChainOfTrust | issueSelfSigned
+	if details.Cert.Issues != want {
+		t.Errorf("Cert.Issues = %b, se esperaba %b", details.Cert.Issues, want)
+	}
+	if details.Cert.Issues&issueHostnameMismatch != 0 {
+		t.Error("el certificado incluye la IP y no debería marcarse hostnameMismatch")
+	}
+	if details.Chain == nil || len(details.Chain.Certs) != 1 {
+		t.Errorf("Chain = %+v, se esperaba solo el certificado autofirmado", details.Chain)
+	}
+}
+
+func TestProbeChainAlpnOcspAndResumption(t *testing.T) {
+	root := newTestCert(t, caTemplate("Test Root"), nil)
+	intermediate := newTestCert(t, caTemplate("Test Intermediate"), root)
+	leaf := newTestCert(t, leafTemplate(time.Now().Add(-time.Hour), time.Now().Add(24*time.Hour)), intermediate)
+
+	certificate := tlsCertificate(leaf, intermediate)
+	// el contenido no se valida: alcanza con que el servidor lo engrape
+	certificate.OCSPStaple = []byte{0x30, 0x03, 0x0a, 0x01, 0x00}
+
+	address := startServer(t, &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+	details := probeEndpoint(t, address, roots).Details
+
+	if got := protocolVersions(details.Protocols); !equalStrings(got, []string{"TLS 1.2", "TLS 1.3"}) {
+		t.Errorf("protocolos = %v, se esperaba TLS 1.2 y TLS 1.3", got)
+	}
+	if !details.FallbackScsv {
+		t.Error("FallbackScsv = false, crypto/tls rechaza el downgrade")
+	}
+
+	if details.Cert == nil || details.Cert.Issues != 0 {
+		t.Errorf("Cert = %+v, se esperaba una cadena válida", details.Cert)
+	}
+	if details.Chain == nil || len(details.Chain.Certs) != 2 {
+		t.Fatalf("Chain = %+v, se esperaban leaf e intermedio", details.Chain)
+	}
+	if details.Chain.Certs[1].Label != "Test Intermediate" {
+		t.Errorf("Chain.Certs[1].Label = %q", details.Chain.Certs[1].Label)
+	}
+
+	if !details.SupportsAlpn || details.AlpnProtocols != "h2 http/1.1" {
+		t.Errorf("ALPN = %v %q, se esperaba h2 y http/1.1", details.SupportsAlpn, details.AlpnProtocols)
+	}
+	if !details.OcspStapling {
+		t.Error("OcspStapling = false")
+	}
+	if details.SessionResumption != 2 {
+		t.Errorf("SessionResumption = %d, se esperaba reanudación por tickets (2)", details.SessionResumption)
+	}
+
+	if details.HstsPolicy == nil || details.HstsPolicy.Status != "present" || details.HstsPolicy.MaxAge != 31536000 || !details.HstsPolicy.IncludeSubDomains {
+		t.Errorf("HstsPolicy = %+v", details.HstsPolicy)
+	}
+	if details.HTTPStatusCode != http.StatusNoContent {
+		t.Errorf("HTTPStatusCode = %d", details.HTTPStatusCode)
+	}
+}
+
+func TestProbeUnreachableEndpoint(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	host, err := New(Options{Timeout: time.Second}).Probe(context.Background(), address)
+	if err != nil {
+		t.Fatalf("Probe: %v", err)
+	}
+	if len(host.Endpoints) != 1 || host.Endpoints[0].StatusMessage != "Unable to connect to the server" {
+		t.Errorf("endpoints = %+v, se esperaba un endpoint sin conexión", host.Endpoints)
+	}
+}
+
+func TestSplitTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		host   string
+		port   int
+		ok     bool
+	}{
+		{"Example.COM", "example.com", 443, true},
+		{"example.com:8443", "example.com", 8443, true},
+		{"10.0.0.1", "10.0.0.1", 443, true},
+		{"[2001:db8::1]:9443", "2001:db8::1", 9443, true},
+		{"2001:db8::1", "2001:db8::1", 443, true},
+		{"", "", 0, false},
+		{"example.com:0", "", 0, false},
+		{"example.com:70000", "", 0, false},
+		{"exa mple.com", "", 0, false},
+	}
+
+	for _, tc := range tests {
+		host, port, err := SplitTarget(tc.target)
+		if tc.ok != (err == nil) || host != tc.host || port != tc.port {
+			t.Errorf("SplitTarget(%q) = %q, %d, %v", tc.target, host, port, err)
+		}
+	}
+}
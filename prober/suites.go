@@ -0,0 +1,180 @@
+package prober
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cipherSuite describe una suite que el prober sabe ofrecer en un ClientHello
+type cipherSuite struct {
+	id       uint16
+	name     string
+	strength int
+	// tls13 indica una suite exclusiva de TLS 1.3
+	tls13 bool
+}
+
+// knownSuites es el catálogo que se ofrece al enumerar, de más a menos
+// preferida. Incluye suites inseguras para poder detectarlas.
+var knownSuites = []cipherSuite{
+	// TLS 1.3
+	{0x1302, "TLS_AES_256_GCM_SHA384", 256, true},
+	{0x1303, "TLS_CHACHA20_POLY1305_SHA256", 256, true},
+	{0x1301, "TLS_AES_128_GCM_SHA256", 128, true},
+	{0x1304, "TLS_AES_128_CCM_SHA256", 128, true},
+	{0x1305, "TLS_AES_128_CCM_8_SHA256", 128, true},
+
+	// ECDHE
+	{0xc02c, "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384", 256, false},
+	{0xc030, "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", 256, false},
+	{0xcca9, "TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256", 256, false},
+	{0xcca8, "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256", 256, false},
+	{0xc02b, "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", 128, false},
+	{0xc02f, "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", 128, false},
+	{0xc0ad, "TLS_ECDHE_ECDSA_WITH_AES_256_CCM", 256, false},
+	{0xc0ac, "TLS_ECDHE_ECDSA_WITH_AES_128_CCM", 128, false},
+	{0xc024, "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA384", 256, false},
+	{0xc028, "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA384", 256, false},
+	{0xc023, "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256", 128, false},
+	{0xc027, "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256", 128, false},
+	{0xc00a, "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA", 256, false},
+	{0xc014, "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA", 256, false},
+	{0xc009, "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA", 128, false},
+	{0xc013, "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA", 128, false},
+	{0xc073, "TLS_ECDHE_ECDSA_WITH_CAMELLIA_256_CBC_SHA384", 256, false},
+	{0xc077, "TLS_ECDHE_RSA_WITH_CAMELLIA_256_CBC_SHA384", 256, false},
+	{0xc072, "TLS_ECDHE_ECDSA_WITH_CAMELLIA_128_CBC_SHA256", 128, false},
+	{0xc076, "TLS_ECDHE_RSA_WITH_CAMELLIA_128_CBC_SHA256", 128, false},
+	{0xc008, "TLS_ECDHE_ECDSA_WITH_3DES_EDE_CBC_SHA", 112, false},
+	{0xc012, "TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA", 112, false},
+	{0xc007, "TLS_ECDHE_ECDSA_WITH_RC4_128_SHA", 128, false},
+	{0xc011, "TLS_ECDHE_RSA_WITH_RC4_128_SHA", 128, false},
+	{0xc006, "TLS_ECDHE_ECDSA_WITH_NULL_SHA", 0, false},
+	{0xc010, "TLS_ECDHE_RSA_WITH_NULL_SHA", 0, false},
+
+	// DHE
+	{0x009f, "TLS_DHE_RSA_WITH_AES_256_GCM_SHA384", 256, false},
+	{0xccaa, "TLS_DHE_RSA_WITH_CHACHA20_POLY1305_SHA256", 256, false},
+	{0x009e, "TLS_DHE_RSA_WITH_AES_128_GCM_SHA256", 128, false},
+	{0xc09f, "TLS_DHE_RSA_WITH_AES_256_CCM", 256, false},
+	{0xc09e, "TLS_DHE_RSA_WITH_AES_128_CCM", 128, false},
+	{0x006b, "TLS_DHE_RSA_WITH_AES_256_CBC_SHA256", 256, false},
+	{0x0067, "TLS_DHE_RSA_WITH_AES_128_CBC_SHA256", 128, false},
+	{0x0039, "TLS_DHE_RSA_WITH_AES_256_CBC_SHA", 256, false},
+	{0x0033, "TLS_DHE_RSA_WITH_AES_128_CBC_SHA", 128, false},
+	{0x0038, "TLS_DHE_DSS_WITH_AES_256_CBC_SHA", 256, false},
+	{0x0032, "TLS_DHE_DSS_WITH_AES_128_CBC_SHA", 128, false},
+	{0x0088, "TLS_DHE_RSA_WITH_CAMELLIA_256_CBC_SHA", 256, false},
+	{0x0045, "TLS_DHE_RSA_WITH_CAMELLIA_128_CBC_SHA", 128, false},
+	{0x009a, "TLS_DHE_RSA_WITH_SEED_CBC_SHA", 128, false},
+	{0x0016, "TLS_DHE_RSA_WITH_3DES_EDE_CBC_SHA", 112, false},
+	{0x0013, "TLS_DHE_DSS_WITH_3DES_EDE_CBC_SHA", 112, false},
+	{0x0015, "TLS_DHE_RSA_WITH_DES_CBC_SHA", 56, false},
+	{0x0012, "TLS_DHE_DSS_WITH_DES_CBC_SHA", 56, false},
+	{0x0014, "TLS_DHE_RSA_EXPORT_WITH_DES40_CBC_SHA", 40, false},
+	{0x0011, "TLS_DHE_DSS_EXPORT_WITH_DES40_CBC_SHA", 40, false},
+
+	// RSA
+	{0x009d, "TLS_RSA_WITH_AES_256_GCM_SHA384", 256, false},
+	{0x009c, "TLS_RSA_WITH_AES_128_GCM_SHA256", 128, false},
+	{0xc09d, "TLS_RSA_WITH_AES_256_CCM", 256, false},
+	{0xc09c, "TLS_RSA_WITH_AES_128_CCM", 128, false},
+	{0x003d, "TLS_RSA_WITH_AES_256_CBC_SHA256", 256, false},
+	{0x003c, "TLS_RSA_WITH_AES_128_CBC_SHA256", 128, false},
+	{0x0035, "TLS_RSA_WITH_AES_256_CBC_SHA", 256, false},
+	{0x002f, "TLS_RSA_WITH_AES_128_CBC_SHA", 128, false},
+	{0x0084, "TLS_RSA_WITH_CAMELLIA_256_CBC_SHA", 256, false},
+	{0x0041, "TLS_RSA_WITH_CAMELLIA_128_CBC_SHA", 128, false},
+	{0x0096, "TLS_RSA_WITH_SEED_CBC_SHA", 128, false},
+	{0x0007, "TLS_RSA_WITH_IDEA_CBC_SHA", 128, false},
+	{0x000a, "TLS_RSA_WITH_3DES_EDE_CBC_SHA", 112, false},
+	{0x0005, "TLS_RSA_WITH_RC4_128_SHA", 128, false},
+	{0x0004, "TLS_RSA_WITH_RC4_128_MD5", 128, false},
+	{0x0009, "TLS_RSA_WITH_DES_CBC_SHA", 56, false},
+	{0x0062, "TLS_RSA_EXPORT1024_WITH_DES_CBC_SHA", 56, false},
+	{0x0064, "TLS_RSA_EXPORT1024_WITH_RC4_56_SHA", 56, false},
+	{0x0008, "TLS_RSA_EXPORT_WITH_DES40_CBC_SHA", 40, false},
+	{0x0006, "TLS_RSA_EXPORT_WITH_RC2_CBC_40_MD5", 40, false},
+	{0x0003, "TLS_RSA_EXPORT_WITH_RC4_40_MD5", 40, false},
+	{0x003b, "TLS_RSA_WITH_NULL_SHA256", 0, false},
+	{0x0002, "TLS_RSA_WITH_NULL_SHA", 0, false},
+	{0x0001, "TLS_RSA_WITH_NULL_MD5", 0, false},
+
+	// ECDH/DH estáticos y anónimos
+	{0xc032, "TLS_ECDH_RSA_WITH_AES_256_GCM_SHA384", 256, false},
+	{0xc02e, "TLS_ECDH_ECDSA_WITH_AES_256_GCM_SHA384", 256, false},
+	{0xc031, "TLS_ECDH_RSA_WITH_AES_128_GCM_SHA256", 128, false},
+	{0xc02d, "TLS_ECDH_ECDSA_WITH_AES_128_GCM_SHA256", 128, false},
+	{0xc00f, "TLS_ECDH_RSA_WITH_AES_256_CBC_SHA", 256, false},
+	{0xc005, "TLS_ECDH_ECDSA_WITH_AES_256_CBC_SHA", 256, false},
+	{0xc00e, "TLS_ECDH_RSA_WITH_AES_128_CBC_SHA", 128, false},
+	{0xc004, "TLS_ECDH_ECDSA_WITH_AES_128_CBC_SHA", 128, false},
+	{0xc018, "TLS_ECDH_anon_WITH_AES_128_CBC_SHA", 128, false},
+	{0xc019, "TLS_ECDH_anon_WITH_AES_256_CBC_SHA", 256, false},
+	{0x00a7, "TLS_DH_anon_WITH_AES_256_GCM_SHA384", 256, false},
+	{0x00a6, "TLS_DH_anon_WITH_AES_128_GCM_SHA256", 128, false},
+	{0x003a, "TLS_DH_anon_WITH_AES_256_CBC_SHA", 256, false},
+	{0x0034, "TLS_DH_anon_WITH_AES_128_CBC_SHA", 128, false},
+	{0x001b, "TLS_DH_anon_WITH_3DES_EDE_CBC_SHA", 112, false},
+	{0x0018, "TLS_DH_anon_WITH_RC4_128_MD5", 128, false},
+	{0x001a, "TLS_DH_anon_WITH_DES_CBC_SHA", 56, false},
+	{0x0017, "TLS_DH_anon_EXPORT_WITH_RC4_40_MD5", 40, false},
+	{0x0019, "TLS_DH_anon_EXPORT_WITH_DES40_CBC_SHA", 40, false},
+}
+
+// suiteFallbackSCSV no es una suite real: indica un reintento con versión menor (RFC 7507)
+const suiteFallbackSCSV = 0x5600
+
+var suitesByID = func() map[uint16]cipherSuite {
+	byID := make(map[uint16]cipherSuite, len(knownSuites))
+	for _, suite := range knownSuites {
+		byID[suite.id] = suite
+	}
+	return byID
+}()
+
+// suitesFor devuelve el catálogo para la versión: en TLS 1.3 solo las suites
+// propias; en las anteriores todas las demás
+func suitesFor(version uint16) []uint16 {
+	ids := []uint16{}
+	for _, suite := range knownSuites {
+		if suite.tls13 == (version == versionTLS13) {
+			ids = append(ids, suite.id)
+		}
+	}
+	return ids
+}
+
+func suiteInfo(id uint16) cipherSuite {
+	if suite, ok := suitesByID[id]; ok {
+		return suite
+	}
+	return cipherSuite{id: id, name: fmt.Sprintf("UNKNOWN_0x%04x", id)}
+}
+
+// forwardSecret indica si la suite usa intercambio efímero (ECDHE, DHE o TLS 1.3)
+func (s cipherSuite) forwardSecret() bool {
+	return s.tls13 || strings.Contains(s.name, "_ECDHE_") || strings.Contains(s.name, "_DHE_")
+}
+
+// weak replica el criterio de SSL Labs para marcar una suite como insegura:
+// sin cifrado, anónima, export, DES, RC4 o menos de 128 bits
+func (s cipherSuite) weak() bool {
+	return s.strength < 128 ||
+		strings.Contains(s.name, "_anon_") ||
+		strings.Contains(s.name, "_EXPORT") ||
+		strings.Contains(s.name, "_RC4_") ||
+		strings.Contains(s.name, "_NULL_")
+}
+
+func (s cipherSuite) cbc() bool {
+	return strings.Contains(s.name, "_CBC_")
+}
+
+func (s cipherSuite) export() bool {
+	return strings.Contains(s.name, "_EXPORT")
+}
+
+func (s cipherSuite) rc4() bool {
+	return strings.Contains(s.name, "_RC4_")
+}
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"sslscanner/output"
+	"sslscanner/service"
+)
+
+// progressRenderer muestra los eventos del escáner
+type progressRenderer interface {
+	// observe es el service.Observer del escáner
+	observe(event service.Event)
+	// hold aparta la pantalla de progreso mientras fn escribe en la terminal
+	hold(fn func())
+	// stop termina la pantalla de progreso; se llama antes del reporte final
+	stop()
+}
+
+// newProgressRenderer redibuja el progreso en su lugar cuando out es una
+// terminal y escribe líneas breves si no lo es. Sin progress solo se muestran
+// los reintentos y las advertencias.
+func newProgressRenderer(out *os.File, progress bool) progressRenderer {
+	if progress && output.IsTerminal(out) && os.Getenv("TERM") != "dumb" {
+		return newLiveProgress(out)
+	}
+	return newProgressPrinter(out, progress)
+}
+
+// progressPrinter escribe una línea por evento, para logs y salidas redirigidas
+type progressPrinter struct {
+	out      io.Writer
+	progress bool
+
+	mu sync.Mutex
+	// last guarda la prueba en curso de cada endpoint para informar solo los cambios
+	last map[string]string
+}
+
+func newProgressPrinter(out io.Writer, progress bool) *progressPrinter {
+	return &progressPrinter{
+		out:      out,
+		progress: progress,
+		last:     make(map[string]string),
+	}
+}
+
+func (p *progressPrinter) observe(event service.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if message, ok := eventMessage(event); ok {
+		fmt.Fprintln(p.out, message)
+		return
+	}
+
+	if !p.progress {
+		return
+	}
+
+	switch e := event.(type) {
+	case service.CacheHit:
+		fmt.Fprintln(p.out, cacheHitMessage(e))
+	case service.StatusChanged:
+		if e.Status == service.StatusDNS || e.Status == service.StatusInProgress {
+			fmt.Fprintf(p.out, "  %s: %s\n", e.Domain, e.Message)
+		}
+	case service.EndpointProgress:
+		if e.Progress < 0 {
+			return
+		}
+		// una línea por prueba y otra al terminar, no una por consulta
+		step := e.Details
+		if e.Progress >= 100 {
+			step = "100"
+		}
+		key := e.Domain + " " + e.IPAddress
+		if p.last[key] == step {
+			return
+		}
+		p.last[key] = step
+
+		if e.Progress >= 100 {
+			fmt.Fprintf(p.out, "  %s [%s] terminado %s\n", e.Domain, e.IPAddress, e.Grade)
+		} else {
+			fmt.Fprintf(p.out, "  %s [%s] %d%% - %s\n", e.Domain, e.IPAddress, e.Progress, e.Details)
+		}
+	case service.Completed:
+		for key := range p.last {
+			if strings.HasPrefix(key, e.Domain+" ") {
+				delete(p.last, key)
+			}
+		}
+	}
+}
+
+func (p *progressPrinter) hold(fn func()) {
+	fn()
+}
+
+func (p *progressPrinter) stop() {}
+
+// eventMessage devuelve el aviso de los eventos que se muestran siempre
+func eventMessage(event service.Event) (string, bool) {
+	switch e := event.(type) {
+	case service.Retry:
+		return fmt.Sprintf("  SSL Labs respondió: %v. Reintento %d/%d en %s",
+			e.Err, e.Attempt, e.MaxRetries, e.Delay.Round(time.Second)), true
+	case service.Warning:
+		return fmt.Sprintf("Advertencia: %s: %v", e.Domain, e.Err), true
+	}
+	return "", false
+}
+
+func cacheHitMessage(e service.CacheHit) string {
+	return fmt.Sprintf("Usando resultado en caché de %s (obtenido hace %s)", e.Domain, e.Age.Round(time.Minute))
+}
+
+const (
+	progressBarWidth = 20
+	redrawInterval   = time.Second
+)
+
+// progressRow es una fila de la pantalla: un endpoint, o el dominio mientras
+// SSL Labs todavía no informa sus endpoints
+type progressRow struct {
+	domain    string
+	ipAddress string
+	progress  int
+	eta       time.Duration
+	updated   time.Time
+	details   string
+	started   time.Time
+	finished  time.Time
+}
+
+// liveProgress redibuja en su lugar una fila por endpoint con barra de
+// progreso, ETA, prueba en curso y tiempo transcurrido
+type liveProgress struct {
+	out   io.Writer
+	width int
+
+	mu    sync.Mutex
+	rows  []*progressRow
+	drawn int
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newLiveProgress(out *os.File) *liveProgress {
+	p := &liveProgress{
+		out:   out,
+		width: terminalWidth(out),
+		done:  make(chan struct{}),
+	}
+	go p.tick()
+	return p
+}
+
+// tick actualiza el tiempo transcurrido entre una consulta y la siguiente
+func (p *liveProgress) tick() {
+	ticker := time.NewTicker(redrawInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			if len(p.rows) > 0 {
+				p.redrawLocked()
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+func (p *liveProgress) observe(event service.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if message, ok := eventMessage(event); ok {
+		p.printLocked(message)
+		return
+	}
+
+	switch e := event.(type) {
+	case service.CacheHit:
+		p.printLocked(cacheHitMessage(e))
+		return
+	case service.StatusChanged:
+		if e.Status != service.StatusDNS && e.Status != service.StatusInProgress {
+			return
+		}
+		row := p.row(e.Domain, "")
+		if row == nil {
+			if p.hasEndpoints(e.Domain) {
+				return
+			}
+			row = p.addRow(e.Domain, "")
+		}
+		row.details = e.Message
+	case service.EndpointProgress:
+		p.removeRows(e.Domain, true)
+		row := p.row(e.Domain, e.IPAddress)
+		if row == nil {
+			row = p.addRow(e.Domain, e.IPAddress)
+		}
+		if row.progress < 0 && e.Progress >= 0 {
+			// el tiempo se cuenta desde que el endpoint sale de la espera
+			row.started = time.Now()
+		}
+		row.progress = e.Progress
+		row.eta = e.ETA
+		row.updated = time.Now()
+		row.details = e.Details
+		if e.Progress >= 100 {
+			row.eta = 0
+			row.details = "terminado " + e.Grade
+			if row.finished.IsZero() {
+				row.finished = time.Now()
+			}
+		}
+	case service.Completed:
+		p.removeRows(e.Domain, false)
+	default:
+		return
+	}
+
+	p.redrawLocked()
+}
+
+func (p *liveProgress) hold(fn func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.clearLocked()
+	fn()
+	p.redrawLocked()
+}
+
+func (p *liveProgress) stop() {
+	p.once.Do(func() {
+		close(p.done)
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.clearLocked()
+		p.rows = nil
+	})
+}
+
+func (p *liveProgress) row(domain, ipAddress string) *progressRow {
+	for _, row := range p.rows {
+		if row.domain == domain && row.ipAddress == ipAddress {
+			return row
+		}
+	}
+	return nil
+}
+
+func (p *liveProgress) hasEndpoints(domain string) bool {
+	for _, row := range p.rows {
+		if row.domain == domain && row.ipAddress != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *liveProgress) addRow(domain, ipAddress string) *progressRow {
+	row := &progressRow{domain: domain, ipAddress: ipAddress, progress: -1, started: time.Now()}
+	p.rows = append(p.rows, row)
+	return row
+}
+
+// removeRows quita las filas del dominio; con onlyDomain solo la fila del
+// dominio, que deja de hacer falta cuando aparecen los endpoints
+func (p *liveProgress) removeRows(domain string, onlyDomain bool) {
+	kept := p.rows[:0]
+	for _, row := range p.rows {
+		if row.domain == domain && (!onlyDomain || row.ipAddress == "") {
+			continue
+		}
+		kept = append(kept, row)
+	}
+	p.rows = kept
+}
+
+// printLocked escribe un aviso por encima de la pantalla de progreso
+func (p *liveProgress) printLocked(message string) {
+	p.clearLocked()
+	fmt.Fprintln(p.out, message)
+	p.redrawLocked()
+}
+
+// clearLocked borra las filas dibujadas y deja el cursor donde empezaban
+func (p *liveProgress) clearLocked() {
+	if p.drawn > 0 {
+		fmt.Fprintf(p.out, "\033[%dF\033[J", p.drawn)
+		p.drawn = 0
+	}
+}
+
+func (p *liveProgress) redrawLocked() {
+	var b strings.Builder
+	if p.drawn > 0 {
+		fmt.Fprintf(&b, "\033[%dF", p.drawn)
+	}
+
+	labelWidth := 0
+	for _, row := range p.rows {
+		labelWidth = max(labelWidth, utf8.RuneCountInString(row.label()))
+	}
+
+	now := time.Now()
+	for _, row := range p.rows {
+		b.WriteString("\033[2K")
+		b.WriteString(truncateLine(row.format(labelWidth, now), p.width))
+		b.WriteByte('\n')
+	}
+	b.WriteString("\033[J")
+
+	p.drawn = len(p.rows)
+	io.WriteString(p.out, b.String())
+}
+
+func (r *progressRow) label() string {
+	if r.ipAddress == "" {
+		return r.domain
+	}
+	return r.domain + " [" + r.ipAddress + "]"
+}
+
+func (r *progressRow) format(labelWidth int, now time.Time) string {
+	label := r.label()
+	label += strings.Repeat(" ", labelWidth-utf8.RuneCountInString(label))
+
+	bar := strings.Repeat("░", progressBarWidth)
+	percent := "    "
+	if r.progress >= 0 {
+		filled := min(r.progress, 100) * progressBarWidth / 100
+		bar = strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+		percent = fmt.Sprintf("%3d%%", r.progress)
+	}
+
+	// la ETA llega con cada consulta; entre consultas se descuenta sola
+	eta := "ETA --:--"
+	if remaining := r.eta - now.Sub(r.updated); r.eta > 0 && remaining > 0 {
+		eta = "ETA " + formatClock(remaining)
+	}
+
+	details := r.details
+	if r.ipAddress != "" && r.progress < 0 {
+		details = "en espera"
+	}
+
+	end := now
+	if !r.finished.IsZero() {
+		end = r.finished
+	}
+
+	return fmt.Sprintf("  %s %s %s  %s  %s  %s", label, bar, percent, eta, formatClock(end.Sub(r.started)), details)
+}
+
+// formatClock muestra una duración como mm:ss
+func formatClock(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%02d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// truncateLine recorta la línea al ancho de la terminal: una fila que ocupa
+// dos líneas descuadra el redibujado
+func truncateLine(line string, width int) string {
+	if utf8.RuneCountInString(line) < width {
+		return line
+	}
+	runes := []rune(line)
+	return string(runes[:width-1])
+}
+
+// terminalWidth consulta el ancho de la terminal; si no se puede, toma el de
+// $COLUMNS o 80
+func terminalWidth(out *os.File) int {
+	if width, _, err := term.GetSize(int(out.Fd())); err == nil && width > 0 {
+		return width
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 20 {
+		return columns
+	}
+	return 80
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"sslscanner/client"
+)
+
+// runRegister registra una cuenta en la API v4 de SSL Labs
+func runRegister(args []string) int {
+	fs := flag.NewFlagSet("register", flag.ContinueOnError)
+	firstName := fs.String("first-name", "", "Nombre")
+	lastName := fs.String("last-name", "", "Apellido")
+	email := fs.String("email", os.Getenv("SSLLABS_EMAIL"), "Email corporativo (env SSLLABS_EMAIL)")
+	organization := fs.String("organization", "", "Organización")
+	connection := addConnectionFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Registra una cuenta en la API v4 de SSL Labs.
+
+Uso:
+  sslscanner register --first-name <nombre> --last-name <apellido> --email <email> --organization <org>
+
+Opciones:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Luego use el email registrado con --api-version 4 --email <email> o con SSLLABS_EMAIL.
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitCodeInvalidArgs
+	}
+
+	if *firstName == "" || *lastName == "" || *email == "" || *organization == "" {
+		fs.Usage()
+		return exitCodeInvalidArgs
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	setupSignalHandler(cancel)
+
+	clientOpts, err := connection.options()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+
+	apiClient, err := client.New(clientOpts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+
+	response, err := apiClient.Register(ctx, client.Registration{
+		FirstName:    *firstName,
+		LastName:     *lastName,
+		Email:        *email,
+		Organization: *organization,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeAnalysisError
+	}
+
+	fmt.Printf("Registro: %s\n", response.Status)
+	if response.Message != "" {
+		fmt.Println(response.Message)
+	}
+
+	return exitCodeSuccess
+}
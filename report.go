@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"sslscanner/model"
+	"sslscanner/output"
+	"sslscanner/policy"
+	"sslscanner/service"
+)
+
+const (
+	formatText  = "text"
+	formatJSON  = "json"
+	formatSARIF = "sarif"
+	formatJUnit = "junit"
+)
+
+// reportConfig agrupa las opciones de presentación elegidas en la línea de comandos
+type reportConfig struct {
+	format string
+	junit  output.JUnitOptions
+	rules  *policy.Policy
+}
+
+func isValidFormat(format string) bool {
+	switch format {
+	case formatText, formatJSON, formatSARIF, formatJUnit:
+		return true
+	default:
+		return false
+	}
+}
+
+// writeDocument escribe en w los formatos de documento (todo excepto texto)
+// con los resultados de todos los dominios analizados
+func writeDocument(w io.Writer, config reportConfig, results []service.BatchResult) error {
+	switch config.format {
+	case formatJSON:
+		report := output.NewJSONReport()
+		for _, result := range results {
+			if result.Err != nil {
+				report.AddError(result.Domain, result.Err)
+				continue
+			}
+			report.AddHost(result.Host)
+		}
+		return report.Write(w)
+	case formatSARIF:
+		report := output.NewSARIFReport()
+		for _, result := range results {
+			if result.Err != nil {
+				report.AddError(result.Domain, result.Err)
+				continue
+			}
+			report.AddHost(result.Host)
+		}
+		return report.Write(w)
+	case formatJUnit:
+		report := output.NewJUnitReport(config.junit)
+		for _, result := range results {
+			if result.Err != nil {
+				report.AddError(result.Domain, result.Err)
+				continue
+			}
+			report.AddHost(result.Host)
+		}
+		return report.Write(w)
+	default:
+		return fmt.Errorf("formato de documento no soportado: %s", config.format)
+	}
+}
+
+// checkPolicy evalúa la política configurada (si hay) y reporta las violaciones:
+// en texto como parte del reporte y en formatos de documento por stderr.
+// Devuelve false si el host no cumple la política.
+func checkPolicy(formatter *output.Formatter, config reportConfig, host *model.Host) bool {
+	if config.rules == nil || host == nil {
+		return true
+	}
+
+	violations := config.rules.Evaluate(host)
+
+	if config.format == formatText {
+		formatter.PrintPolicyViolations(violations)
+	} else {
+		for _, violation := range violations {
+			fmt.Fprintf(os.Stderr, "Política: %s %s\n", host.Host, violation)
+		}
+	}
+
+	return len(violations) == 0
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sslscanner/api"
+	"sslscanner/history"
+	"sslscanner/service"
+)
+
+// runServe expone el escáner como una API REST
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("listen", api.DefaultAddr, "Dirección en la que atender la API")
+	workers := fs.Int("concurrency", service.DefaultBatchConcurrency, "Máximo de análisis simultáneos")
+	queueSize := fs.Int("queue-size", api.DefaultQueueSize, "Máximo de análisis en espera")
+	statePath := fs.String("state", "", "Archivo de trabajos de la API (por defecto $XDG_DATA_HOME/sslscanner/serve-jobs.json)")
+	token := fs.String("token", os.Getenv("SSLSCANNER_API_TOKEN"), "Exigir Authorization: Bearer <token> (env SSLSCANNER_API_TOKEN)")
+	verbose := fs.Bool("verbose", false, "Mostrar el progreso de cada análisis")
+	historyConfig := addHistoryFlags(fs)
+	engine := addEngineFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Atiende una API REST para pedir análisis sin instalar la CLI.
+
+Uso:
+  sslscanner serve [opciones]
+
+Rutas:
+  POST /scans                   encola un análisis: {"domain": "example.com", "new": false}
+  GET  /scans/{id}              estado y progreso del análisis
+  GET  /hosts/{dominio}/latest  último reporte del dominio (mismo JSON que --format json)
+
+Opciones:
+`)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitCodeInvalidArgs
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return exitCodeInvalidArgs
+	}
+
+	store, err := historyConfig.open(*engine.engine)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+
+	if *statePath == "" {
+		dataDir, err := history.DataDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCodeInvalidArgs
+		}
+		*statePath = filepath.Join(dataDir, "serve-jobs.json")
+	}
+
+	scanner, err := engine.newScanner(engine.retryPolicy())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+	queue, err := api.NewQueue(scanner, *statePath, *queueSize)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+	queue.SetLogOutput(os.Stdout)
+	// la cola sigue el progreso de sus trabajos con el mismo observador
+	scanner.SetObserver(service.MultiObserver(queue.Observe, newProgressPrinter(os.Stderr, *verbose).observe))
+
+	server := api.NewServer(queue, store, *token)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	setupSignalHandler(cancel)
+
+	if err := server.ListenAndServe(ctx, *addr, *workers); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeAnalysisError
+	}
+
+	return exitCodeSuccess
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+
+	"sslscanner/client"
+	"sslscanner/model"
+)
+
+// Backend es el motor de análisis sobre el que trabaja Scanner. *client.Client
+// lo implementa contra SSL Labs; service/scantest ofrece un fake en memoria.
+type Backend interface {
+	GetInfo(ctx context.Context) (*model.Info, error)
+	StartAnalysis(ctx context.Context, domain string, opts client.AnalyzeOptions) (*model.Host, error)
+	CheckAnalysisStatus(ctx context.Context, domain string, opts client.AnalyzeOptions) (*model.Host, error)
+	GetEndpointDetails(ctx context.Context, domain, ipAddress string) (*model.Endpoint, error)
+}
+
+// TargetValidator lo implementan los motores que aceptan destinos distintos de
+// un dominio público (IPs, puertos); Scanner lo usa en lugar de ValidateDomain
+type TargetValidator interface {
+	ValidateTarget(target string) error
+}
+
+var _ Backend = (*client.Client)(nil)
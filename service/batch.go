@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"sslscanner/model"
+)
+
+const DefaultBatchConcurrency = 4
+
+// BatchResult agrupa el resultado del análisis de un dominio dentro de un lote
+type BatchResult struct {
+	Domain   string
+	Host     *model.Host
+	Err      error
+	Duration time.Duration
+}
+
+// RunBatch analiza varios dominios con un pool de workers acotado por los
+// análisis libres que reporta SSL Labs. Un fallo en un dominio no detiene al resto.
+// onResult (opcional) se invoca de forma serializada a medida que termina cada dominio.
+// Los resultados se devuelven en el mismo orden que los dominios recibidos.
+func (s *Scanner) RunBatch(ctx context.Context, domains []string, opts ScanOptions, concurrency int, onResult func(BatchResult)) ([]BatchResult, error) {
+	if len(domains) == 0 {
+		return nil, nil
+	}
+
+	workers, err := s.batchWorkers(ctx, concurrency)
+	if err != nil {
+		return nil, err
+	}
+	if workers > len(domains) {
+		workers = len(domains)
+	}
+
+	results := make([]BatchResult, len(domains))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				start := time.Now()
+				host, err := s.RunAnalysis(ctx, domains[i], opts)
+
+				result := BatchResult{
+					Domain:   domains[i],
+					Host:     host,
+					Err:      err,
+					Duration: time.Since(start),
+				}
+				results[i] = result
+
+				if onResult != nil {
+					mu.Lock()
+					onResult(result)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := range domains {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			// marcar como cancelados los dominios que no alcanzaron a encolarse
+			for j := i; j < len(domains); j++ {
+				results[j] = BatchResult{
+					Domain: domains[j],
+					Err:    fmt.Errorf("análisis cancelado: %w", ctx.Err()),
+				}
+			}
+			close(jobs)
+			wg.Wait()
+			return results, nil
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, nil
+}
+
+// batchWorkers limita la concurrencia pedida al máximo de análisis que SSL Labs
+// permite. Los cupos ocupados en este momento los administra el cliente, que hace
+// esperar a cada worker hasta que se libere uno.
+func (s *Scanner) batchWorkers(ctx context.Context, concurrency int) (int, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// sin conexión no hay límites de SSL Labs que respetar
+	if s.cache != nil && s.cacheMode == CacheOnly {
+		return concurrency, nil
+	}
+
+	info, err := s.backend.GetInfo(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("no se pudo verificar disponibilidad del servicio: %w", err)
+	}
+
+	if info.MaxAssessments > 0 && concurrency > info.MaxAssessments {
+		concurrency = info.MaxAssessments
+	}
+
+	return concurrency, nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"time"
+
+	"sslscanner/model"
+)
+
+// Event es un evento de progreso de un análisis. Los tipos concretos son
+// StatusChanged, EndpointProgress, CacheHit, Retry, Warning y Completed.
+type Event interface {
+	event()
+}
+
+// Observer recibe los eventos del Scanner. En modo por lotes se invoca desde
+// varias goroutines a la vez, así que debe sincronizar su propio estado.
+type Observer func(Event)
+
+// StatusChanged se emite cuando el análisis de un dominio cambia de estado
+// (DNS, IN_PROGRESS, READY o ERROR)
+type StatusChanged struct {
+	Domain string
+	// Options distingue análisis simultáneos del mismo dominio
+	Options ScanOptions
+	Status  string
+	Message string
+}
+
+// EndpointProgress es el avance de un endpoint en cada consulta del estado.
+// Progress es -1 mientras el endpoint espera su turno.
+type EndpointProgress struct {
+	Domain    string
+	Options   ScanOptions
+	IPAddress string
+	Progress  int
+	// ETA es la estimación de SSL Labs hasta terminar; cero si no la informa
+	ETA     time.Duration
+	Details string
+	Grade   string
+}
+
+// CacheHit se emite cuando el resultado sale de la caché local
+type CacheHit struct {
+	Domain string
+	Age    time.Duration
+}
+
+// Retry se emite antes de esperar para reintentar una solicitud a SSL Labs
+type Retry struct {
+	Attempt    int
+	MaxRetries int
+	Delay      time.Duration
+	Err        error
+}
+
+// Warning informa un fallo que no interrumpe el análisis, como no poder leer
+// o escribir la caché local o el historial
+type Warning struct {
+	Domain string
+	Err    error
+}
+
+// Completed se emite al terminar RunAnalysis, con Host o con Err
+type Completed struct {
+	Domain  string
+	Host    *model.Host
+	Err     error
+	Elapsed time.Duration
+	Cached  bool
+}
+
+func (StatusChanged) event()    {}
+func (EndpointProgress) event() {}
+func (CacheHit) event()         {}
+func (Retry) event()            {}
+func (Warning) event()          {}
+func (Completed) event()        {}
+
+// SetObserver registra la función que recibe los eventos de progreso. Sin
+// observador el Scanner no escribe nada.
+func (s *Scanner) SetObserver(observer Observer) {
+	s.observer = observer
+}
+
+// MultiObserver reenvía cada evento a todos los observadores, en orden
+func MultiObserver(observers ...Observer) Observer {
+	return func(event Event) {
+		for _, observer := range observers {
+			observer(event)
+		}
+	}
+}
+
+func (s *Scanner) emit(event Event) {
+	if s.observer != nil {
+		s.observer(event)
+	}
+}
+
+// emitProgress emite el avance de cada endpoint de host
+func (s *Scanner) emitProgress(domain string, opts ScanOptions, host *model.Host) {
+	for _, endpoint := range host.Endpoints {
+		s.emit(EndpointProgress{
+			Domain:    domain,
+			Options:   opts,
+			IPAddress: endpoint.IPAddress,
+			Progress:  endpoint.Progress,
+			ETA:       max(time.Duration(endpoint.ETA)*time.Second, 0),
+			Details:   endpoint.StatusDetailsMessage,
+			Grade:     endpoint.Grade,
+		})
+	}
+}
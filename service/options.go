@@ -0,0 +1,63 @@
+package service
+
+import (
+	"fmt"
+
+	"sslscanner/client"
+)
+
+// DefaultMaxAgeHours es la antigüedad máxima por defecto de un reporte reutilizado de SSL Labs
+const DefaultMaxAgeHours = 24
+
+// ScanOptions controla cómo se pide cada análisis a SSL Labs
+type ScanOptions struct {
+	// ForceNew inicia siempre un análisis nuevo (startNew=on) e ignora la caché local
+	ForceNew bool
+	// MaxAgeHours reutiliza un reporte de SSL Labs más reciente que este valor (fromCache=on).
+	// 0 acepta cualquier reporte en caché de SSL Labs.
+	MaxAgeHours int
+	// Publish publica el resultado en los tableros de SSL Labs
+	Publish bool
+	// IgnoreMismatch continúa aunque el certificado no coincida con el dominio
+	IgnoreMismatch bool
+}
+
+// DefaultScanOptions reutiliza reportes de SSL Labs de menos de 24 horas
+func DefaultScanOptions() ScanOptions {
+	return ScanOptions{
+		MaxAgeHours: DefaultMaxAgeHours,
+	}
+}
+
+func (o ScanOptions) Validate() error {
+	if o.MaxAgeHours < 0 {
+		return fmt.Errorf("la antigüedad máxima no puede ser negativa")
+	}
+	return nil
+}
+
+// analyzeOptions traduce las opciones del análisis a parámetros de /analyze
+func (o ScanOptions) analyzeOptions() client.AnalyzeOptions {
+	opts := client.AnalyzeOptions{
+		Publish:        o.Publish,
+		All:            client.AllDone,
+		IgnoreMismatch: o.IgnoreMismatch,
+	}
+
+	if o.ForceNew {
+		opts.StartNew = true
+	} else {
+		opts.FromCache = true
+		opts.MaxAge = o.MaxAgeHours
+	}
+
+	return opts
+}
+
+// cacheOptions lista las opciones que cambian el resultado y por lo tanto la clave de caché
+func (o ScanOptions) cacheOptions() []string {
+	if o.IgnoreMismatch {
+		return []string{"ignoreMismatch=on"}
+	}
+	return nil
+}
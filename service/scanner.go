@@ -2,11 +2,14 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"time"
 
+	"sslscanner/cache"
 	"sslscanner/client"
+	"sslscanner/history"
 	"sslscanner/model"
 )
 
@@ -21,24 +24,113 @@ const (
 	MaxWaitTime         = 15 * time.Minute
 )
 
+// Polling define los intervalos de consulta del estado de un análisis
+type Polling struct {
+	// Initial es el intervalo mientras el análisis está en DNS
+	Initial time.Duration
+	// Running es el intervalo mientras el análisis está en curso
+	Running time.Duration
+	// MaxWait es el tiempo máximo de espera de un análisis
+	MaxWait time.Duration
+}
+
+// DefaultPolling son los intervalos que recomienda SSL Labs
+var DefaultPolling = Polling{
+	Initial: PollIntervalInitial,
+	Running: PollIntervalRunning,
+	MaxWait: MaxWaitTime,
+}
+
+// CacheMode define cómo se usa la caché local de resultados
+type CacheMode int
+
+const (
+	// CacheDefault usa el resultado en caché si está fresco y si no consulta SSL Labs
+	CacheDefault CacheMode = iota
+	// CacheRefresh ignora la caché pero guarda el resultado nuevo
+	CacheRefresh
+	// CacheOnly trabaja sin red: solo devuelve resultados en caché
+	CacheOnly
+)
+
+// ErrNotCached se devuelve en modo CacheOnly cuando no hay un resultado fresco
+var ErrNotCached = errors.New("no hay un resultado fresco en caché (modo sin conexión)")
+
 var domainRegex = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9\-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z]{2,}$`)
 
 type Scanner struct {
-	client *client.Client
+	backend   Backend
+	polling   Polling
+	cache     *cache.Store
+	cacheMode CacheMode
+	history   *history.Store
+	observer  Observer
 }
 
-func NewScanner() *Scanner {
-	return &Scanner{
-		client: client.NewClient(),
+// NewScanner crea un Scanner con un cliente configurado por opts (ver client.New)
+func NewScanner(opts ...client.Option) (*Scanner, error) {
+	c, err := client.New(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("configuración del cliente inválida: %w", err)
 	}
+	return NewScannerWithClient(c), nil
 }
 
+// NewScannerWithClient crea un Scanner sobre c. Los reintentos del cliente se
+// informan también como eventos Retry; c no se modifica, así que varios
+// Scanner pueden compartirlo.
 func NewScannerWithClient(c *client.Client) *Scanner {
+	s := NewScannerWithBackend(nil)
+
+	policy := c.RetryPolicy()
+	onRetry := policy.OnRetry
+	policy.OnRetry = func(attempt int, delay time.Duration, err error) {
+		if onRetry != nil {
+			onRetry(attempt, delay, err)
+		}
+		s.emit(Retry{Attempt: attempt, MaxRetries: policy.MaxRetries, Delay: delay, Err: err})
+	}
+	s.backend = c.WithRetry(policy)
+
+	return s
+}
+
+// NewScannerWithBackend crea un Scanner sobre cualquier motor de análisis,
+// p. ej. el fake de service/scantest
+func NewScannerWithBackend(backend Backend) *Scanner {
 	return &Scanner{
-		client: c,
+		backend: backend,
+		polling: DefaultPolling,
 	}
 }
 
+// SetPolling cambia los intervalos de consulta; los valores en cero conservan
+// los de DefaultPolling
+func (s *Scanner) SetPolling(polling Polling) {
+	if polling.Initial <= 0 {
+		polling.Initial = DefaultPolling.Initial
+	}
+	if polling.Running <= 0 {
+		polling.Running = DefaultPolling.Running
+	}
+	if polling.MaxWait <= 0 {
+		polling.MaxWait = DefaultPolling.MaxWait
+	}
+	s.polling = polling
+}
+
+// SetCache habilita la caché local de resultados terminados
+func (s *Scanner) SetCache(store *cache.Store, mode CacheMode) {
+	s.cache = store
+	s.cacheMode = mode
+}
+
+// SetHistory guarda cada análisis terminado en el historial; los resultados
+// tomados de la caché local no se vuelven a guardar
+func (s *Scanner) SetHistory(store *history.Store) {
+	s.history = store
+}
+
 func ValidateDomain(domain string) error {
 	if domain == "" {
 		return fmt.Errorf("el dominio no puede estar vacío")
@@ -55,54 +147,124 @@ func ValidateDomain(domain string) error {
 	return nil
 }
 
-// RunAnalysis ejecuta el flujo completo: validar, iniciar y esperar resultado
-func (s *Scanner) RunAnalysis(ctx context.Context, domain string) (*model.Host, error) {
-	if err := ValidateDomain(domain); err != nil {
-		return nil, fmt.Errorf("validación fallida: %w", err)
+// ValidateTarget valida el destino con las reglas del motor: un dominio para
+// SSL Labs, o también IPs y host:puerto para el motor local
+func (s *Scanner) ValidateTarget(domain string) error {
+	if validator, ok := s.backend.(TargetValidator); ok {
+		return validator.ValidateTarget(domain)
 	}
+	return ValidateDomain(domain)
+}
 
-	info, err := s.client.GetInfo(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("no se pudo verificar disponibilidad del servicio: %w", err)
+// RunAnalysis ejecuta el flujo completo: validar, consultar la caché, iniciar y
+// esperar resultado. Al terminar emite un evento Completed.
+func (s *Scanner) RunAnalysis(ctx context.Context, domain string, opts ScanOptions) (*model.Host, error) {
+	start := time.Now()
+	host, cached, err := s.runAnalysis(ctx, domain, opts)
+	s.emit(Completed{Domain: domain, Host: host, Err: err, Elapsed: time.Since(start), Cached: cached})
+	return host, err
+}
+
+func (s *Scanner) runAnalysis(ctx context.Context, domain string, opts ScanOptions) (*model.Host, bool, error) {
+	if err := s.ValidateTarget(domain); err != nil {
+		return nil, false, fmt.Errorf("validación fallida: %w", err)
 	}
 
-	if info.CurrentAssessments >= info.MaxAssessments {
-		return nil, fmt.Errorf("límite de análisis concurrentes alcanzado (%d/%d)",
-			info.CurrentAssessments, info.MaxAssessments)
+	if err := opts.Validate(); err != nil {
+		return nil, false, fmt.Errorf("validación fallida: %w", err)
 	}
 
-	host, err := s.client.StartAnalysis(ctx, domain)
-	if err != nil {
-		return nil, fmt.Errorf("no se pudo iniciar el análisis: %w", err)
+	// la caché se consulta antes de cualquier llamada de red
+	if host, ok, err := s.lookupCache(domain, opts); ok || err != nil {
+		return host, ok, err
 	}
 
-	if host.Status == StatusReady || host.Status == StatusError {
-		return host, nil
+	// GetInfo verifica la disponibilidad y actualiza el cool-off del cliente;
+	// si no hay cupo, StartAnalysis espera a que se libere uno
+	if _, err := s.backend.GetInfo(ctx); err != nil {
+		return nil, false, fmt.Errorf("no se pudo verificar disponibilidad del servicio: %w", err)
 	}
 
-	// Verificar que la caché tenga los resultados antes de hacer polling (con CheckDomainInCache)
-	cacheFilePath := fmt.Sprintf("cache/%s.json", domain)
-	inCache, err := client.CheckDomainInCache(cacheFilePath, domain)
+	host, err := s.backend.StartAnalysis(ctx, domain, opts.analyzeOptions())
 	if err != nil {
-		return nil, fmt.Errorf("error al verificar caché: %w", err)
+		return nil, false, fmt.Errorf("no se pudo iniciar el análisis: %w", err)
 	}
-	if inCache {
-		host, err := client.LoadLocalCache(cacheFilePath, domain)
-		if err == nil {
-			return host, nil
+	s.emit(StatusChanged{Domain: domain, Options: opts, Status: host.Status, Message: host.StatusMessage})
+
+	if host.Status == StatusError {
+		return nil, false, fmt.Errorf("el análisis terminó con error: %s", host.StatusMessage)
+	}
+
+	if host.Status != StatusReady {
+		s.emitProgress(domain, opts, host)
+		host, err = s.pollAnalysisStatus(ctx, domain, opts, host.Status)
+		if err != nil {
+			return nil, false, err
 		}
-		// Si hay error al cargar la caché, continuar con el polling
 	}
 
+	s.storeCache(domain, opts, host)
+	s.storeHistory(domain, host)
 
+	return host, false, nil
+}
+
+// lookupCache devuelve el resultado en caché si está fresco. En modo CacheOnly
+// la ausencia de un resultado fresco es un error.
+func (s *Scanner) lookupCache(domain string, opts ScanOptions) (*model.Host, bool, error) {
+	if s.cache == nil || s.cacheMode == CacheRefresh || (opts.ForceNew && s.cacheMode != CacheOnly) {
+		return nil, false, nil
+	}
+
+	entry, err := s.cache.Get(cacheKey(domain, opts))
+	if err == nil {
+		s.emit(CacheHit{Domain: domain, Age: entry.Age()})
+		return entry.Host, true, nil
+	}
+
+	if s.cacheMode == CacheOnly {
+		return nil, false, fmt.Errorf("%s: %w", domain, ErrNotCached)
+	}
 
-	return s.pollAnalysisStatus(ctx, domain)
+	if !errors.Is(err, cache.ErrNotFound) && !errors.Is(err, cache.ErrExpired) {
+		s.emit(Warning{Domain: domain, Err: fmt.Errorf("no se pudo leer la caché local: %w", err)})
+	}
+
+	return nil, false, nil
+}
+
+// storeCache guarda solo análisis terminados; un fallo al escribir no aborta el análisis
+func (s *Scanner) storeCache(domain string, opts ScanOptions, host *model.Host) {
+	if s.cache == nil || host.Status != StatusReady {
+		return
+	}
+
+	if err := s.cache.Put(cacheKey(domain, opts), host); err != nil {
+		s.emit(Warning{Domain: domain, Err: fmt.Errorf("no se pudo guardar en caché local: %w", err)})
+	}
 }
 
-// pollAnalysisStatus hace polling hasta que el análisis termine o falle
-func (s *Scanner) pollAnalysisStatus(ctx context.Context, domain string) (*model.Host, error) {
+// storeHistory agrega el análisis al historial; como la caché, un fallo no aborta el análisis
+func (s *Scanner) storeHistory(domain string, host *model.Host) {
+	if s.history == nil || host.Status != StatusReady {
+		return
+	}
+
+	if err := s.history.Append(domain, host); err != nil {
+		s.emit(Warning{Domain: domain, Err: fmt.Errorf("no se pudo guardar en el historial: %w", err)})
+	}
+}
+
+func cacheKey(domain string, opts ScanOptions) string {
+	return cache.Key(domain, opts.cacheOptions()...)
+}
+
+// pollAnalysisStatus hace polling hasta que el análisis termine o falle. status
+// es el último estado informado, para emitir StatusChanged solo en los cambios.
+func (s *Scanner) pollAnalysisStatus(ctx context.Context, domain string, opts ScanOptions, status string) (*model.Host, error) {
+	analyzeOpts := opts.analyzeOptions()
 	startTime := time.Now()
-	pollInterval := PollIntervalInitial
+	pollInterval := s.polling.Initial
 
 	for {
 		select {
@@ -111,50 +273,35 @@ func (s *Scanner) pollAnalysisStatus(ctx context.Context, domain string) (*model
 		case <-time.After(pollInterval):
 		}
 
-		if time.Since(startTime) > MaxWaitTime {
-			return nil, fmt.Errorf("tiempo máximo de espera excedido (%v)", MaxWaitTime)
-		}
-
-		host, err := s.client.CheckAnalysisStatus(ctx, domain)
-
-		// Guardad host en caché local cada vez que se obtiene un estado actualizado
-		cacheFilePath := fmt.Sprintf("cache/%s.json", domain)
-		if err == nil {
-			saveErr := client.SaveToLocalCache(cacheFilePath, host)
-			if saveErr != nil {
-				fmt.Printf("Advertencia: no se pudo guardar en caché local: %v\n", saveErr)
-			}
+		if time.Since(startTime) > s.polling.MaxWait {
+			return nil, fmt.Errorf("tiempo máximo de espera excedido (%v)", s.polling.MaxWait)
 		}
 
+		host, err := s.backend.CheckAnalysisStatus(ctx, domain, analyzeOpts)
 		if err != nil {
 			return nil, fmt.Errorf("error al consultar estado: %w", err)
 		}
 
+		if host.Status != status {
+			status = host.Status
+			s.emit(StatusChanged{Domain: domain, Options: opts, Status: host.Status, Message: host.StatusMessage})
+		}
+
 		switch host.Status {
 		case StatusReady:
-			
-
 			return host, nil
 		case StatusError:
 			return nil, fmt.Errorf("el análisis terminó con error: %s", host.StatusMessage)
 		case StatusInProgress:
-			pollInterval = PollIntervalRunning
-			s.reportProgress(host)
+			pollInterval = s.polling.Running
+			s.emitProgress(domain, opts, host)
 		case StatusDNS:
-			pollInterval = PollIntervalInitial
-		}
-	}
-}
-
-func (s *Scanner) reportProgress(host *model.Host) {
-	for _, endpoint := range host.Endpoints {
-		if endpoint.Progress >= 0 {
-			fmt.Printf("  [%s] Progreso: %d%% - %s\n",
-				endpoint.IPAddress, endpoint.Progress, endpoint.StatusDetailsMessage)
+			pollInterval = s.polling.Initial
+			s.emitProgress(domain, opts, host)
 		}
 	}
 }
 
 func (s *Scanner) GetServiceInfo(ctx context.Context) (*model.Info, error) {
-	return s.client.GetInfo(ctx)
+	return s.backend.GetInfo(ctx)
 }
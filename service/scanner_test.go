@@ -0,0 +1,365 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sslscanner/cache"
+	"sslscanner/model"
+	"sslscanner/service"
+	"sslscanner/service/scantest"
+)
+
+// recorder guarda los eventos del Scanner; en lotes llegan desde varios workers
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) observe(event service.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, describe(event))
+}
+
+func (r *recorder) all() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+// describe resume un evento sin los campos que dependen del tiempo
+func describe(event service.Event) string {
+	switch e := event.(type) {
+	case service.StatusChanged:
+		return fmt.Sprintf("status %s %s", e.Domain, e.Status)
+	case service.EndpointProgress:
+		return fmt.Sprintf("progress %s %s %d %s", e.Domain, e.IPAddress, e.Progress, e.Details)
+	case service.CacheHit:
+		return "cache " + e.Domain
+	case service.Warning:
+		return "warning " + e.Domain
+	case service.Retry:
+		return fmt.Sprintf("retry %d", e.Attempt)
+	case service.Completed:
+		return fmt.Sprintf("completed %s cached=%t err=%t", e.Domain, e.Cached, e.Err != nil)
+	}
+	return fmt.Sprintf("%T", event)
+}
+
+func resultHost(domain string) *model.Host {
+	return &model.Host{
+		Host:     domain,
+		Port:     443,
+		Protocol: "http",
+		Endpoints: []model.Endpoint{
+			{IPAddress: "192.0.2.1", StatusMessage: "Ready", Grade: "A+", Progress: 100},
+		},
+	}
+}
+
+func newTestScanner(backend *scantest.Backend) (*service.Scanner, *recorder) {
+	scanner := service.NewScannerWithBackend(backend)
+	scanner.SetPolling(service.Polling{Initial: time.Millisecond, Running: time.Millisecond})
+
+	events := &recorder{}
+	scanner.SetObserver(events.observe)
+	return scanner, events
+}
+
+func methods(calls []scantest.Call) []string {
+	names := []string{}
+	for _, call := range calls {
+		names = append(names, call.Method)
+	}
+	return names
+}
+
+func assertStrings(t *testing.T, what string, got, want []string) {
+	t.Helper()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("%s:\n  obtenidos: %q\n  esperados: %q", what, got, want)
+	}
+}
+
+func TestRunAnalysisReady(t *testing.T) {
+	backend := scantest.New()
+	backend.Script("example.com", resultHost("example.com"),
+		scantest.DNS(),
+		scantest.InProgress(30, "Testing protocols"),
+		scantest.InProgress(80, "Testing HSTS"),
+		scantest.Ready(),
+	)
+	scanner, events := newTestScanner(backend)
+
+	host, err := scanner.RunAnalysis(context.Background(), "example.com", service.DefaultScanOptions())
+	if err != nil {
+		t.Fatalf("RunAnalysis: %v", err)
+	}
+	if host.Status != service.StatusReady || host.Endpoints[0].Grade != "A+" {
+		t.Errorf("host = %s con calificación %q", host.Status, host.Endpoints[0].Grade)
+	}
+
+	assertStrings(t, "eventos", events.all(), []string{
+		"status example.com DNS",
+		"status example.com IN_PROGRESS",
+		"progress example.com 192.0.2.1 30 Testing protocols",
+		"progress example.com 192.0.2.1 80 Testing HSTS",
+		"status example.com READY",
+		"completed example.com cached=false err=false",
+	})
+	assertStrings(t, "llamadas", methods(backend.Calls()), []string{
+		"GetInfo", "StartAnalysis", "CheckAnalysisStatus", "CheckAnalysisStatus", "CheckAnalysisStatus",
+	})
+}
+
+func TestRunAnalysisError(t *testing.T) {
+	backend := scantest.New()
+	backend.Script("example.com", nil, scantest.DNS(), scantest.Failed("Unable to resolve domain name"))
+	scanner, events := newTestScanner(backend)
+
+	_, err := scanner.RunAnalysis(context.Background(), "example.com", service.DefaultScanOptions())
+	if err == nil || !strings.Contains(err.Error(), "Unable to resolve domain name") {
+		t.Fatalf("err = %v, se esperaba el mensaje de SSL Labs", err)
+	}
+
+	assertStrings(t, "eventos", events.all(), []string{
+		"status example.com DNS",
+		"status example.com ERROR",
+		"completed example.com cached=false err=true",
+	})
+}
+
+func TestRunAnalysisImmediateError(t *testing.T) {
+	backend := scantest.New()
+	backend.Script("example.com", nil, scantest.Failed("Unable to resolve domain name"))
+	scanner, _ := newTestScanner(backend)
+
+	host, err := scanner.RunAnalysis(context.Background(), "example.com", service.DefaultScanOptions())
+	if err == nil || !strings.Contains(err.Error(), "Unable to resolve domain name") {
+		t.Fatalf("host = %+v, err = %v; un ERROR en la primera respuesta también es un error", host, err)
+	}
+	assertStrings(t, "llamadas", methods(backend.Calls()), []string{"GetInfo", "StartAnalysis"})
+}
+
+func TestRunAnalysisStatusCheckFails(t *testing.T) {
+	backend := scantest.New()
+	backend.Script("example.com", nil, scantest.DNS(), scantest.Fail(errors.New("conexión rechazada")))
+	scanner, _ := newTestScanner(backend)
+
+	_, err := scanner.RunAnalysis(context.Background(), "example.com", service.DefaultScanOptions())
+	if err == nil || !strings.Contains(err.Error(), "conexión rechazada") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestRunAnalysisInvalidTarget(t *testing.T) {
+	backend := scantest.New()
+	scanner, _ := newTestScanner(backend)
+
+	for _, domain := range []string{"", "no es un dominio", "example"} {
+		if _, err := scanner.RunAnalysis(context.Background(), domain, service.DefaultScanOptions()); err == nil {
+			t.Errorf("RunAnalysis(%q) no falló", domain)
+		}
+	}
+	if calls := backend.Calls(); len(calls) != 0 {
+		t.Errorf("un destino inválido no debería llegar al backend: %v", methods(calls))
+	}
+}
+
+func TestRunAnalysisOptions(t *testing.T) {
+	backend := scantest.New()
+	backend.Script("example.com", resultHost("example.com"))
+	scanner, _ := newTestScanner(backend)
+
+	if _, err := scanner.RunAnalysis(context.Background(), "example.com", service.ScanOptions{ForceNew: true, Publish: true}); err != nil {
+		t.Fatalf("RunAnalysis: %v", err)
+	}
+
+	calls := backend.Calls()
+	start := calls[len(calls)-1]
+	if start.Method != "StartAnalysis" || !start.Options.StartNew || start.Options.FromCache || !start.Options.Publish {
+		t.Errorf("StartAnalysis recibió %+v", start.Options)
+	}
+}
+
+func TestRunAnalysisUsesCacheBeforeNetwork(t *testing.T) {
+	backend := scantest.New()
+	backend.Script("example.com", resultHost("example.com"), scantest.InProgress(50, "Testing protocols"), scantest.Ready())
+	scanner, events := newTestScanner(backend)
+	scanner.SetCache(cache.New(t.TempDir(), time.Hour), service.CacheDefault)
+
+	if _, err := scanner.RunAnalysis(context.Background(), "example.com", service.DefaultScanOptions()); err != nil {
+		t.Fatalf("primer RunAnalysis: %v", err)
+	}
+	networkCalls := len(backend.Calls())
+
+	host, err := scanner.RunAnalysis(context.Background(), "example.com", service.DefaultScanOptions())
+	if err != nil {
+		t.Fatalf("segundo RunAnalysis: %v", err)
+	}
+	if host.Endpoints[0].Grade != "A+" {
+		t.Errorf("resultado en caché = %+v", host)
+	}
+	if calls := backend.Calls(); len(calls) != networkCalls {
+		t.Errorf("el resultado en caché no debería consultar el backend: %v", methods(calls[networkCalls:]))
+	}
+
+	all := events.all()
+	assertStrings(t, "eventos del segundo análisis", all[len(all)-2:], []string{
+		"cache example.com",
+		"completed example.com cached=true err=false",
+	})
+}
+
+func TestRunAnalysisCacheKeyIncludesIgnoreMismatch(t *testing.T) {
+	backend := scantest.New()
+	backend.Script("example.com", resultHost("example.com"))
+	scanner, events := newTestScanner(backend)
+	scanner.SetCache(cache.New(t.TempDir(), time.Hour), service.CacheDefault)
+
+	if _, err := scanner.RunAnalysis(context.Background(), "example.com", service.DefaultScanOptions()); err != nil {
+		t.Fatalf("primer RunAnalysis: %v", err)
+	}
+
+	opts := service.DefaultScanOptions()
+	opts.IgnoreMismatch = true
+	if _, err := scanner.RunAnalysis(context.Background(), "example.com", opts); err != nil {
+		t.Fatalf("segundo RunAnalysis: %v", err)
+	}
+
+	calls := backend.Calls()
+	if last := calls[len(calls)-1]; last.Method != "StartAnalysis" || !last.Options.IgnoreMismatch {
+		t.Errorf("con ignoreMismatch debería consultarse SSL Labs, última llamada: %+v", last)
+	}
+	for _, event := range events.all() {
+		if strings.HasPrefix(event, "cache ") {
+			t.Errorf("no debería usarse el resultado sin ignoreMismatch: %v", events.all())
+		}
+	}
+}
+
+func TestRunAnalysisCacheOnly(t *testing.T) {
+	backend := scantest.New()
+	backend.Script("example.com", resultHost("example.com"))
+	scanner, _ := newTestScanner(backend)
+	scanner.SetCache(cache.New(t.TempDir(), time.Hour), service.CacheOnly)
+
+	_, err := scanner.RunAnalysis(context.Background(), "example.com", service.DefaultScanOptions())
+	if !errors.Is(err, service.ErrNotCached) {
+		t.Errorf("err = %v, se esperaba ErrNotCached", err)
+	}
+	if calls := backend.Calls(); len(calls) != 0 {
+		t.Errorf("en modo sin conexión no debería consultarse el backend: %v", methods(calls))
+	}
+}
+
+func TestRunAnalysisCanceled(t *testing.T) {
+	backend := scantest.New()
+	// el análisis nunca termina: el último paso se repite
+	backend.Script("example.com", resultHost("example.com"), scantest.InProgress(10, "Testing protocols"))
+	scanner, _ := newTestScanner(backend)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scanner.SetObserver(func(event service.Event) {
+		if _, ok := event.(service.EndpointProgress); ok {
+			cancel()
+		}
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := scanner.RunAnalysis(ctx, "example.com", service.DefaultScanOptions())
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, se esperaba context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunAnalysis no volvió después de cancelar")
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	backend := scantest.New()
+	backend.Script("a.example.com", resultHost("a.example.com"), scantest.DNS(), scantest.Ready())
+	backend.Script("b.example.com", nil, scantest.Failed("Unable to connect to the server"))
+	backend.Script("c.example.com", resultHost("c.example.com"), scantest.InProgress(50, "Testing protocols"), scantest.Ready())
+	scanner, events := newTestScanner(backend)
+
+	domains := []string{"a.example.com", "b.example.com", "c.example.com"}
+	var finished []string
+	results, err := scanner.RunBatch(context.Background(), domains, service.DefaultScanOptions(), 2, func(result service.BatchResult) {
+		finished = append(finished, result.Domain)
+	})
+	if err != nil {
+		t.Fatalf("RunBatch: %v", err)
+	}
+
+	if len(finished) != len(domains) {
+		t.Errorf("onResult se invocó para %v", finished)
+	}
+	for i, result := range results {
+		if result.Domain != domains[i] {
+			t.Errorf("resultado %d = %s, se esperaba el orden de entrada", i, result.Domain)
+		}
+		if failed := result.Err != nil; failed != (result.Domain == "b.example.com") {
+			t.Errorf("%s: err = %v", result.Domain, result.Err)
+		}
+	}
+
+	completed := 0
+	for _, event := range events.all() {
+		if strings.HasPrefix(event, "completed ") {
+			completed++
+		}
+	}
+	if completed != len(domains) {
+		t.Errorf("%d eventos Completed, se esperaban %d", completed, len(domains))
+	}
+}
+
+func TestRunBatchCanceled(t *testing.T) {
+	backend := scantest.New()
+	backend.Script("a.example.com", resultHost("a.example.com"), scantest.InProgress(10, "Testing protocols"))
+	backend.Script("b.example.com", resultHost("b.example.com"))
+	scanner, _ := newTestScanner(backend)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scanner.SetObserver(func(event service.Event) {
+		if _, ok := event.(service.EndpointProgress); ok {
+			cancel()
+		}
+	})
+
+	// con un solo worker, b.example.com no llega a empezar
+	results, err := scanner.RunBatch(ctx, []string{"a.example.com", "b.example.com"}, service.DefaultScanOptions(), 1, nil)
+	if err != nil {
+		t.Fatalf("RunBatch: %v", err)
+	}
+	for _, result := range results {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("%s: err = %v, se esperaba context.Canceled", result.Domain, result.Err)
+		}
+	}
+}
+
+func TestRunBatchServiceUnavailable(t *testing.T) {
+	backend := scantest.New()
+	backend.SetInfo(model.Info{}, errors.New("servicio en mantenimiento"))
+	scanner, _ := newTestScanner(backend)
+
+	if _, err := scanner.RunBatch(context.Background(), []string{"example.com"}, service.DefaultScanOptions(), 1, nil); err == nil {
+		t.Fatal("RunBatch debería fallar si GetInfo falla")
+	}
+}
@@ -0,0 +1,236 @@
+// Package scantest ofrece un service.Backend en memoria que recorre una
+// secuencia de estados predefinida, para probar código construido sobre
+// service.Scanner sin acceso a la red.
+package scantest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"sslscanner/client"
+	"sslscanner/model"
+)
+
+// Step es el estado que devuelve una consulta de /analyze
+type Step struct {
+	// Status es DNS, IN_PROGRESS, READY o ERROR
+	Status string
+	// StatusMessage acompaña a los estados DNS y ERROR
+	StatusMessage string
+	// Progress y Details se asignan a cada endpoint en IN_PROGRESS
+	Progress int
+	Details  string
+	// Err hace que la consulta falle, p. ej. con un *client.APIError
+	Err error
+}
+
+// DNS es el estado inicial mientras SSL Labs resuelve el dominio
+func DNS() Step {
+	return Step{Status: "DNS", StatusMessage: "Resolving domain names"}
+}
+
+// InProgress es un análisis en curso con el progreso y el test actual de cada endpoint
+func InProgress(progress int, details string) Step {
+	return Step{Status: "IN_PROGRESS", Progress: progress, Details: details}
+}
+
+// Ready entrega el resultado registrado con Script
+func Ready() Step {
+	return Step{Status: "READY"}
+}
+
+// Failed es un análisis que SSL Labs terminó con error
+func Failed(message string) Step {
+	return Step{Status: "ERROR", StatusMessage: message}
+}
+
+// Fail hace que la consulta devuelva err en lugar de un estado
+func Fail(err error) Step {
+	return Step{Err: err}
+}
+
+// Call registra una llamada recibida por el Backend
+type Call struct {
+	Method  string
+	Domain  string
+	Options client.AnalyzeOptions
+}
+
+type script struct {
+	result *model.Host
+	steps  []Step
+	next   int
+}
+
+// Backend implementa service.Backend. Cada StartAnalysis o CheckAnalysisStatus
+// avanza un paso del guion del dominio; el último paso se repite.
+type Backend struct {
+	mu      sync.Mutex
+	info    model.Info
+	infoErr error
+	scripts map[string]*script
+	calls   []Call
+}
+
+func New() *Backend {
+	return &Backend{
+		info: model.Info{
+			EngineVersion:   "scantest",
+			CriteriaVersion: "2009q",
+			MaxAssessments:  25,
+		},
+		scripts: make(map[string]*script),
+	}
+}
+
+// SetInfo reemplaza la respuesta de GetInfo; con err distinto de nil GetInfo falla
+func (b *Backend) SetInfo(info model.Info, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.info = info
+	b.infoErr = err
+}
+
+// Script registra el guion de un dominio. result es el host que se entrega en
+// READY; sus endpoints también se usan para el progreso de IN_PROGRESS.
+// Sin pasos el análisis se entrega terminado en la primera consulta.
+func (b *Backend) Script(domain string, result *model.Host, steps ...Step) {
+	if len(steps) == 0 {
+		steps = []Step{Ready()}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.scripts[domain] = &script{result: result, steps: steps}
+}
+
+// Calls devuelve las llamadas recibidas en orden
+func (b *Backend) Calls() []Call {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]Call(nil), b.calls...)
+}
+
+func (b *Backend) GetInfo(ctx context.Context) (*model.Info, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.calls = append(b.calls, Call{Method: "GetInfo"})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if b.infoErr != nil {
+		return nil, b.infoErr
+	}
+
+	info := b.info
+	return &info, nil
+}
+
+func (b *Backend) StartAnalysis(ctx context.Context, domain string, opts client.AnalyzeOptions) (*model.Host, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("opciones de análisis inválidas: %w", err)
+	}
+	return b.advance(ctx, "StartAnalysis", domain, opts)
+}
+
+func (b *Backend) CheckAnalysisStatus(ctx context.Context, domain string, opts client.AnalyzeOptions) (*model.Host, error) {
+	return b.advance(ctx, "CheckAnalysisStatus", domain, opts)
+}
+
+func (b *Backend) GetEndpointDetails(ctx context.Context, domain, ipAddress string) (*model.Endpoint, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.calls = append(b.calls, Call{Method: "GetEndpointDetails", Domain: domain})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s, ok := b.scripts[domain]
+	if !ok || s.result == nil {
+		return nil, fmt.Errorf("scantest: dominio sin guion: %s", domain)
+	}
+
+	for _, endpoint := range copyHost(s.result).Endpoints {
+		if endpoint.IPAddress == ipAddress {
+			return &endpoint, nil
+		}
+	}
+
+	return nil, fmt.Errorf("scantest: endpoint %s no encontrado en %s", ipAddress, domain)
+}
+
+func (b *Backend) advance(ctx context.Context, method, domain string, opts client.AnalyzeOptions) (*model.Host, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.calls = append(b.calls, Call{Method: method, Domain: domain, Options: opts})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s, ok := b.scripts[domain]
+	if !ok {
+		return nil, fmt.Errorf("scantest: dominio sin guion: %s", domain)
+	}
+
+	step := s.steps[s.next]
+	if s.next < len(s.steps)-1 {
+		s.next++
+	}
+
+	if step.Err != nil {
+		return nil, step.Err
+	}
+
+	return s.hostFor(domain, step), nil
+}
+
+// hostFor arma la respuesta de /analyze para el paso
+func (s *script) hostFor(domain string, step Step) *model.Host {
+	host := &model.Host{Host: domain, Port: 443, Protocol: "http"}
+	if s.result != nil {
+		host = copyHost(s.result)
+	}
+
+	host.Status = step.Status
+	host.StatusMessage = step.StatusMessage
+
+	switch step.Status {
+	case "DNS":
+		host.Endpoints = nil
+	case "IN_PROGRESS":
+		for i := range host.Endpoints {
+			endpoint := &host.Endpoints[i]
+			endpoint.StatusMessage = "In progress"
+			endpoint.Progress = step.Progress
+			endpoint.StatusDetailsMessage = step.Details
+			endpoint.Grade = ""
+			endpoint.Details = nil
+		}
+	case "ERROR":
+		host.Endpoints = nil
+	}
+
+	return host
+}
+
+// copyHost evita que quien recibe el resultado modifique el guion
+func copyHost(host *model.Host) *model.Host {
+	data, err := json.Marshal(host)
+	if err != nil {
+		panic(fmt.Sprintf("scantest: no se pudo copiar el host: %v", err))
+	}
+
+	var clone model.Host
+	if err := json.Unmarshal(data, &clone); err != nil {
+		panic(fmt.Sprintf("scantest: no se pudo copiar el host: %v", err))
+	}
+	return &clone
+}
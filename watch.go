@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sslscanner/history"
+	"sslscanner/service"
+	"sslscanner/watch"
+)
+
+// runWatch vigila los dominios de la configuración hasta recibir una señal
+func runWatch(args []string) int {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Archivo YAML con los dominios, schedules, alertas y notificadores")
+	statePath := fs.String("state", "", "Archivo de estado del modo watch (por defecto $XDG_DATA_HOME/sslscanner/watch-state.json)")
+	once := fs.Bool("once", false, "Analizar todos los dominios una vez y terminar")
+	concurrency := fs.Int("concurrency", service.DefaultBatchConcurrency, "Máximo de análisis simultáneos")
+	forceNew := fs.Bool("new", false, "Forzar un análisis nuevo en SSL Labs en lugar de reutilizar uno reciente")
+	verbose := fs.Bool("verbose", false, "Mostrar el progreso de cada análisis")
+	historyConfig := addHistoryFlags(fs)
+	engine := addEngineFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Analiza los dominios de la configuración según su schedule y envía alertas
+cuando baja la calificación, aparece una vulnerabilidad o un certificado se
+acerca al vencimiento. Los resultados se guardan en el historial.
+
+Uso:
+  sslscanner watch --config watch.yaml [opciones]
+
+Opciones:
+`)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitCodeInvalidArgs
+	}
+	if *configPath == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return exitCodeInvalidArgs
+	}
+
+	config, err := watch.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+
+	notifiers := make([]watch.Notifier, 0, len(config.Notifiers))
+	for _, notifierConfig := range config.Notifiers {
+		notifier, err := watch.NewNotifier(notifierConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCodeInvalidArgs
+		}
+		notifiers = append(notifiers, notifier)
+	}
+
+	store, err := historyConfig.open(*engine.engine)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+
+	if *statePath == "" {
+		dataDir, err := history.DataDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCodeInvalidArgs
+		}
+		*statePath = filepath.Join(dataDir, "watch-state.json")
+	}
+
+	scanner, err := engine.newScanner(engine.retryPolicy())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+	scanner.SetObserver(newProgressPrinter(os.Stderr, *verbose).observe)
+
+	opts := service.DefaultScanOptions()
+	opts.ForceNew = *forceNew
+
+	watcher, err := watch.New(scanner, store, config, notifiers, *statePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeInvalidArgs
+	}
+	watcher.SetScanOptions(opts)
+	watcher.SetConcurrency(*concurrency)
+	watcher.SetLogOutput(os.Stdout)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	setupSignalHandler(cancel)
+
+	if *once {
+		err = watcher.RunOnce(ctx)
+	} else {
+		err = watcher.Run(ctx)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCodeAnalysisError
+	}
+
+	return exitCodeSuccess
+}
@@ -0,0 +1,156 @@
+package watch
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"sslscanner/diff"
+	"sslscanner/expiry"
+	"sslscanner/model"
+)
+
+// Tipos de alerta
+const (
+	AlertGradeDrop        = "grade-drop"
+	AlertNewVulnerability = "new-vulnerability"
+	AlertCertExpiry       = "cert-expiry"
+)
+
+// Alert es un evento que se envía a los notificadores
+type Alert struct {
+	Kind        string              `json:"kind"`
+	Domain      string              `json:"domain"`
+	Time        time.Time           `json:"time"`
+	Summary     string              `json:"summary"`
+	Changes     []diff.Change       `json:"changes,omitempty"`
+	Certificate *expiry.Certificate `json:"certificate,omitempty"`
+}
+
+// Subject es el asunto corto de la alerta (p. ej. para el correo)
+func (a Alert) Subject() string {
+	return fmt.Sprintf("[sslscanner] %s: %s", a.Domain, alertTitles[a.Kind])
+}
+
+var alertTitles = map[string]string{
+	AlertGradeDrop:        "bajó la calificación",
+	AlertNewVulnerability: "nueva vulnerabilidad",
+	AlertCertExpiry:       "certificado por vencer",
+}
+
+// compareResults genera las alertas de calificación y vulnerabilidades entre
+// dos análisis del mismo dominio; sin análisis previo no hay con qué comparar
+func compareResults(domain string, previous, current *model.Host, rules AlertRules, now time.Time) []Alert {
+	if previous == nil {
+		return nil
+	}
+
+	report := diff.Compare(previous, current)
+
+	var grades, vulnerabilities []diff.Change
+	var gradeLines, vulnerabilityLines []string
+	for _, endpoint := range report.Endpoints {
+		for _, change := range endpoint.Changes {
+			if !change.Regression() {
+				continue
+			}
+			switch {
+			case change.Category == diff.CategoryGrade && change.Item == "grade":
+				grades = append(grades, change)
+				gradeLines = append(gradeLines, fmt.Sprintf("%s: %s → %s", endpoint.IPAddress, orDash(change.Before), orDash(change.After)))
+			case change.Category == diff.CategoryVulnerability:
+				vulnerabilities = append(vulnerabilities, change)
+				vulnerabilityLines = append(vulnerabilityLines, fmt.Sprintf("%s: %s", endpoint.IPAddress, vulnerabilityLabel(change)))
+			}
+		}
+	}
+
+	alerts := []Alert{}
+	if rules.gradeDrop() && len(grades) > 0 {
+		alerts = append(alerts, Alert{
+			Kind:    AlertGradeDrop,
+			Domain:  domain,
+			Time:    now,
+			Summary: "La calificación bajó en " + strings.Join(gradeLines, ", "),
+			Changes: grades,
+		})
+	}
+	if rules.newVulnerability() && len(vulnerabilities) > 0 {
+		alerts = append(alerts, Alert{
+			Kind:    AlertNewVulnerability,
+			Domain:  domain,
+			Time:    now,
+			Summary: "Nuevas vulnerabilidades en " + strings.Join(vulnerabilityLines, ", "),
+			Changes: vulnerabilities,
+		})
+	}
+
+	return alerts
+}
+
+// expiryAlerts genera una alerta por certificado dentro de la ventana de aviso.
+// notified es el último estado avisado de cada certificado, para avisar solo
+// cuando entra en la ventana o empeora (aviso → crítico → vencido); se devuelve
+// actualizado y sin los certificados que ya no se ven.
+func expiryAlerts(domain string, host *model.Host, rules AlertRules, notified map[string]string, now time.Time) ([]Alert, map[string]string) {
+	if rules.CertDays <= 0 {
+		return nil, nil
+	}
+
+	report := expiry.NewReport(rules.expiryThresholds(), now)
+	report.AddHost(domain, host)
+
+	alerts := []Alert{}
+	current := make(map[string]string)
+	for _, cert := range report.Certificates {
+		if cert.Status == expiry.StatusOK {
+			continue
+		}
+
+		key := certKey(cert)
+		current[key] = cert.Status
+		if expiryRank[notified[key]] >= expiryRank[cert.Status] {
+			continue
+		}
+
+		summary := fmt.Sprintf("El certificado %s vence en %d días (%s)", cert.Subject, cert.DaysRemaining, cert.NotAfter.Format("2006-01-02"))
+		if cert.Status == expiry.StatusExpired {
+			summary = fmt.Sprintf("El certificado %s venció el %s", cert.Subject, cert.NotAfter.Format("2006-01-02"))
+		}
+
+		alerts = append(alerts, Alert{
+			Kind:        AlertCertExpiry,
+			Domain:      domain,
+			Time:        now,
+			Summary:     summary,
+			Certificate: &cert,
+		})
+	}
+
+	return alerts, current
+}
+
+// expiryRank ordena los estados de vencimiento de menos a más grave
+var expiryRank = map[string]int{
+	expiry.StatusWarning:  1,
+	expiry.StatusCritical: 2,
+	expiry.StatusExpired:  3,
+}
+
+func certKey(cert expiry.Certificate) string {
+	return cert.Position + "|" + cert.Subject + "|" + cert.NotAfter.Format(time.RFC3339)
+}
+
+func vulnerabilityLabel(change diff.Change) string {
+	if change.Item == "fallbackScsv" {
+		return "sin TLS_FALLBACK_SCSV"
+	}
+	return change.Item
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
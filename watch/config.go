@@ -0,0 +1,208 @@
+package watch
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"sslscanner/expiry"
+)
+
+// DefaultEvery es el intervalo de los dominios sin schedule propio
+const DefaultEvery = 24 * time.Hour
+
+// Config es el archivo de configuración del modo watch
+type Config struct {
+	Defaults  Defaults         `yaml:"defaults"`
+	Alerts    AlertRules       `yaml:"alerts"`
+	Domains   []Target         `yaml:"domains"`
+	Notifiers []NotifierConfig `yaml:"notifiers"`
+}
+
+// Defaults se aplican a los dominios que no los definen
+type Defaults struct {
+	Every string `yaml:"every"`
+	Cron  string `yaml:"cron"`
+}
+
+// Target es un dominio vigilado con su schedule: un intervalo (every) o una
+// expresión cron, no ambos
+type Target struct {
+	Domain string `yaml:"domain"`
+	Every  string `yaml:"every"`
+	Cron   string `yaml:"cron"`
+
+	schedule Schedule
+}
+
+// Schedule devuelve el schedule ya validado
+func (t Target) Schedule() Schedule {
+	return t.schedule
+}
+
+// AlertRules elige qué alertas se envían. Las dos primeras están activas si no
+// se indican; CertDays es la ventana de aviso del vencimiento (0 = desactivada).
+type AlertRules struct {
+	GradeDrop        *bool `yaml:"gradeDrop"`
+	NewVulnerability *bool `yaml:"newVulnerability"`
+	CertDays         int   `yaml:"certDays"`
+	CertCriticalDays int   `yaml:"certCriticalDays"`
+}
+
+func (r AlertRules) gradeDrop() bool {
+	return r.GradeDrop == nil || *r.GradeDrop
+}
+
+func (r AlertRules) newVulnerability() bool {
+	return r.NewVulnerability == nil || *r.NewVulnerability
+}
+
+func (r AlertRules) expiryThresholds() expiry.Thresholds {
+	return expiry.Thresholds{Warning: r.CertDays, Critical: min(r.CertCriticalDays, r.CertDays)}
+}
+
+// NotifierConfig configura un notificador; Type es webhook, smtp o command y
+// cada tipo usa sus propios campos
+type NotifierConfig struct {
+	Type string `yaml:"type"`
+
+	// webhook
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+
+	// smtp; la contraseña se lee de la variable de entorno PasswordEnv
+	Host        string   `yaml:"host"`
+	Port        int      `yaml:"port"`
+	Username    string   `yaml:"username"`
+	PasswordEnv string   `yaml:"passwordEnv"`
+	From        string   `yaml:"from"`
+	To          []string `yaml:"to"`
+
+	// command; recibe la alerta en JSON por stdin
+	Command []string `yaml:"command"`
+
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// LoadConfig lee y valida la configuración YAML
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("falló al leer la configuración: %w", err)
+	}
+
+	config := Config{
+		Alerts: AlertRules{CertDays: expiry.DefaultWarningDays, CertCriticalDays: expiry.DefaultCriticalDays},
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("falló al decodificar la configuración YAML: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// Validate verifica la configuración y arma el schedule de cada dominio
+func (c *Config) Validate() error {
+	if len(c.Domains) == 0 {
+		return fmt.Errorf("configuración inválida: no hay dominios para vigilar")
+	}
+
+	if c.Alerts.CertDays < 0 || c.Alerts.CertCriticalDays < 0 {
+		return fmt.Errorf("configuración inválida: certDays y certCriticalDays no pueden ser negativos")
+	}
+
+	defaults, err := parseSchedule(c.Defaults.Every, c.Defaults.Cron)
+	if err != nil {
+		return fmt.Errorf("configuración inválida en defaults: %w", err)
+	}
+	if defaults == nil {
+		defaults = Interval(DefaultEvery)
+	}
+
+	seen := make(map[string]bool)
+	for i := range c.Domains {
+		target := &c.Domains[i]
+		target.Domain = strings.ToLower(strings.TrimSpace(target.Domain))
+		if target.Domain == "" {
+			return fmt.Errorf("configuración inválida: el dominio %d está vacío", i+1)
+		}
+		if seen[target.Domain] {
+			return fmt.Errorf("configuración inválida: %s está repetido", target.Domain)
+		}
+		seen[target.Domain] = true
+
+		schedule, err := parseSchedule(target.Every, target.Cron)
+		if err != nil {
+			return fmt.Errorf("configuración inválida en %s: %w", target.Domain, err)
+		}
+		if schedule == nil {
+			schedule = defaults
+		}
+		if schedule.Next(time.Now()).IsZero() {
+			return fmt.Errorf("configuración inválida en %s: el schedule nunca se cumple", target.Domain)
+		}
+		target.schedule = schedule
+	}
+
+	for i, notifier := range c.Notifiers {
+		if err := notifier.validate(); err != nil {
+			return fmt.Errorf("configuración inválida en el notificador %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// parseSchedule devuelve nil si no se indicó ninguno
+func parseSchedule(every, cron string) (Schedule, error) {
+	switch {
+	case every != "" && cron != "":
+		return nil, fmt.Errorf("every y cron no se pueden usar juntos")
+	case every != "":
+		interval, err := time.ParseDuration(every)
+		if err != nil {
+			return nil, fmt.Errorf("intervalo inválido %q: %w", every, err)
+		}
+		if interval < MinInterval {
+			return nil, fmt.Errorf("el intervalo debe ser de al menos %s", MinInterval)
+		}
+		return Interval(interval), nil
+	case cron != "":
+		return ParseCron(cron)
+	}
+	return nil, nil
+}
+
+func (n NotifierConfig) validate() error {
+	switch n.Type {
+	case "webhook":
+		if !strings.HasPrefix(n.URL, "http://") && !strings.HasPrefix(n.URL, "https://") {
+			return fmt.Errorf("webhook requiere una url http o https")
+		}
+	case "smtp":
+		if n.Host == "" || n.From == "" || len(n.To) == 0 {
+			return fmt.Errorf("smtp requiere host, from y to")
+		}
+	case "command":
+		if len(n.Command) == 0 {
+			return fmt.Errorf("command requiere el comando a ejecutar")
+		}
+	default:
+		return fmt.Errorf("tipo de notificador desconocido %q (webhook, smtp o command)", n.Type)
+	}
+
+	if n.Timeout < 0 {
+		return fmt.Errorf("timeout no puede ser negativo")
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultNotifyTimeout limita cada envío de una alerta
+const DefaultNotifyTimeout = 30 * time.Second
+
+// Notifier envía una alerta a un destino
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// NewNotifier crea el notificador de la configuración, que ya debe estar validada
+func NewNotifier(config NotifierConfig) (Notifier, error) {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = DefaultNotifyTimeout
+	}
+
+	switch config.Type {
+	case "webhook":
+		return &WebhookNotifier{
+			URL:     config.URL,
+			Headers: config.Headers,
+			client:  &http.Client{Timeout: timeout},
+		}, nil
+	case "smtp":
+		port := config.Port
+		if port == 0 {
+			port = 587
+		}
+		password := ""
+		if config.PasswordEnv != "" {
+			password = os.Getenv(config.PasswordEnv)
+			if password == "" {
+				return nil, fmt.Errorf("la variable de entorno %s no está definida", config.PasswordEnv)
+			}
+		}
+		return &SMTPNotifier{
+			Addr:     net.JoinHostPort(config.Host, strconv.Itoa(port)),
+			Username: config.Username,
+			Password: password,
+			From:     config.From,
+			To:       config.To,
+			Timeout:  timeout,
+		}, nil
+	case "command":
+		return &CommandNotifier{Command: config.Command, Timeout: timeout}, nil
+	}
+
+	return nil, fmt.Errorf("tipo de notificador desconocido %q", config.Type)
+}
+
+// WebhookNotifier envía la alerta en JSON por POST
+type WebhookNotifier struct {
+	URL     string
+	Headers map[string]string
+
+	client *http.Client
+}
+
+func (n *WebhookNotifier) Name() string {
+	return "webhook " + n.URL
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("falló al codificar la alerta: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("falló al crear la solicitud: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range n.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("falló al enviar el webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("el webhook respondió %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SMTPNotifier envía la alerta por correo; usa STARTTLS si el servidor lo ofrece
+type SMTPNotifier struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+	To       []string
+	Timeout  time.Duration
+}
+
+func (n *SMTPNotifier) Name() string {
+	return "smtp " + n.Addr
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, alert Alert) error {
+	var auth smtp.Auth
+	if n.Username != "" {
+		host, _, _ := net.SplitHostPort(n.Addr)
+		auth = smtp.PlainAuth("", n.Username, n.Password, host)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, n.Timeout)
+	defer cancel()
+
+	// smtp.SendMail no acepta contexto; se abandona la espera si se cancela
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(n.Addr, auth, n.From, n.To, n.message(alert))
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("falló al enviar el correo: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("falló al enviar el correo: %w", ctx.Err())
+	}
+}
+
+func (n *SMTPNotifier) message(alert Alert) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", n.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", alert.Subject())
+	fmt.Fprintf(&buf, "Date: %s\r\n", alert.Time.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+
+	fmt.Fprintf(&buf, "%s\r\n\r\n", alert.Summary)
+	fmt.Fprintf(&buf, "Dominio: %s\r\n", alert.Domain)
+	fmt.Fprintf(&buf, "Fecha: %s\r\n", alert.Time.Local().Format("2006-01-02 15:04:05"))
+	return buf.Bytes()
+}
+
+// CommandNotifier ejecuta un comando con la alerta en JSON por stdin y los
+// datos principales en variables de entorno SSLSCANNER_ALERT_*
+type CommandNotifier struct {
+	Command []string
+	Timeout time.Duration
+}
+
+func (n *CommandNotifier) Name() string {
+	return "command " + n.Command[0]
+}
+
+func (n *CommandNotifier) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("falló al codificar la alerta: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, n.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, n.Command[0], n.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"SSLSCANNER_ALERT_KIND="+alert.Kind,
+		"SSLSCANNER_ALERT_DOMAIN="+alert.Domain,
+		"SSLSCANNER_ALERT_SUMMARY="+alert.Summary,
+	)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("falló el comando %s: %w: %s", n.Command[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package watch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule calcula la próxima ejecución posterior a un instante
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// Interval repite el análisis cada cierto tiempo
+type Interval time.Duration
+
+func (i Interval) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(i))
+}
+
+func (i Interval) String() string {
+	return "cada " + time.Duration(i).String()
+}
+
+// MinInterval evita schedules que saturarían la API
+const MinInterval = time.Minute
+
+// cronSearchLimit acota la búsqueda de la próxima ejecución de expresiones que
+// nunca coinciden (p. ej. 30 de febrero)
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// cronMacros son los atajos habituales de cron
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Cron es una expresión cron de cinco campos: minuto, hora, día del mes, mes y
+// día de la semana (0 o 7 = domingo). Se evalúa en la hora local.
+type Cron struct {
+	expr                         string
+	minute, hour, dom, month     uint64
+	dow                          uint64
+	domRestricted, dowRestricted bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minuto", 0, 59},
+	{"hora", 0, 23},
+	{"día del mes", 1, 31},
+	{"mes", 1, 12},
+	{"día de la semana", 0, 7},
+}
+
+// ParseCron interpreta una expresión cron con *, listas (1,15), rangos (1-5),
+// pasos (*/15, 0-30/10) y los atajos @hourly, @daily, @weekly, @monthly y @yearly
+func ParseCron(expr string) (*Cron, error) {
+	spec := strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("expresión cron inválida %q: se esperan 5 campos", expr)
+	}
+
+	sets := make([]uint64, len(fields))
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("expresión cron inválida %q: %w", expr, err)
+		}
+		sets[i] = set
+	}
+
+	// 7 es otro nombre del domingo
+	dow := sets[4]
+	if dow&(1<<7) != 0 {
+		dow = dow&^(1<<7) | 1
+	}
+
+	return &Cron{
+		expr:          expr,
+		minute:        sets[0],
+		hour:          sets[1],
+		dom:           sets[2],
+		month:         sets[3],
+		dow:           dow,
+		domRestricted: !unrestricted(fields[2]),
+		dowRestricted: !unrestricted(fields[4]),
+	}, nil
+}
+
+// unrestricted indica si el campo abarca todos los valores; "*/1" equivale a "*"
+func unrestricted(field string) bool {
+	return field == "*" || field == "*/1"
+}
+
+func parseCronField(field string, spec cronField) (uint64, error) {
+	var set uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("paso inválido en el %s: %q", spec.name, part)
+			}
+			step = n
+		}
+
+		low, high := spec.min, spec.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = cronNumber(from, spec); err != nil {
+				return 0, err
+			}
+			if high, err = cronNumber(to, spec); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("rango inválido en el %s: %q", spec.name, part)
+			}
+		default:
+			n, err := cronNumber(rangePart, spec)
+			if err != nil {
+				return 0, err
+			}
+			low, high = n, n
+			// "5/15" equivale a "5-max/15"
+			if hasStep {
+				high = spec.max
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			set |= 1 << v
+		}
+	}
+
+	return set, nil
+}
+
+func cronNumber(text string, spec cronField) (int, error) {
+	n, err := strconv.Atoi(text)
+	if err != nil || n < spec.min || n > spec.max {
+		return 0, fmt.Errorf("valor inválido en el %s: %q (%d-%d)", spec.name, text, spec.min, spec.max)
+	}
+	return n, nil
+}
+
+// Next devuelve el primer minuto posterior a after que cumple la expresión, o
+// el instante cero si no hay ninguno en los próximos años
+func (c *Cron) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// dayMatches sigue la regla de cron: si se restringen el día del mes y el de la
+// semana, alcanza con que coincida uno de los dos
+func (c *Cron) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case c.domRestricted && c.dowRestricted:
+		return domMatch || dowMatch
+	case c.domRestricted:
+		return domMatch
+	case c.dowRestricted:
+		return dowMatch
+	}
+	return true
+}
+
+func (c *Cron) String() string {
+	return "cron " + c.expr
+}
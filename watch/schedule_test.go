@@ -0,0 +1,47 @@
+package watch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNextDayFields(t *testing.T) {
+	// el 2 de marzo de 2026 es lunes
+	at := func(day, hour int) time.Time {
+		return time.Date(2026, 3, day, hour, 0, 0, 0, time.Local)
+	}
+
+	for _, tc := range []struct {
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{"0 9 1 * *", at(1, 10), time.Date(2026, 4, 1, 9, 0, 0, 0, time.Local)},
+		// */1 no restringe el día de la semana
+		{"0 9 1 * */1", at(1, 10), time.Date(2026, 4, 1, 9, 0, 0, 0, time.Local)},
+		// ni el día del mes
+		{"0 9 */1 * 1", at(2, 10), at(9, 9)},
+		// con ambos restringidos alcanza con que coincida uno
+		{"0 9 1 * 1", at(30, 10), time.Date(2026, 4, 1, 9, 0, 0, 0, time.Local)},
+		{"0 9 1 * 1", at(2, 10), at(9, 9)},
+		// 7 es domingo
+		{"0 9 * * 7", at(2, 10), at(8, 9)},
+		{"@weekly", at(2, 10), at(8, 0)},
+	} {
+		cron, err := ParseCron(tc.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tc.expr, err)
+		}
+		if got := cron.Next(tc.after); !got.Equal(tc.want) {
+			t.Errorf("%q después de %v = %v, se esperaba %v", tc.expr, tc.after, got, tc.want)
+		}
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * * 8"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) no falló", expr)
+		}
+	}
+}
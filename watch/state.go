@@ -0,0 +1,70 @@
+package watch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"sslscanner/internal/fsutil"
+)
+
+// State es lo que el modo watch recuerda entre reinicios: cuándo se analizó
+// cada dominio y qué vencimientos ya se avisaron
+type State struct {
+	Domains map[string]*DomainState `json:"domains"`
+}
+
+type DomainState struct {
+	LastRun   time.Time `json:"lastRun,omitzero"`
+	NextRun   time.Time `json:"nextRun,omitzero"`
+	LastGrade string    `json:"lastGrade,omitempty"`
+	LastError string    `json:"lastError,omitempty"`
+	// NotifiedCerts es el último estado de vencimiento avisado por certificado
+	NotifiedCerts map[string]string `json:"notifiedCerts,omitempty"`
+}
+
+// LoadState lee el estado; si el archivo no existe devuelve uno vacío
+func LoadState(path string) (*State, error) {
+	state := &State{Domains: make(map[string]*DomainState)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("falló al leer el estado: %w", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("falló al decodificar el estado %s: %w", path, err)
+	}
+	if state.Domains == nil {
+		state.Domains = make(map[string]*DomainState)
+	}
+
+	return state, nil
+}
+
+// Save escribe el estado de forma atómica
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("falló al codificar el estado: %w", err)
+	}
+
+	if err := fsutil.WriteFileAtomic(path, data, 0o600); err != nil {
+		return fmt.Errorf("falló al guardar el estado: %w", err)
+	}
+	return nil
+}
+
+func (s *State) domain(name string) *DomainState {
+	state, ok := s.Domains[name]
+	if !ok {
+		state = &DomainState{}
+		s.Domains[name] = state
+	}
+	return state
+}
@@ -0,0 +1,243 @@
+// Package watch ejecuta el escáner como proceso de larga duración: analiza cada
+// dominio según su schedule, guarda los resultados en el historial y envía
+// alertas cuando baja la calificación, aparece una vulnerabilidad o un
+// certificado se acerca al vencimiento.
+package watch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"time"
+
+	"sslscanner/history"
+	"sslscanner/internal/logging"
+	"sslscanner/model"
+	"sslscanner/service"
+)
+
+// Watcher vigila los dominios de la configuración
+type Watcher struct {
+	scanner   *service.Scanner
+	history   *history.Store
+	config    *Config
+	notifiers []Notifier
+
+	statePath string
+	state     *State
+
+	opts        service.ScanOptions
+	concurrency int
+	log         logging.Logger
+}
+
+// New crea el watcher y carga el estado guardado. Los resultados se guardan en
+// store, que también es la fuente del análisis anterior de cada dominio.
+func New(scanner *service.Scanner, store *history.Store, config *Config, notifiers []Notifier, statePath string) (*Watcher, error) {
+	state, err := LoadState(statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner.SetHistory(store)
+
+	return &Watcher{
+		scanner:     scanner,
+		history:     store,
+		config:      config,
+		notifiers:   notifiers,
+		statePath:   statePath,
+		state:       state,
+		opts:        service.DefaultScanOptions(),
+		concurrency: service.DefaultBatchConcurrency,
+	}, nil
+}
+
+func (w *Watcher) SetScanOptions(opts service.ScanOptions) {
+	w.opts = opts
+}
+
+// SetConcurrency limita los análisis simultáneos; el límite de SSL Labs se
+// respeta igual que en el modo por lotes
+func (w *Watcher) SetConcurrency(concurrency int) {
+	w.concurrency = concurrency
+}
+
+// SetLogOutput redirige el registro de actividad (por defecto se descarta)
+func (w *Watcher) SetLogOutput(out io.Writer) {
+	w.log.SetOutput(out)
+}
+
+// Run analiza los dominios a medida que les toca hasta que se cancela ctx.
+// Los dominios sin análisis previo se analizan al arrancar. Al cancelar, los
+// análisis en curso se abandonan y se reintentan en el próximo arranque.
+func (w *Watcher) Run(ctx context.Context) error {
+	w.log.Printf("Vigilando %d dominios (%d notificadores)", len(w.config.Domains), len(w.notifiers))
+
+	for {
+		// al cancelar, los dominios sin analizar siguen pendientes: no hay que
+		// volver a intentarlos
+		if ctx.Err() != nil {
+			w.log.Printf("Deteniendo el modo watch")
+			return nil
+		}
+
+		due, next := w.due(time.Now())
+		if len(due) > 0 {
+			if err := w.scan(ctx, due); err != nil {
+				return err
+			}
+			continue
+		}
+
+		w.log.Printf("Próximo análisis: %s", next.Local().Format("2006-01-02 15:04:05"))
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			w.log.Printf("Deteniendo el modo watch")
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// RunOnce analiza todos los dominios una vez, sin esperar a su schedule
+func (w *Watcher) RunOnce(ctx context.Context) error {
+	domains := make([]string, len(w.config.Domains))
+	for i, target := range w.config.Domains {
+		domains[i] = target.Domain
+	}
+	return w.scan(ctx, domains)
+}
+
+// due devuelve los dominios a los que ya les toca y el próximo instante en que
+// le toca a alguno
+func (w *Watcher) due(now time.Time) ([]string, time.Time) {
+	due := []string{}
+	var next time.Time
+
+	for _, target := range w.config.Domains {
+		state := w.state.domain(target.Domain)
+
+		// el schedule puede haber cambiado desde el último arranque
+		runAt := now
+		if !state.LastRun.IsZero() {
+			runAt = target.Schedule().Next(state.LastRun)
+		}
+		state.NextRun = runAt
+
+		if runAt.IsZero() {
+			continue
+		}
+		if !runAt.After(now) {
+			due = append(due, target.Domain)
+			continue
+		}
+		if next.IsZero() || runAt.Before(next) {
+			next = runAt
+		}
+	}
+
+	sort.Strings(due)
+	return due, next
+}
+
+// scan analiza los dominios y procesa cada resultado a medida que termina
+func (w *Watcher) scan(ctx context.Context, domains []string) error {
+	previous := make(map[string]*model.Host, len(domains))
+	for _, domain := range domains {
+		records, err := w.history.List(domain)
+		if err != nil {
+			w.log.Printf("%s: no se pudo leer el historial: %v", domain, err)
+			continue
+		}
+		if len(records) > 0 {
+			previous[domain] = records[len(records)-1].Host
+		}
+	}
+
+	w.log.Printf("Analizando %d dominios", len(domains))
+	_, err := w.scanner.RunBatch(ctx, domains, w.opts, w.concurrency, func(result service.BatchResult) {
+		w.handleResult(ctx, result, previous[result.Domain])
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		// sin el servicio disponible se reintenta en el próximo ciclo
+		w.log.Printf("Error: %v", err)
+		return w.backOff(ctx)
+	}
+
+	return nil
+}
+
+// retryDelay es la espera cuando el servicio no está disponible
+const retryDelay = 5 * time.Minute
+
+func (w *Watcher) backOff(ctx context.Context) error {
+	w.log.Printf("Reintentando en %s", retryDelay)
+	select {
+	case <-ctx.Done():
+	case <-time.After(retryDelay):
+	}
+	return nil
+}
+
+func (w *Watcher) handleResult(ctx context.Context, result service.BatchResult, previous *model.Host) {
+	// un análisis cancelado no cuenta como ejecución: se repite al volver a arrancar
+	if errors.Is(result.Err, context.Canceled) {
+		return
+	}
+
+	now := time.Now()
+	state := w.state.domain(result.Domain)
+	state.LastRun = now
+	state.NextRun = w.schedule(result.Domain).Next(now)
+
+	if result.Err != nil {
+		state.LastError = result.Err.Error()
+		w.log.Printf("%s: %v", result.Domain, result.Err)
+		w.saveState()
+		return
+	}
+
+	state.LastError = ""
+	state.LastGrade = model.WorstGrade(result.Host)
+	w.log.Printf("%s: análisis terminado (%s)", result.Domain, model.DisplayGrade(state.LastGrade))
+
+	alerts := compareResults(result.Domain, previous, result.Host, w.config.Alerts, now)
+	expiring, notified := expiryAlerts(result.Domain, result.Host, w.config.Alerts, state.NotifiedCerts, now)
+	alerts = append(alerts, expiring...)
+	state.NotifiedCerts = notified
+
+	w.saveState()
+
+	// las alertas se envían aunque se esté cerrando el proceso
+	notifyCtx := context.WithoutCancel(ctx)
+	for _, alert := range alerts {
+		w.log.Printf("ALERTA %s: %s", alert.Domain, alert.Summary)
+		for _, notifier := range w.notifiers {
+			if err := notifier.Notify(notifyCtx, alert); err != nil {
+				w.log.Printf("%s: no se pudo notificar por %s: %v", alert.Domain, notifier.Name(), err)
+			}
+		}
+	}
+}
+
+func (w *Watcher) schedule(domain string) Schedule {
+	for _, target := range w.config.Domains {
+		if target.Domain == domain {
+			return target.Schedule()
+		}
+	}
+	return Interval(DefaultEvery)
+}
+
+func (w *Watcher) saveState() {
+	if err := w.state.Save(w.statePath); err != nil {
+		w.log.Printf("Advertencia: %v", err)
+	}
+}
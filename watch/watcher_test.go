@@ -0,0 +1,136 @@
+package watch
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sslscanner/history"
+	"sslscanner/model"
+	"sslscanner/service"
+	"sslscanner/service/scantest"
+)
+
+func testHost(domain string) *model.Host {
+	return &model.Host{
+		Host:     domain,
+		Port:     443,
+		Protocol: "http",
+		Endpoints: []model.Endpoint{
+			{IPAddress: "192.0.2.1", StatusMessage: "Ready", Grade: "A"},
+		},
+	}
+}
+
+func newTestWatcher(t *testing.T, backend *scantest.Backend, domains ...string) (*Watcher, *service.Scanner) {
+	t.Helper()
+
+	config := &Config{}
+	for _, domain := range domains {
+		config.Domains = append(config.Domains, Target{Domain: domain, Every: "1h"})
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	scanner := service.NewScannerWithBackend(backend)
+	scanner.SetPolling(service.Polling{Initial: 5 * time.Millisecond, Running: 5 * time.Millisecond})
+
+	dir := t.TempDir()
+	watcher, err := New(scanner, history.New(filepath.Join(dir, "history"), history.Retention{}), config, nil, filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	watcher.SetConcurrency(1)
+
+	return watcher, scanner
+}
+
+// runWithTimeout ejecuta Run y falla si no vuelve a tiempo
+func runWithTimeout(t *testing.T, ctx context.Context, watcher *Watcher) {
+	t.Helper()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- watcher.Run(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run devolvió %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run no volvió después de cancelar el contexto")
+	}
+}
+
+func TestRunReturnsWhenCanceledMidScan(t *testing.T) {
+	backend := scantest.New()
+	// el primer dominio nunca termina; el segundo no llega a empezar
+	backend.Script("a.example.com", testHost("a.example.com"), scantest.InProgress(10, "Testing protocols"))
+	backend.Script("b.example.com", testHost("b.example.com"))
+
+	watcher, scanner := newTestWatcher(t, backend, "a.example.com", "b.example.com")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scanner.SetObserver(func(event service.Event) {
+		if _, ok := event.(service.EndpointProgress); ok {
+			cancel()
+		}
+	})
+
+	runWithTimeout(t, ctx, watcher)
+
+	// los análisis cancelados no cuentan como ejecución
+	for _, domain := range []string{"a.example.com", "b.example.com"} {
+		if state := watcher.state.domain(domain); !state.LastRun.IsZero() {
+			t.Errorf("%s: LastRun = %v, se esperaba vacío", domain, state.LastRun)
+		}
+	}
+}
+
+func TestRunReturnsWhenServiceCheckIsCanceled(t *testing.T) {
+	backend := scantest.New()
+	backend.Script("a.example.com", testHost("a.example.com"))
+
+	watcher, _ := newTestWatcher(t, backend, "a.example.com")
+
+	// con el contexto ya cancelado GetInfo falla antes de empezar el lote
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	runWithTimeout(t, ctx, watcher)
+}
+
+func TestRunRecordsFinishedScans(t *testing.T) {
+	backend := scantest.New()
+	backend.Script("a.example.com", testHost("a.example.com"), scantest.DNS(), scantest.Ready())
+
+	watcher, scanner := newTestWatcher(t, backend, "a.example.com")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scanner.SetObserver(func(event service.Event) {
+		if _, ok := event.(service.Completed); ok {
+			cancel()
+		}
+	})
+
+	runWithTimeout(t, ctx, watcher)
+
+	state := watcher.state.domain("a.example.com")
+	if state.LastRun.IsZero() {
+		t.Fatal("LastRun vacío después de un análisis terminado")
+	}
+	if state.LastGrade != "A" {
+		t.Errorf("LastGrade = %q, se esperaba A", state.LastGrade)
+	}
+	if !state.NextRun.After(state.LastRun) {
+		t.Errorf("NextRun = %v, se esperaba después de %v", state.NextRun, state.LastRun)
+	}
+}